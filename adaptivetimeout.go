@@ -0,0 +1,83 @@
+package circuitbreaker
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// - is a BackoffPolicy deriving the open timeout from the observed recovery times,
+// the time from opening to closing again, so probing follows how long the dependency
+// actually needs, until the first recovery Initial is used
+type AdaptiveTimeout struct {
+	Quantile float64
+	Initial  time.Duration
+	Min      time.Duration
+	// zero means no upper bound
+	Max time.Duration
+
+	mu        sync.Mutex
+	history   int
+	recovered []time.Duration
+}
+
+// - is a constructor, the quantile (e.g. 0.9) is taken over the last history recoveries
+func NewAdaptiveTimeout(quantile float64, history int, initial, minTimeout, maxTimeout time.Duration) *AdaptiveTimeout {
+	return &AdaptiveTimeout{
+		Quantile: quantile,
+		Initial:  initial,
+		Min:      minTimeout,
+		Max:      maxTimeout,
+		history:  max(history, 1),
+	}
+}
+
+// - returns the quantile of the recovery times within Min and Max
+func (a *AdaptiveTimeout) OpenTimeout(int) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	timeout := a.Initial
+	if len(a.recovered) > 0 {
+		sorted := slices.Sorted(slices.Values(a.recovered))
+		index := int(a.Quantile*float64(len(sorted))+0.5) - 1
+		timeout = sorted[min(max(index, 0), len(sorted)-1)]
+	}
+
+	timeout = max(timeout, a.Min)
+	if a.Max > 0 {
+		timeout = min(timeout, a.Max)
+	}
+	return timeout
+}
+
+// - records the time the breaker needed to close after it opened
+func (a *AdaptiveTimeout) ObserveRecovery(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.recovered = append(a.recovered, d)
+	if len(a.recovered) > a.history {
+		a.recovered = a.recovered[len(a.recovered)-a.history:]
+	}
+}
+
+// - derives the open timeout from the quantile of the last history recovery times,
+// the timeout passed to NewCircuitBreaker is used until the first recovery
+func WithAdaptiveOpenTimeout(quantile float64, history int, minTimeout, maxTimeout time.Duration) Option {
+	return func(cb *CircuitBreaker) {
+		cb.backoff = NewAdaptiveTimeout(quantile, history, cb.openedTimeout, minTimeout, maxTimeout)
+	}
+}
+
+// observeRecovery reports the duration of the open streak ending at now to the
+// backoff policy when it learns from recoveries, must be called under write lock
+func (cb *CircuitBreaker) observeRecovery(now time.Time) {
+	o, ok := cb.backoff.(interface{ ObserveRecovery(d time.Duration) })
+	if !ok || cb.openStreak == 0 {
+		return
+	}
+	if d, skewed := elapsed(now, cb.streakStart); !skewed {
+		o.ObserveRecovery(d)
+	}
+}
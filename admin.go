@@ -0,0 +1,181 @@
+package circuitbreaker
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// - authenticates an admin request and returns who sends it, e.g. a user or an automation,
+// recorded in the override events and the transition, ok is false when the request is not
+// authenticated, e.g. the subject of a verified client certificate or of a checked token
+type Identify func(r *http.Request) (actor string, ok bool)
+
+// - returns an Identify accepting the requests with an "Authorization: Bearer <token>"
+// header, tokens maps every accepted token to its actor
+func BearerIdentity(tokens map[string]string) Identify {
+	return func(r *http.Request) (string, bool) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return "", false
+		}
+		for known, actor := range tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(known)) == 1 {
+				return actor, true
+			}
+		}
+		return "", false
+	}
+}
+
+// - is the admin API representation of a breaker
+type BreakerStatus struct {
+	Name            string          `json:"name"`
+	State           string          `json:"state"`
+	Forced          bool            `json:"forced"`
+	Successes       int64           `json:"successes"`
+	Failures        int64           `json:"failures"`
+	Rejected        int64           `json:"rejected"`
+	RejectedInState int64           `json:"rejected_in_state"`
+	LastTransition  *TransitionView `json:"last_transition,omitempty"`
+}
+
+// - is the admin API representation of a transition
+type TransitionView struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason"`
+	// trace of the call that caused the transition, see TagTraceID
+	TraceID string `json:"trace_id,omitempty"`
+	// who forced the transition, see Identify
+	Actor string `json:"actor,omitempty"`
+}
+
+// - builds the admin API representation of the breaker
+func StatusOf(cb *CircuitBreaker) BreakerStatus {
+	metrics := cb.Metrics()
+	status := BreakerStatus{
+		Name:            cb.Name(),
+		State:           metrics.State,
+		Forced:          cb.Forced(),
+		Successes:       metrics.Counts.Successes,
+		Failures:        metrics.Counts.Failures,
+		Rejected:        metrics.Rejected,
+		RejectedInState: metrics.RejectedInState,
+	}
+
+	if tr := cb.LastTransition(); !tr.At.IsZero() {
+		status.LastTransition = &TransitionView{From: tr.From, To: tr.To, At: tr.At, Reason: tr.Reason, TraceID: tr.Tags[TagTraceID], Actor: tr.Actor}
+	}
+
+	return status
+}
+
+// - serves the admin API of the registry:
+//
+//	GET  /breakers                    list breakers
+//	GET  /breakers/{name}             show breaker
+//	GET  /breakers/{name}/rejections  calls rejected while open, see WithRejectionSink
+//	GET  /breakers/{name}/errors      recent errors of the failed calls, see RecentErrors
+//	POST /breakers/{name}/force-open  force breaker open
+//	POST /breakers/{name}/force-close force breaker closed
+//	POST /breakers/{name}/reset       clear override and reset breaker
+//	GET  /debug                       HTML page with recent results and transitions
+//
+// the POST actions are audited with the actor returned by identify, requests it does not
+// authenticate get 401 Unauthorized, a nil identify rejects every POST action,
+// mount it with http.StripPrefix when serving under a sub-path
+func NewAdminHandler(registry *Registry, identify Identify) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /breakers", func(w http.ResponseWriter, r *http.Request) {
+		all := registry.All()
+		statuses := make([]BreakerStatus, 0, len(all))
+		for _, cb := range all {
+			statuses = append(statuses, StatusOf(cb))
+		}
+		writeJSON(w, http.StatusOK, statuses)
+	})
+
+	mux.HandleFunc("GET /breakers/{name}", func(w http.ResponseWriter, r *http.Request) {
+		withBreaker(registry, w, r, func(*CircuitBreaker) {})
+	})
+
+	mux.HandleFunc("GET /breakers/{name}/rejections", func(w http.ResponseWriter, r *http.Request) {
+		cb, ok := registry.Get(r.PathValue("name"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrNotFound.Error()})
+			return
+		}
+
+		rejections := cb.Rejections()
+		if rejections == nil {
+			rejections = []RejectedCall{}
+		}
+		writeJSON(w, http.StatusOK, rejections)
+	})
+
+	mux.HandleFunc("GET /breakers/{name}/errors", func(w http.ResponseWriter, r *http.Request) {
+		cb, ok := registry.Get(r.PathValue("name"))
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrNotFound.Error()})
+			return
+		}
+
+		samples := cb.RecentErrors()
+		if samples == nil {
+			samples = []ErrorSample{}
+		}
+		writeJSON(w, http.StatusOK, samples)
+	})
+
+	mux.HandleFunc("GET /debug", func(w http.ResponseWriter, r *http.Request) {
+		writeDebugPage(w, registry)
+	})
+
+	actions := map[string]func(*CircuitBreaker, string){
+		"force-open":  (*CircuitBreaker).ForceOpenBy,
+		"force-close": (*CircuitBreaker).ForceCloseBy,
+		"reset":       (*CircuitBreaker).ResetBy,
+	}
+	for action, apply := range actions {
+		mux.HandleFunc("POST /breakers/{name}/"+action, func(w http.ResponseWriter, r *http.Request) {
+			var actor string
+			ok := identify != nil
+			if ok {
+				actor, ok = identify(r)
+			}
+			if !ok || actor == "" {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": ErrUnauthenticated.Error()})
+				return
+			}
+
+			withBreaker(registry, w, r, func(cb *CircuitBreaker) {
+				apply(cb, actor)
+			})
+		})
+	}
+
+	return mux
+}
+
+// withBreaker applies fn to the breaker named in the path and writes its status
+func withBreaker(registry *Registry, w http.ResponseWriter, r *http.Request, fn func(*CircuitBreaker)) {
+	cb, ok := registry.Get(r.PathValue("name"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrNotFound.Error()})
+		return
+	}
+
+	fn(cb)
+	writeJSON(w, http.StatusOK, StatusOf(cb))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
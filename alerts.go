@@ -0,0 +1,123 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// - is a change of a rejection alert, Firing is false when the alert resolves
+type RejectionAlert struct {
+	Breaker string
+	Firing  bool
+	// share of calls rejected in the last evaluation interval
+	Ratio float64
+	// when the ratio first exceeded the limit
+	Since time.Time
+	Time  time.Time
+}
+
+// - fires when the share of calls rejected by a breaker of the registry stays at or above
+// Ratio for For, a ready-made "users are being shed" signal, the alert resolves when the
+// ratio falls below Ratio, intervals without calls keep the alert as it is
+type RejectionAlerter struct {
+	registry *Registry
+	Ratio    float64
+	For      time.Duration
+	handler  func(RejectionAlert)
+
+	mu       sync.Mutex
+	breakers map[string]*alertState
+}
+
+type alertState struct {
+	admitted int64
+	rejected int64
+	since    time.Time
+	firing   bool
+}
+
+// - is a constructor, handler is called on every alert change
+func NewRejectionAlerter(registry *Registry, ratio float64, d time.Duration, handler func(RejectionAlert)) *RejectionAlerter {
+	return &RejectionAlerter{
+		registry: registry,
+		Ratio:    ratio,
+		For:      d,
+		handler:  handler,
+		breakers: make(map[string]*alertState),
+	}
+}
+
+// - evaluates the calls since the previous evaluation at now, Run calls it every interval
+func (a *RejectionAlerter) Evaluate(now time.Time) {
+	var changes []RejectionAlert
+
+	a.mu.Lock()
+	for _, cb := range a.registry.All() {
+		metrics := cb.Metrics()
+		s, ok := a.breakers[cb.Name()]
+		if !ok {
+			a.breakers[cb.Name()] = &alertState{admitted: metrics.Admitted, rejected: metrics.Rejected}
+			continue
+		}
+
+		admitted, rejected := metrics.Admitted-s.admitted, metrics.Rejected-s.rejected
+		s.admitted, s.rejected = metrics.Admitted, metrics.Rejected
+		if admitted+rejected <= 0 {
+			continue
+		}
+
+		ratio := float64(rejected) / float64(admitted+rejected)
+		if ratio < a.Ratio {
+			if s.firing {
+				changes = append(changes, RejectionAlert{Breaker: cb.Name(), Ratio: ratio, Since: s.since, Time: now})
+			}
+			s.since, s.firing = time.Time{}, false
+			continue
+		}
+
+		if s.since.IsZero() {
+			s.since = now
+		}
+		if spent, _ := elapsed(now, s.since); !s.firing && spent >= a.For {
+			s.firing = true
+			changes = append(changes, RejectionAlert{Breaker: cb.Name(), Firing: true, Ratio: ratio, Since: s.since, Time: now})
+		}
+	}
+	a.mu.Unlock()
+
+	for _, change := range changes {
+		a.handler(change)
+	}
+}
+
+// - returns the breakers whose alert is firing
+func (a *RejectionAlerter) Firing() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var names []string
+	for name, s := range a.breakers {
+		if s.firing {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// - calls Evaluate every interval until ctx is done, run it in its own goroutine
+func (a *RejectionAlerter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.Evaluate(now)
+		}
+	}
+}
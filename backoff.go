@@ -0,0 +1,87 @@
+package circuitbreaker
+
+import "time"
+
+// - computes the open timeout of the n-th consecutive open period, n starts at 1
+// and is reset when the breaker closes
+type BackoffPolicy interface {
+	OpenTimeout(n int) time.Duration
+}
+
+// - is a BackoffPolicy defined by a function
+type BackoffFunc func(n int) time.Duration
+
+func (f BackoffFunc) OpenTimeout(n int) time.Duration {
+	return f(n)
+}
+
+// - keeps the same open timeout for every open period
+type ConstantBackoff struct {
+	Timeout time.Duration
+}
+
+func (b ConstantBackoff) OpenTimeout(int) time.Duration {
+	return b.Timeout
+}
+
+// - multiplies the open timeout by Factor (2 when zero) for every consecutive open period,
+// up to Max when it is set
+type ExponentialBackoff struct {
+	Base   time.Duration
+	Factor float64
+	Max    time.Duration
+}
+
+func (b ExponentialBackoff) OpenTimeout(n int) time.Duration {
+	factor := b.Factor
+	if factor <= 0 {
+		factor = 2
+	}
+
+	timeout := float64(b.Base)
+	for i := 1; i < n; i++ {
+		timeout *= factor
+		if b.Max > 0 && timeout >= float64(b.Max) {
+			return b.Max
+		}
+	}
+	return time.Duration(timeout)
+}
+
+// - grows the open timeout as Base times the Fibonacci sequence 1, 1, 2, 3, 5...,
+// up to Max when it is set
+type FibonacciBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+func (b FibonacciBackoff) OpenTimeout(n int) time.Duration {
+	prev, cur := time.Duration(0), b.Base
+	for i := 1; i < n; i++ {
+		prev, cur = cur, prev+cur
+		if b.Max > 0 && cur >= b.Max {
+			return b.Max
+		}
+	}
+	return cur
+}
+
+// - replaces the fixed open timeout with the policy, the timeout passed to
+// NewCircuitBreaker and UpdateValues is then ignored
+func WithBackoffPolicy(policy BackoffPolicy) Option {
+	return func(cb *CircuitBreaker) {
+		cb.backoff = policy
+	}
+}
+
+// openTimeout returns the timeout of the current open period, must be called under lock
+func (cb *CircuitBreaker) openTimeout() time.Duration {
+	timeout := cb.openedTimeout
+	if cb.backoff != nil {
+		timeout = cb.backoff.OpenTimeout(max(cb.openStreak, 1))
+	}
+	if cb.ejectTimeout > 0 {
+		timeout = cb.ejectTimeout
+	}
+	return max(cb.dampedTimeout(timeout), cb.dwell[StateOpened])
+}
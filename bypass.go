@@ -0,0 +1,39 @@
+package circuitbreaker
+
+import "context"
+
+type bypassKey struct{}
+
+// - marks the calls run with ctx as privileged, e.g. health checks or admin traffic:
+// ExecuteContext admits them whatever the state of the breaker, including a forced open
+// state, and records their outcomes like any other call, only draining and the rate and
+// concurrency limiters still reject them
+func WithBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// - reports whether the calls run with ctx bypass the breaker, see WithBypass
+func Bypassed(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	bypass, _ := ctx.Value(bypassKey{}).(bool)
+	return bypass
+}
+
+// admitBypass is admit for the calls marked with WithBypass
+func (cb *CircuitBreaker) admitBypass() error {
+	if cb.isDraining() {
+		return ErrDraining
+	}
+	if cb.limiter != nil && !cb.limiter.Allow() {
+		return ErrRateLimited
+	}
+
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.checkOpenTimeout()
+	cb.bypassed++
+	return nil
+}
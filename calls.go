@@ -0,0 +1,41 @@
+package circuitbreaker
+
+import (
+	"context"
+	"time"
+)
+
+// - describes a call made through Execute or ExecuteContext
+type CallInfo struct {
+	Breaker string
+	// tags of the call, see WithTags
+	Tags    Tags
+	Latency time.Duration
+	Err     error
+	// the call was not admitted, Err holds the reason
+	Rejected bool
+}
+
+// - is called after every call made through Execute, outside of the breaker lock,
+// e.g. by metrics exporters slicing calls by tags
+type CallObserver func(CallInfo)
+
+// - registers observer for calls made through Execute and ExecuteContext
+func WithCallObserver(observer CallObserver) Option {
+	return func(cb *CircuitBreaker) {
+		cb.callObservers = append(cb.callObservers, observer)
+	}
+}
+
+// observeCall passes the call to the observers
+func (cb *CircuitBreaker) observeCall(ctx context.Context, info CallInfo) {
+	if len(cb.callObservers) == 0 {
+		return
+	}
+
+	info.Breaker = cb.name
+	info.Tags = TagsFrom(ctx)
+	for _, observer := range cb.callObservers {
+		observer(info)
+	}
+}
@@ -0,0 +1,86 @@
+package circuitbreaker
+
+import "time"
+
+// - counts the closed periods in which a candidate threshold decided differently
+// from the active trip policy, see WithCandidateThreshold
+type CandidateStats struct {
+	// the candidate would have opened the circuit while it stayed closed
+	WouldHaveOpened int64
+	// the circuit opened while the candidate would have kept it closed
+	WouldHaveStayed int64
+}
+
+type candidateThreshold struct {
+	threshold CustomThreshold
+	sw        Switch
+	stats     CandidateStats
+	// the candidate tripped and a divergence was reported in the current closed period
+	tripped  bool
+	reported bool
+}
+
+// - evaluates threshold in parallel with the active failure threshold or trip policy
+// without acting on it, the first result of a closed period on which the candidate would
+// have opened the circuit and every open the candidate would not have done are reported
+// as EventCandidateDivergence and counted in CandidateStats, so a new threshold can be
+// compared against the current one before it is promoted with UpdateValues
+func WithCandidateThreshold(threshold CustomThreshold) Option {
+	return func(cb *CircuitBreaker) {
+		cb.candidate = &candidateThreshold{threshold: threshold, sw: ChooseSwitch(threshold)}
+	}
+}
+
+// - returns the divergences of the candidate threshold
+func (cb *CircuitBreaker) CandidateStats() CandidateStats {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	if cb.candidate == nil {
+		return CandidateStats{}
+	}
+	return cb.candidate.stats
+}
+
+// compareCandidate reports where the candidate diverges from the active decision trip,
+// must be called under write lock
+func (cb *CircuitBreaker) compareCandidate(now time.Time, stats Stats, trip bool) {
+	c := cb.candidate
+	if c == nil {
+		return
+	}
+
+	value, ok := cb.thresholdValue(c.threshold, stats.ConsecutiveFailures, stats.Failures, stats.Counts)
+	wouldTrip := ok && cb.check(c.sw, value)
+	reason := ""
+	switch {
+	case c.reported:
+	case wouldTrip && !trip:
+		c.stats.WouldHaveOpened++
+		reason = "candidate would have opened: " + describeCheck("failure", value, c.threshold)
+	case trip && !wouldTrip && !c.tripped:
+		c.stats.WouldHaveStayed++
+		reason = "candidate would have stayed closed"
+	}
+	c.tripped = c.tripped || wouldTrip
+	if reason == "" {
+		return
+	}
+	c.reported = true
+
+	cb.queueEvent(Event{
+		Type:   EventCandidateDivergence,
+		From:   cb.state,
+		To:     cb.state,
+		Time:   now,
+		Reason: reason,
+	})
+}
+
+// resetCandidate starts a new period for the candidate, must be called under write lock
+func (cb *CircuitBreaker) resetCandidate() {
+	if cb.candidate != nil {
+		cb.candidate.tripped = false
+		cb.candidate.reported = false
+	}
+}
@@ -0,0 +1,114 @@
+package cbtest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is a manually advanced circuitbreaker.Clock, timers created with AfterFunc fire
+// in order while the clock is advanced, on the advancing goroutine
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *FakeClock
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+// - is a constructor
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) circuitbreaker.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := !t.stopped
+	t.stopped = true
+	return active
+}
+
+// - moves the clock forward by d firing due timers
+func (c *FakeClock) Advance(d time.Duration) {
+	c.AdvanceTo(c.Now().Add(d))
+}
+
+// - moves the clock forward to target firing due timers in order, the clock never goes back
+func (c *FakeClock) AdvanceTo(target time.Time) {
+	for {
+		c.mu.Lock()
+		next := c.nextTimer(target)
+		if next == nil {
+			if target.After(c.now) {
+				c.now = target
+			}
+			c.mu.Unlock()
+			return
+		}
+		next.stopped = true
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		c.mu.Unlock()
+
+		next.f()
+	}
+}
+
+// - returns the number of timers that have not fired or been stopped
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, t := range c.timers {
+		if !t.stopped {
+			n++
+		}
+	}
+	return n
+}
+
+// nextTimer pops the earliest active timer due not later than target, must be called under lock
+func (c *FakeClock) nextTimer(target time.Time) *fakeTimer {
+	active := c.timers[:0]
+	for _, t := range c.timers {
+		if !t.stopped {
+			active = append(active, t)
+		}
+	}
+	c.timers = active
+
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].at.Before(c.timers[j].at)
+	})
+
+	if len(c.timers) == 0 || c.timers[0].at.After(target) {
+		return nil
+	}
+	return c.timers[0]
+}
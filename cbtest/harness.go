@@ -0,0 +1,163 @@
+// Package cbtest helps testing breaker configurations in virtual time: a fake clock,
+// scripted outcome sequences and assertions on the observed transitions, without
+// real sleeps.
+package cbtest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is the start time of the fake clock of New
+var Epoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// - builds the breaker under test, the options must be passed to the constructor
+// and the clock to any clock-aware thresholds (e.g. SlidingWindowThreshold.SetClock)
+type Factory func(clock circuitbreaker.Clock, opts ...circuitbreaker.Option) *circuitbreaker.CircuitBreaker
+
+// - is a state change observed by the harness
+type Transition struct {
+	Time   time.Time
+	From   string
+	To     string
+	Reason string
+}
+
+func (tr Transition) String() string {
+	return tr.From + "->" + tr.To
+}
+
+// - drives a breaker in virtual time, the open -> half-open transition is timer driven
+// so it happens while the clock is advanced
+type Harness struct {
+	t       testing.TB
+	Clock   *FakeClock
+	Breaker *circuitbreaker.CircuitBreaker
+
+	transitions []Transition
+	rejected    int
+}
+
+// - builds the breaker with factory on a fake clock starting at Epoch
+func New(t testing.TB, factory Factory) *Harness {
+	h := &Harness{t: t, Clock: NewFakeClock(Epoch)}
+	h.Breaker = factory(
+		h.Clock,
+		circuitbreaker.WithClock(h.Clock),
+		circuitbreaker.WithTransitionTimer(),
+		circuitbreaker.WithEventHandler(func(e circuitbreaker.Event) {
+			if e.Type == circuitbreaker.EventStateChange {
+				h.transitions = append(h.transitions, Transition{Time: e.Time, From: e.From, To: e.To, Reason: e.Reason})
+			}
+		}),
+	)
+	return h
+}
+
+// - plays a script of calls, one character per call: S success, F failure, I ignored
+// (see RecordIgnore), R expects the call to be rejected, spaces are skipped, every call
+// goes through Allow and rejected S, F and I calls are counted by Rejected,
+// an unknown character fails the test
+func (h *Harness) Play(script string) *Harness {
+	h.t.Helper()
+
+	for i, step := range script {
+		if step == ' ' {
+			continue
+		}
+		allowed := h.Breaker.Allow()
+		switch step {
+		case 'R':
+			if allowed {
+				h.t.Errorf("call %d of %q: expected rejection in state %s", i, script, h.Breaker.State())
+				h.Breaker.RecordIgnore()
+			}
+			continue
+		case 'S', 'F', 'I':
+		default:
+			h.t.Fatalf("call %d of %q: unknown step %q", i, script, step)
+		}
+
+		if !allowed {
+			h.rejected++
+			continue
+		}
+		switch step {
+		case 'S':
+			h.Breaker.RecordSuccess()
+		case 'F':
+			h.Breaker.RecordFailure()
+		case 'I':
+			h.Breaker.RecordIgnore()
+		}
+	}
+	return h
+}
+
+// - advances the fake clock by d
+func (h *Harness) Wait(d time.Duration) *Harness {
+	h.Clock.Advance(d)
+	return h
+}
+
+// - returns the observed transitions
+func (h *Harness) Transitions() []Transition {
+	return slices.Clone(h.transitions)
+}
+
+// - returns the number of S, F and I calls rejected by the breaker
+func (h *Harness) Rejected() int {
+	return h.rejected
+}
+
+// - fails the test unless the breaker is in state
+func (h *Harness) AssertState(state string) *Harness {
+	h.t.Helper()
+
+	if got := h.Breaker.State(); got != state {
+		h.t.Errorf("expected state %s, got %s", state, got)
+	}
+	return h
+}
+
+// - fails the test unless the observed transitions are want, written as "closed->open"
+func (h *Harness) AssertTransitions(want ...string) *Harness {
+	h.t.Helper()
+
+	got := make([]string, len(h.transitions))
+	for i, tr := range h.transitions {
+		got[i] = tr.String()
+	}
+	if !slices.Equal(got, want) {
+		h.t.Errorf("expected transitions %v, got %v", want, got)
+	}
+	return h
+}
+
+// - fails the test unless the last transition happened d after Epoch
+func (h *Harness) AssertTransitionAt(d time.Duration) *Harness {
+	h.t.Helper()
+
+	if len(h.transitions) == 0 {
+		h.t.Errorf("expected a transition at %s, got none", d)
+		return h
+	}
+	last := h.transitions[len(h.transitions)-1]
+	if got := last.Time.Sub(Epoch); got != d {
+		h.t.Errorf("expected %s at %s, got %s", last, d, got)
+	}
+	return h
+}
+
+// - fails the test unless n calls were rejected
+func (h *Harness) AssertRejected(n int) *Harness {
+	h.t.Helper()
+
+	if h.rejected != n {
+		h.t.Errorf("expected %d rejected calls, got %d", n, h.rejected)
+	}
+	return h
+}
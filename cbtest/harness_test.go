@@ -0,0 +1,46 @@
+package cbtest
+
+import (
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t, func(_ circuitbreaker.Clock, opts ...circuitbreaker.Option) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(3),
+			circuitbreaker.NewInt64Threshold(2),
+			30*time.Second,
+			opts...,
+		)
+	})
+
+	h.Play("SSF FF").AssertState(circuitbreaker.StateOpened).
+		Play("RR").
+		Wait(29*time.Second).AssertTransitions("closed->open").
+		Wait(time.Second).AssertTransitionAt(30*time.Second).
+		Play("SS").
+		AssertState(circuitbreaker.StateClosed).
+		AssertTransitions("closed->open", "open->half-open", "half-open->closed").
+		AssertRejected(0)
+
+	if h.Clock.Pending() != 0 {
+		t.Errorf("Expected no pending timers, got %d", h.Clock.Pending())
+	}
+}
+
+func TestFakeClockFiresTimersInOrder(t *testing.T) {
+	clock := NewFakeClock(Epoch)
+	var fired []int
+	clock.AfterFunc(2*time.Second, func() { fired = append(fired, 2) })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+	stopped := clock.AfterFunc(time.Second, func() { fired = append(fired, 0) })
+	stopped.Stop()
+
+	clock.Advance(3 * time.Second)
+	if len(fired) != 2 || fired[0] != 1 || fired[1] != 2 || !clock.Now().Equal(Epoch.Add(3*time.Second)) {
+		t.Errorf("Expected timers fired in order, got %v at %s", fired, clock.Now())
+	}
+}
@@ -0,0 +1,44 @@
+package circuitbreaker
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// - configures fault injection, so fallback paths can be verified in staging
+// without breaking the real dependency
+type ChaosConfig struct {
+	// probability of rejecting an admitted call as if the circuit was open
+	RejectProbability float64
+	// probability of recording a successful call as a failure
+	FailureProbability float64
+	// chaos is active only while the schedule is active, always when nil
+	Schedule Schedule
+	// source of randomness in [0, 1), math/rand/v2 when nil
+	Rand func() float64
+}
+
+// - enables chaos mode
+func WithChaos(cfg ChaosConfig) Option {
+	return func(cb *CircuitBreaker) {
+		if cfg.Rand == nil {
+			cfg.Rand = rand.Float64
+		}
+		cb.chaos = &cfg
+	}
+}
+
+// chaosActive reports whether fault injection is enabled at the moment
+func (cb *CircuitBreaker) chaosActive(now time.Time) bool {
+	return cb.chaos != nil && (cb.chaos.Schedule == nil || cb.chaos.Schedule.Active(now))
+}
+
+// chaosReject decides whether the admitted call is rejected, must be called under write lock
+func (cb *CircuitBreaker) chaosReject(now time.Time) bool {
+	return cb.chaosActive(now) && cb.chaos.Rand() < cb.chaos.RejectProbability
+}
+
+// chaosFailure decides whether the successful call is recorded as a failure, must be called under write lock
+func (cb *CircuitBreaker) chaosFailure(now time.Time) bool {
+	return cb.chaosActive(now) && cb.chaos.Rand() < cb.chaos.FailureProbability
+}
@@ -4,9 +4,28 @@ package circuitbreaker
 // 1) base functionality
 // 2) thread safety
 // 3) custom thresholds
+// 4) events and options
+// 5) worker pool
+// 6) execute and metrics
+// 7) registry and admin API
+// 8) clock handling
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -143,6 +162,36 @@ func TestConcurrentAccess(t *testing.T) {
 	}
 }
 
+func TestConcurrentStateReads(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		50*time.Millisecond,
+	)
+	cb.RecordFailure()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				_ = cb.State()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected state %s, got %s", StateOpened, state)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected state %s after timeout, got %s", StateHalfOpen, state)
+	}
+}
+
 // custom thresholds - SlidingWindowThreshold
 
 func TestSlidingWindowThreshold(t *testing.T) {
@@ -223,6 +272,35 @@ func TestSlidingWindowThreshold(t *testing.T) {
 		}
 	})
 
+	t.Run("GetCurrentFailures Prunes Expired", func(t *testing.T) {
+		threshold := NewSlidingWindowThreshold(30*time.Millisecond, 5, "prune-test")
+
+		threshold.RecordFailure()
+		threshold.RecordFailure()
+		time.Sleep(40 * time.Millisecond)
+		threshold.RecordFailure()
+
+		if count := threshold.GetCurrentFailures(); count != 1 {
+			t.Errorf("Expected 1 failure within window, got %d", count)
+		}
+		if rate := threshold.FailureRate(); rate < 33 || rate > 34 {
+			t.Errorf("Expected ~33.3 failures per second, got %f", rate)
+		}
+	})
+
+	t.Run("SetWindowSize", func(t *testing.T) {
+		threshold := NewSlidingWindowThreshold(time.Second, 2, "resize-test")
+
+		threshold.RecordFailure()
+		time.Sleep(30 * time.Millisecond)
+		threshold.RecordFailure()
+
+		threshold.SetWindowSize(20 * time.Millisecond)
+		if count := threshold.GetCurrentFailures(); count != 1 {
+			t.Errorf("Expected 1 failure after shrinking window, got %d", count)
+		}
+	})
+
 	t.Run("String and GetThreshold", func(t *testing.T) {
 		windowSize := 100 * time.Millisecond
 		maxFailures := 2
@@ -268,6 +346,378 @@ func TestSlidingWindowThreshold(t *testing.T) {
 	})
 }
 
+func TestSuccessRateThreshold(t *testing.T) {
+	threshold := NewSuccessRateThreshold(0.9, 20)
+
+	cases := []struct {
+		counts   Counts
+		expected bool
+	}{
+		{Counts{Successes: 10, Total: 10}, false},
+		{Counts{Successes: 18, Failures: 2, Total: 20}, true},
+		{Counts{Successes: 17, Failures: 3, Total: 20}, false},
+	}
+	for _, c := range cases {
+		if actual := threshold.Check(c.counts); actual != c.expected {
+			t.Errorf("Expected Check(%+v) to be %v", c.counts, c.expected)
+		}
+	}
+
+	if threshold.Check(int64(20)) {
+		t.Error("Expected Check to return false for non-Counts value")
+	}
+
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewSuccessRateThreshold(1, 2),
+		10*time.Millisecond,
+	)
+	cb.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+
+	if !cb.Allow() {
+		t.Fatal("Expected Allow() to return true in half-open state")
+	}
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected state %s before enough samples, got %s", StateHalfOpen, state)
+	}
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected state %s, got %s", StateClosed, state)
+	}
+
+	probing := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewSuccessRateThreshold(0.75, 4),
+		10*time.Millisecond,
+	)
+	probing.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+	probing.Allow()
+	probing.RecordSuccess()
+	probing.RecordFailure()
+	if state := probing.State(); state != StateHalfOpen {
+		t.Errorf("Expected a failed probe to keep probing before enough samples, got %s", state)
+	}
+	probing.RecordSuccess()
+	probing.RecordSuccess()
+	if state := probing.State(); state != StateClosed {
+		t.Errorf("Expected 3 of 4 successful probes to close the circuit, got %s", state)
+	}
+
+	probing.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+	probing.Allow()
+	probing.RecordFailure()
+	probing.RecordFailure()
+	probing.RecordSuccess()
+	probing.RecordSuccess()
+	if state := probing.State(); state != StateOpened {
+		t.Errorf("Expected 2 of 4 successful probes to reopen the circuit, got %s", state)
+	}
+}
+
+func TestFloat64ThresholdMinimumCalls(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewFloat64Threshold(0.5),
+		NewInt64Threshold(1),
+		time.Second,
+		WithMinimumCalls(4),
+	)
+
+	cb.RecordFailure()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected state %s below minimum calls, got %s", StateClosed, state)
+	}
+
+	cb.RecordSuccess()
+	cb.RecordSuccess()
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected state %s with 25%% failure rate, got %s", StateClosed, state)
+	}
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected state %s with 50%% failure rate, got %s", StateOpened, state)
+	}
+}
+
+func TestHalfOpenCounterPolicy(t *testing.T) {
+	run := func(policy HalfOpenCounterPolicy) string {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewSuccessRateThreshold(0.6, 2),
+			10*time.Millisecond,
+			WithHalfOpenCounterPolicy(policy),
+		)
+
+		cb.RecordFailure()
+		time.Sleep(20 * time.Millisecond)
+		cb.Allow()
+		cb.RecordSuccess()
+		cb.RecordFailure()
+
+		time.Sleep(20 * time.Millisecond)
+		cb.Allow()
+		cb.RecordSuccess()
+		return cb.State()
+	}
+
+	if state := run(HalfOpenCounterPolicy{}); state != StateHalfOpen {
+		t.Errorf("Expected state %s with reset policy, got %s", StateHalfOpen, state)
+	}
+	if state := run(HalfOpenCounterPolicy{OnProbeFailure: CounterCarryOver}); state != StateClosed {
+		t.Errorf("Expected state %s with carried over probes, got %s", StateClosed, state)
+	}
+
+	cb := NewCircuitBreaker(nil, nil, 0)
+	if policy := cb.HalfOpenCounterPolicy(); policy.OnEnter != CounterReset || policy.OnProbeFailure != CounterReset {
+		t.Errorf("Expected reset policy by default, got %+v", policy)
+	}
+}
+
+func TestHalfOpenSuccessRate(t *testing.T) {
+	newBreaker := func() *CircuitBreaker {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			10*time.Millisecond,
+			WithHalfOpenSuccessRate(0.8, 5),
+		)
+		cb.RecordFailure()
+		time.Sleep(15 * time.Millisecond)
+		cb.Allow()
+		return cb
+	}
+
+	cb := newBreaker()
+	cb.RecordFailure()
+	for range 3 {
+		cb.RecordSuccess()
+	}
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected half-open state before the probe set completes, got %s", state)
+	}
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state at 80%% of 5 probes, got %s", state)
+	}
+
+	cb = newBreaker()
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected early reopen when 80%% is unreachable, got %s", state)
+	}
+}
+
+func TestHalfOpenPolicy(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		10*time.Millisecond,
+		WithHalfOpenPolicy(NewSuccessCountPolicy(2, 2)),
+	)
+	cb.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+
+	admitted := 0
+	for range 5 {
+		if cb.Allow() {
+			admitted++
+		}
+	}
+	if admitted != 2 {
+		t.Errorf("Expected 2 probes admitted in half-open state, got %d", admitted)
+	}
+
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected half-open state after 1 of 2 successes, got %s", state)
+	}
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state after 2 successes, got %s", state)
+	}
+}
+
+func TestHalfOpenPolicyWithCarriedCounters(t *testing.T) {
+	for _, policy := range []HalfOpenPolicy{NewSuccessCountPolicy(2, 0), NewSuccessRatePolicy(1, 2)} {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(2),
+			NewInt64Threshold(1),
+			10*time.Millisecond,
+			WithHalfOpenPolicy(policy),
+			WithHalfOpenCounterPolicy(HalfOpenCounterPolicy{OnEnter: CounterCarryOver}),
+		)
+		cb.RecordFailure()
+		cb.RecordFailure()
+		time.Sleep(15 * time.Millisecond)
+		cb.Allow()
+
+		if counts := cb.Counts(); counts.Failures != 2 {
+			t.Errorf("%T: expected the closed state failures to be carried over, got %+v", policy, counts)
+		}
+		cb.RecordSuccess()
+		if state := cb.State(); state != StateHalfOpen {
+			t.Errorf("%T: expected carried failures not to count as probes, got %s", policy, state)
+		}
+		cb.RecordSuccess()
+		if state := cb.State(); state != StateClosed {
+			t.Errorf("%T: expected 2 successful probes to close the circuit, got %s", policy, state)
+		}
+	}
+}
+
+func TestTripPolicy(t *testing.T) {
+	var last Stats
+	cb := NewCircuitBreaker(
+		nil,
+		NewInt64Threshold(1),
+		time.Minute,
+		WithTripPolicy(TripFunc(func(stats Stats) (bool, string) {
+			last = stats
+			return stats.ConsecutiveFailures >= 2 && stats.Total >= 4, "2 consecutive failures of 4 calls"
+		})),
+	)
+
+	cb.RecordFailure()
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state, got %s", state)
+	}
+	if last.Total != 3 || last.Failures != 2 || last.ConsecutiveFailures != 1 {
+		t.Errorf("Unexpected stats: %+v", last)
+	}
+
+	cb.RecordFailure()
+	if tr := cb.LastTransition(); tr.To != StateOpened || tr.Reason != "2 consecutive failures of 4 calls" {
+		t.Errorf("Expected trip by policy, got %+v", tr)
+	}
+}
+
+func TestThresholdNumericTypes(t *testing.T) {
+	intThreshold := NewInt64Threshold(3)
+	for _, value := range []any{3, int8(3), int32(4), uint(3), uint64(1 << 63), 3.5, float32(3)} {
+		if !intThreshold.Check(value) {
+			t.Errorf("Expected Int64Threshold to pass for %T(%v)", value, value)
+		}
+	}
+
+	floatThreshold := NewFloat64Threshold(0.5)
+	for _, value := range []any{0.5, float32(0.75), 1, uint8(1)} {
+		if !floatThreshold.Check(value) {
+			t.Errorf("Expected Float64Threshold to pass for %T(%v)", value, value)
+		}
+	}
+
+	if _, err := intThreshold.CheckErr("3"); !errors.Is(err, ErrUnsupporterType) {
+		t.Errorf("Expected %v, got %v", ErrUnsupporterType, err)
+	}
+	if _, err := floatThreshold.CheckErr(nil); !errors.Is(err, ErrUnsupporterType) {
+		t.Errorf("Expected %v, got %v", ErrUnsupporterType, err)
+	}
+}
+
+func TestThresholdErrorEvent(t *testing.T) {
+	type wrapped struct {
+		*Int64Threshold
+	}
+
+	var reasons []string
+	cb := NewCircuitBreaker(
+		wrapped{NewInt64Threshold(1)},
+		NewInt64Threshold(1),
+		time.Second,
+		WithEventHandler(func(e Event) {
+			if e.Type == EventThresholdError {
+				reasons = append(reasons, e.Reason)
+			}
+		}),
+	)
+
+	cb.RecordFailure()
+	if len(reasons) != 1 {
+		t.Fatalf("Expected 1 threshold error event, got %d", len(reasons))
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected state %s, got %s", StateClosed, state)
+	}
+}
+
+func TestThresholdSpec(t *testing.T) {
+	config := `{"type": "any", "children": [
+		{"type": "sliding-window", "params": {"window": "30s", "max_failures": 5, "name": "errors"}},
+		{"type": "success-rate", "params": {"min_rate": 0.9, "min_samples": 20}}
+	]}`
+
+	var spec ThresholdSpec
+	if err := json.Unmarshal([]byte(config), &spec); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+
+	threshold, err := NewThreshold(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cb := NewCircuitBreaker(threshold, NewInt64Threshold(1), time.Minute, WithOutcomeFeed())
+	for range 4 {
+		cb.RecordFailure()
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected state %s below the window limit, got %s", StateClosed, state)
+	}
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected the configured window to open the circuit, got %s", state)
+	}
+
+	mixed := func() *CircuitBreaker {
+		threshold, err := NewThreshold(ThresholdSpec{Type: "any", Children: []ThresholdSpec{
+			{Type: "int64", Params: map[string]any{"value": 2.0}},
+			{Type: "float64", Params: map[string]any{"value": 0.5}},
+		}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return NewCircuitBreaker(threshold, NewInt64Threshold(1), time.Minute, WithMinimumCalls(10))
+	}
+	consecutive := mixed()
+	consecutive.RecordSuccess()
+	consecutive.RecordFailure()
+	consecutive.RecordFailure()
+	if tr := consecutive.LastTransition(); tr.To != StateOpened || tr.Reason == "" {
+		t.Errorf("Expected 2 consecutive failures to open the circuit, got %+v", tr)
+	}
+	rate := mixed()
+	for range 5 {
+		rate.RecordSuccess()
+		rate.RecordFailure()
+	}
+	if state := rate.State(); state != StateOpened {
+		t.Errorf("Expected a 50%% failure rate to open the circuit, got %s", state)
+	}
+
+	roundTrip, err := SpecOf(threshold)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(roundTrip.Children) != 2 || roundTrip.Children[0].Params["window"] != "30s" {
+		t.Errorf("Unexpected spec: %+v", roundTrip)
+	}
+
+	if _, err := NewThreshold(ThresholdSpec{Type: "unknown"}); !errors.Is(err, ErrUnknownThreshold) {
+		t.Errorf("Expected %v, got %v", ErrUnknownThreshold, err)
+	}
+	if _, err := NewThreshold(ThresholdSpec{Type: "int64", Params: map[string]any{"value": 1.5}}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("Expected %v, got %v", ErrInvalidThreshold, err)
+	}
+}
+
 func TestCircuitBreakerWithSlidingWindowThreshold(t *testing.T) {
 	windowSize := 100 * time.Millisecond
 	maxFailures := 2
@@ -323,3 +773,2904 @@ func TestSlidingWindowThresholdWithTimeControl(t *testing.T) {
 		t.Errorf("Expected 1 current failure, got %d", count)
 	}
 }
+
+// events and options
+
+func TestTransitionTimer(t *testing.T) {
+	events := make(chan Event, 4)
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		50*time.Millisecond,
+		WithTransitionTimer(),
+		WithEventHandler(func(e Event) { events <- e }),
+	)
+
+	cb.RecordFailure()
+	if e := <-events; e.From != StateClosed || e.To != StateOpened {
+		t.Errorf("Expected %s -> %s event, got %s -> %s", StateClosed, StateOpened, e.From, e.To)
+	}
+
+	select {
+	case e := <-events:
+		if e.From != StateOpened || e.To != StateHalfOpen {
+			t.Errorf("Expected %s -> %s event, got %s -> %s", StateOpened, StateHalfOpen, e.From, e.To)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected timer to switch breaker to half-open without calls")
+	}
+
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected state %s, got %s", StateHalfOpen, state)
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Forced Open", func(t *testing.T) {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Second,
+			WithMaintenanceWindows(MaintenanceWindow{
+				Schedule: TimeRange{Start: now.Add(-time.Minute), End: now.Add(time.Minute)},
+				State:    StateOpened,
+			}),
+		)
+
+		if cb.Allow() {
+			t.Error("Expected Allow() to return false during forced open window")
+		}
+		if state := cb.State(); state != StateOpened {
+			t.Errorf("Expected state %s, got %s", StateOpened, state)
+		}
+	})
+
+	t.Run("Forced Closed", func(t *testing.T) {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Second,
+			WithMaintenanceWindows(MaintenanceWindow{
+				Schedule: TimeRange{Start: now.Add(-time.Minute), End: now.Add(time.Minute)},
+				State:    StateClosed,
+			}),
+		)
+
+		cb.RecordFailure()
+		cb.RecordFailure()
+		if !cb.Allow() {
+			t.Error("Expected Allow() to return true during forced closed window")
+		}
+	})
+
+	t.Run("Daily Window Across Midnight", func(t *testing.T) {
+		window := DailyWindow{
+			Start:    23 * time.Hour,
+			Duration: 2 * time.Hour,
+			Weekdays: []time.Weekday{time.Saturday},
+			Location: time.UTC,
+		}
+
+		saturday := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+		cases := map[time.Duration]bool{
+			22 * time.Hour:                false,
+			23*time.Hour + time.Minute:    true,
+			24*time.Hour + time.Hour:      false,
+			24*time.Hour + 30*time.Minute: true,
+		}
+		for offset, expected := range cases {
+			if active := window.Active(saturday.Add(offset)); active != expected {
+				t.Errorf("Expected Active() at +%s to be %v", offset, expected)
+			}
+		}
+	})
+}
+
+func TestWarmup(t *testing.T) {
+	t.Run("Minimum Calls", func(t *testing.T) {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Second,
+			WithWarmup(0, 3),
+		)
+
+		for range 3 {
+			cb.RecordFailure()
+		}
+		if state := cb.State(); state != StateClosed {
+			t.Errorf("Expected state %s during warmup, got %s", StateClosed, state)
+		}
+
+		cb.RecordFailure()
+		if state := cb.State(); state != StateOpened {
+			t.Errorf("Expected state %s after warmup, got %s", StateOpened, state)
+		}
+
+		cb.Reset()
+		cb.RecordFailure()
+		if state := cb.State(); state != StateClosed {
+			t.Errorf("Expected state %s after reset, got %s", StateClosed, state)
+		}
+	})
+
+	t.Run("Duration", func(t *testing.T) {
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Second,
+			WithWarmup(50*time.Millisecond, 0),
+		)
+
+		cb.RecordFailure()
+		if state := cb.State(); state != StateClosed {
+			t.Errorf("Expected state %s during warmup, got %s", StateClosed, state)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		cb.RecordFailure()
+		if state := cb.State(); state != StateOpened {
+			t.Errorf("Expected state %s after warmup, got %s", StateOpened, state)
+		}
+	})
+}
+
+func TestChaos(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(2),
+		NewInt64Threshold(1),
+		time.Second,
+		WithChaos(ChaosConfig{
+			RejectProbability:  0.5,
+			FailureProbability: 1,
+			Rand:               func() float64 { return 0.7 },
+		}),
+	)
+
+	if !cb.Allow() {
+		t.Error("Expected Allow() to return true when roll is above reject probability")
+	}
+
+	cb.RecordSuccess()
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected injected failures to open the breaker, got %s", state)
+	}
+
+	expired := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+		WithChaos(ChaosConfig{
+			RejectProbability: 1,
+			Schedule:          TimeRange{End: time.Now().Add(-time.Minute)},
+		}),
+	)
+	if !expired.Allow() {
+		t.Error("Expected no rejections outside of chaos schedule")
+	}
+}
+
+func TestLastTransition(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewFloat64Threshold(0.5),
+		NewInt64Threshold(1),
+		time.Second,
+	)
+
+	if info := cb.LastTransition(); !info.At.IsZero() {
+		t.Errorf("Expected zero transition initially, got %+v", info)
+	}
+
+	cb.RecordFailure()
+
+	info := cb.LastTransition()
+	if info.From != StateClosed || info.To != StateOpened {
+		t.Errorf("Expected %s -> %s, got %s -> %s", StateClosed, StateOpened, info.From, info.To)
+	}
+	if expected := "failure rate 100% >= 50%"; info.Reason != expected {
+		t.Errorf("Expected reason %q, got %q", expected, info.Reason)
+	}
+	if info.Counts.Failures != 1 || info.Counts.Total != 1 {
+		t.Errorf("Expected counts at trip time, got %+v", info.Counts)
+	}
+}
+
+func TestRejectionEventSampling(t *testing.T) {
+	counts := make(map[EventType]int)
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithRejectionEventSampling(10),
+		WithEventHandler(func(e Event) { counts[e.Type]++ }),
+	)
+
+	cb.RecordFailure()
+	for range 25 {
+		cb.Allow()
+	}
+
+	if counts[EventStateChange] != 1 || counts[EventRejected] != 2 {
+		t.Errorf("Expected 1 transition and 2 sampled rejections, got %v", counts)
+	}
+}
+
+func TestJSONEventHandler(t *testing.T) {
+	var buf strings.Builder
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithName("payments"),
+		WithEventHandler(NewJSONEventHandler(&buf)),
+	)
+
+	cb.RecordFailure()
+	cb.ForceClose()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", buf.String())
+	}
+
+	var event JSONEvent
+	if err := json.Unmarshal([]byte(lines[0]), &event); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if event.Breaker != "payments" || event.Type != EventStateChange || event.To != StateOpened {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestClosedStateSampling(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(3),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithClosedStateSampling(0.5),
+	)
+
+	var n int
+	cb.sampleRand = func() float64 {
+		n++
+		return float64(n%2) * 0.9
+	}
+
+	for range 4 {
+		cb.RecordFailure()
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state with 2 of 4 failures sampled, got %s", state)
+	}
+	if samples := cb.RecentSamples(); len(samples) != 2 {
+		t.Errorf("Expected only the 2 sampled failures to be kept, got %d", len(samples))
+	}
+
+	for range 2 {
+		cb.RecordFailure()
+	}
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected open state with 3 of 6 failures sampled, got %s", state)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	presets := []Preset{PresetDatabase(), PresetHTTPAPI(), PresetCache(), PresetAggressive(), PresetConservative()}
+	for _, preset := range presets {
+		cb := preset.New()
+		if cb.Name() != preset.Name || cb.State() != StateClosed {
+			t.Errorf("Unexpected breaker for preset %s: %s %s", preset.Name, cb.Name(), cb.State())
+		}
+	}
+
+	cb := PresetDatabase().New(WithName("orders-db"))
+	for range 19 {
+		cb.RecordFailure()
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state below minimum calls, got %s", state)
+	}
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened || cb.Name() != "orders-db" {
+		t.Errorf("Expected orders-db to open, got %s %s", cb.Name(), state)
+	}
+}
+
+// countsProbe is a custom threshold keeping the last value it checked
+type countsProbe struct {
+	last any
+}
+
+func (p *countsProbe) Check(value any) bool { p.last = value; return false }
+func (p *countsProbe) GetThreshold() any    { return nil }
+
+func TestCustomThresholdGetsCounts(t *testing.T) {
+	probe := &countsProbe{}
+	cb := NewCircuitBreaker(probe, NewInt64Threshold(1), time.Minute)
+	cb.RecordSuccess()
+	cb.RecordFailure()
+
+	counts, ok := probe.last.(Counts)
+	if !ok {
+		t.Fatalf("Expected Counts, got %T", probe.last)
+	}
+	if counts != (Counts{Successes: 1, Failures: 1, Total: 2}) {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+	if _, legacy := probe.last.(struct{ Successes, Failures, Total int64 }); legacy {
+		t.Error("Expected the anonymous counts struct to be replaced by Counts")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CB_PAYMENTS_FAILURE_RATE", "0.5")
+	t.Setenv("CB_PAYMENTS_MINIMUM_CALLS", "4")
+	t.Setenv("CB_PAYMENTS_OPEN_TIMEOUT", "1m")
+
+	config, err := ConfigFromEnv("CB_PAYMENTS")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Failure.Type != "float64" || time.Duration(config.OpenTimeout) != time.Minute {
+		t.Errorf("Unexpected config: %+v", config)
+	}
+
+	cb, err := config.New(WithName("payments"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cb.RecordSuccess()
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected open state at 50%% of 4 calls, got %s", state)
+	}
+
+	t.Setenv("CB_SEARCH_WINDOW", "10s")
+	t.Setenv("CB_SEARCH_MAX_FAILURES", "2")
+	config, err = ConfigFromEnv("CB_SEARCH")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	search, err := config.New(WithName("search"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	search.RecordFailure()
+	if state := search.State(); state != StateClosed {
+		t.Errorf("Expected state %s after 1 failure within the window, got %s", StateClosed, state)
+	}
+	search.RecordFailure()
+	if state := search.State(); state != StateOpened {
+		t.Errorf("Expected open state at 2 failures within the window, got %s", state)
+	}
+
+	t.Setenv("CB_PAYMENTS_OPEN_TIMEOUT", "soon")
+	if _, err := ConfigFromEnv("CB_PAYMENTS"); err == nil || !strings.Contains(err.Error(), "CB_PAYMENTS_OPEN_TIMEOUT") {
+		t.Errorf("Expected parse error naming the variable, got %v", err)
+	}
+}
+
+func TestProbeBudget(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Hour,
+		WithProbeBudget(2, time.Hour),
+	)
+	cb.RecordFailure()
+
+	errDownstream := errors.New("downstream error")
+	var calls int
+	for range 5 {
+		_ = cb.Execute(func() error { calls++; return errDownstream })
+	}
+	if calls != 2 || cb.State() != StateOpened {
+		t.Errorf("Expected 2 failed probes in open state, got %d calls in %s state", calls, cb.State())
+	}
+
+	cb = NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Hour,
+		WithProbeBudget(1, time.Hour),
+	)
+	cb.RecordFailure()
+	if err := cb.Execute(func() error { return nil }); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected half-open state after successful probe, got %s", state)
+	}
+}
+
+func TestBackoffPolicy(t *testing.T) {
+	exponential := ExponentialBackoff{Base: time.Second, Max: 5 * time.Second}
+	fibonacci := FibonacciBackoff{Base: time.Second}
+	for n, want := range map[int][2]time.Duration{
+		1: {time.Second, time.Second},
+		2: {2 * time.Second, time.Second},
+		3: {4 * time.Second, 2 * time.Second},
+		4: {5 * time.Second, 3 * time.Second},
+	} {
+		if got := exponential.OpenTimeout(n); got != want[0] {
+			t.Errorf("Expected exponential timeout %s for period %d, got %s", want[0], n, got)
+		}
+		if got := fibonacci.OpenTimeout(n); got != want[1] {
+			t.Errorf("Expected fibonacci timeout %s for period %d, got %s", want[1], n, got)
+		}
+	}
+
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Hour,
+		WithBackoffPolicy(ExponentialBackoff{Base: 10 * time.Millisecond}),
+	)
+
+	cb.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+	if state := cb.State(); state != StateHalfOpen {
+		t.Fatalf("Expected half-open after first period, got %s", state)
+	}
+
+	cb.RecordFailure()
+	time.Sleep(15 * time.Millisecond)
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected second open period to last longer, got %s", state)
+	}
+}
+
+func TestMarshalAndRestore(t *testing.T) {
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	cb.RecordFailure()
+
+	data, err := json.Marshal(cb)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var snapshot BreakerState
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if snapshot.State != StateOpened || snapshot.Config.Failure.Type != "int64" || snapshot.LastTransition == nil {
+		t.Errorf("Unexpected snapshot: %s", data)
+	}
+
+	restored := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Unexpected restore error: %v", err)
+	}
+	if state := restored.State(); state != StateOpened {
+		t.Errorf("Expected restored open state, got %s", state)
+	}
+
+	if err := restored.Restore(BreakerState{State: "broken"}); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("Expected %v, got %v", ErrInvalidState, err)
+	}
+
+	restored.ForceClose()
+	if snapshot := restored.Snapshot(); snapshot.State != StateOpened {
+		t.Errorf("Expected the unforced state in the snapshot, got %s", snapshot.State)
+	}
+}
+
+func TestRestoreResetsBreakerState(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithHalfOpenPolicy(NewSuccessCountPolicy(1, 1)),
+		WithHalfOpenCounterPolicy(HalfOpenCounterPolicy{OnEnter: CounterCarryOver}),
+	)
+	cb.RecordFailure()
+	cb.RecordFailure()
+
+	// the failures carried over by the trip must not reach the restored circuit
+	if err := cb.Restore(BreakerState{State: StateOpened, Since: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if cb.openStreak != 1 {
+		t.Errorf("Expected a new open streak, got %d", cb.openStreak)
+	}
+	if !cb.Allow() || cb.State() != StateHalfOpen {
+		t.Fatalf("Expected a probe in half-open state, got %s", cb.State())
+	}
+	if counts := cb.Counts(); counts != (Counts{}) {
+		t.Errorf("Expected no carried counts, got %+v", counts)
+	}
+
+	if err := cb.Restore(BreakerState{State: StateHalfOpen}); err != nil {
+		t.Fatal(err)
+	}
+	if !cb.Allow() {
+		t.Error("Expected the probes admitted before the restore to be forgotten")
+	}
+}
+
+func TestEventJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breaker.journal")
+	journal, err := OpenJournal(path, 300, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithName("payments"),
+		WithRejectionEventSampling(1),
+		WithEventHandler(journal.Handler()),
+	)
+
+	cb.RecordFailure()
+	cb.Allow()
+	cb.UpdateValues(NewInt64Threshold(5), NewInt64Threshold(2), time.Second)
+	cb.ForceClose()
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Record(Event{}); !errors.Is(err, ErrJournalClosed) {
+		t.Errorf("Expected ErrJournalClosed, got %v", err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("Expected the journal to be rotated: %v", err)
+	}
+	events, err := ReadJournalFiles(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make([]EventType, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	if !slices.Equal(types, []EventType{EventStateChange, EventConfigChange, EventOverride}) || events[0].Breaker != "payments" {
+		t.Errorf("Expected transition, config change and override in order, got %+v", events)
+	}
+}
+
+// worker pool
+
+func TestPoolPausesWhileOpen(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		100*time.Millisecond,
+	)
+
+	pool := NewPool(cb, 2, 10)
+	pool.SetPauseInterval(5 * time.Millisecond)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	failed := make(chan struct{})
+	if err := pool.Submit(context.Background(), func(context.Context) error {
+		defer close(failed)
+		return errors.New("downstream error")
+	}); err != nil {
+		t.Fatalf("Unexpected submit error: %v", err)
+	}
+	<-failed
+
+	var processed atomic.Int64
+	for range 3 {
+		if err := pool.Submit(context.Background(), func(context.Context) error {
+			processed.Add(1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Unexpected submit error: %v", err)
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := processed.Load(); n != 0 {
+		t.Errorf("Expected no jobs processed while open, got %d", n)
+	}
+	if n := cb.Metrics().Rejected; n != 0 {
+		t.Errorf("Expected paused workers to cause no rejections, got %d", n)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if n := processed.Load(); n != 3 {
+		t.Errorf("Expected 3 jobs processed after recovery, got %d", n)
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected state %s, got %s", StateClosed, state)
+	}
+
+	pool.Stop()
+	if err := pool.Submit(context.Background(), func(context.Context) error { return nil }); !errors.Is(err, ErrPoolStopped) {
+		t.Errorf("Expected %v after stop, got %v", ErrPoolStopped, err)
+	}
+}
+
+// execute and metrics
+
+func TestExecute(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+	)
+
+	value, err := Execute(cb, func() (int, error) { return 42, nil })
+	if err != nil || value != 42 {
+		t.Errorf("Expected 42 and no error, got %d and %v", value, err)
+	}
+
+	errDownstream := errors.New("downstream error")
+	if err := cb.Execute(func() error { return errDownstream }); !errors.Is(err, errDownstream) {
+		t.Errorf("Expected downstream error, got %v", err)
+	}
+
+	called := false
+	if err := cb.Execute(func() error { called = true; return nil }); !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected %v, got %v", ErrOpenState, err)
+	}
+	if called {
+		t.Error("Expected fn not to be called while open")
+	}
+
+	if count := cb.Metrics().Latency.Count; count != 2 {
+		t.Errorf("Expected 2 latency samples, got %d", count)
+	}
+}
+
+func TestIgnoreContextErrors(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+		IgnoreContextErrors(true),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cb.ExecuteContext(ctx, func(ctx context.Context) error { return ctx.Err() })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected caller cancellation to be ignored, got state %s", state)
+	}
+
+	err = cb.ExecuteContext(context.Background(), func(context.Context) error { return context.DeadlineExceeded })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected downstream deadline to count as failure, got state %s", state)
+	}
+
+	plain := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second, IgnoreContextErrors(true))
+	_ = plain.Execute(func() error { return context.DeadlineExceeded })
+	if state := plain.State(); state != StateOpened {
+		t.Errorf("Expected the deadline of fn in Execute to count as failure, got state %s", state)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := NewLatencyHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	snapshot := h.Snapshot()
+	if snapshot.Count != 1000 || snapshot.Min != time.Millisecond || snapshot.Max != time.Second {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+
+	within := func(actual, expected time.Duration) bool {
+		diff := actual - expected
+		if diff < 0 {
+			diff = -diff
+		}
+		return float64(diff) <= float64(expected)*0.07
+	}
+	if !within(snapshot.P50, 500*time.Millisecond) {
+		t.Errorf("Expected p50 near 500ms, got %s", snapshot.P50)
+	}
+	if !within(snapshot.P99, 990*time.Millisecond) {
+		t.Errorf("Expected p99 near 990ms, got %s", snapshot.P99)
+	}
+
+	h.Reset()
+	if p := h.Percentile(50); p != 0 {
+		t.Errorf("Expected 0 after reset, got %s", p)
+	}
+}
+
+func TestRejectedCounters(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		20*time.Millisecond,
+	)
+
+	cb.RecordFailure()
+	for range 3 {
+		cb.Allow()
+	}
+
+	metrics := cb.Metrics()
+	if metrics.Rejected != 3 || metrics.RejectedInState != 3 {
+		t.Errorf("Expected 3 rejected calls, got %d total and %d in state", metrics.Rejected, metrics.RejectedInState)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	cb.Allow()
+
+	metrics = cb.Metrics()
+	if metrics.Rejected != 3 || metrics.RejectedInState != 0 {
+		t.Errorf("Expected 3 total and 0 in state after transition, got %d and %d", metrics.Rejected, metrics.RejectedInState)
+	}
+}
+
+func TestDecorators(t *testing.T) {
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	shadowed := 0
+	inner := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second)
+	b := Decorate(inner, Shadow(func() { shadowed++ }), Logging(logger, "payments"))
+
+	if err := Do(b, func() error { return errors.New("downstream error") }); err == nil {
+		t.Error("Expected downstream error")
+	}
+	if state := b.State(); state != StateOpened {
+		t.Errorf("Expected state %s, got %s", StateOpened, state)
+	}
+
+	if !b.Allow() {
+		t.Error("Expected shadow mode to admit the call")
+	}
+	if shadowed != 1 {
+		t.Errorf("Expected 1 shadowed rejection, got %d", shadowed)
+	}
+
+	for _, msg := range []string{"circuit breaker state changed", "circuit breaker rejected call", "breaker=payments"} {
+		if !strings.Contains(logs.String(), msg) {
+			t.Errorf("Expected log to contain %q, got %s", msg, logs.String())
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+	t.Run("Token Bucket", func(t *testing.T) {
+		limiter := NewRateLimiter(10, 2)
+		limiter.SetClock(clock)
+
+		if !limiter.Allow() || !limiter.Allow() {
+			t.Error("Expected burst of 2 to be allowed")
+		}
+		if limiter.Allow() {
+			t.Error("Expected empty bucket to reject")
+		}
+
+		clock.Add(100 * time.Millisecond)
+		if !limiter.Allow() {
+			t.Error("Expected a token after refill")
+		}
+	})
+
+	t.Run("Limit Before Breaker", func(t *testing.T) {
+		limiter := NewRateLimiter(0, 1)
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Second,
+			WithRateLimiter(limiter, LimitBeforeBreaker),
+		)
+
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected %v, got %v", ErrRateLimited, err)
+		}
+		if rejected := cb.Metrics().Rejected; rejected != 0 {
+			t.Errorf("Expected rate limited calls not to count as rejected, got %d", rejected)
+		}
+	})
+
+	t.Run("Limit After Breaker", func(t *testing.T) {
+		limiter := NewRateLimiter(0, 1)
+		cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second)
+		b := Decorate(cb, RateLimit(limiter, LimitAfterBreaker))
+
+		cb.ForceOpen()
+		if b.Allow() {
+			t.Error("Expected open breaker to reject")
+		}
+		cb.Reset()
+		if !b.Allow() {
+			t.Error("Expected token to be kept while breaker rejected")
+		}
+	})
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Run("AIMD", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(4, 1, 10, NewAIMD(1, 0.5, 100*time.Millisecond))
+
+		for range 4 {
+			if !limiter.TryAcquire() {
+				t.Fatal("Expected slot within limit")
+			}
+		}
+		if limiter.TryAcquire() {
+			t.Error("Expected limit to be enforced")
+		}
+
+		limiter.Release(10*time.Millisecond, false)
+		if limit := limiter.Limit(); limit != 5 {
+			t.Errorf("Expected limit to grow to 5, got %d", limit)
+		}
+
+		limiter.Release(time.Second, false)
+		if limit := limiter.Limit(); limit != 2 {
+			t.Errorf("Expected limit to shrink to 2 after slow call, got %d", limit)
+		}
+	})
+
+	t.Run("Gradient", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(20, 1, 100, NewGradient(1.5, 0.5, 100))
+
+		for range 20 {
+			limiter.TryAcquire()
+		}
+		for range 10 {
+			limiter.Release(10*time.Millisecond, false)
+		}
+		before := limiter.Limit()
+
+		for range 10 {
+			limiter.TryAcquire()
+		}
+		for range 10 {
+			limiter.Release(time.Second, false)
+		}
+		if after := limiter.Limit(); after >= before {
+			t.Errorf("Expected limit to shrink when latency grows, got %d -> %d", before, after)
+		}
+	})
+
+	t.Run("Minimum Limit", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(2, 0, 10, NewAIMD(1, 0.5, 100*time.Millisecond))
+		for range 5 {
+			if !limiter.TryAcquire() {
+				t.Fatalf("Expected a slot after the limit shrank to %d", limiter.Limit())
+			}
+			limiter.Release(time.Second, true)
+		}
+		if limit := limiter.Limit(); limit != 1 {
+			t.Errorf("Expected the limit to stop at 1, got %d", limit)
+		}
+
+		if limiter := NewConcurrencyLimiter(0, 0, 0, NewAIMD(1, 0.5, 0)); !limiter.TryAcquire() {
+			t.Error("Expected a zero limiter to admit one call")
+		}
+	})
+
+	t.Run("Execute", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(1, 1, 1, NewAIMD(1, 0.5, 0))
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Second,
+			WithConcurrencyLimiter(limiter),
+		)
+
+		err := cb.Execute(func() error {
+			return cb.Execute(func() error { return nil })
+		})
+		if !errors.Is(err, ErrConcurrencyLimited) {
+			t.Errorf("Expected %v, got %v", ErrConcurrencyLimited, err)
+		}
+		if n := limiter.Inflight(); n != 0 {
+			t.Errorf("Expected slots to be released, got %d in flight", n)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(1, 1, 1, NewAIMD(1, 0.5, 0))
+		cb := NewCircuitBreaker(NewInt64Threshold(5), NewInt64Threshold(1), time.Second, WithConcurrencyLimiter(limiter))
+
+		func() {
+			defer func() { _ = recover() }()
+			_ = cb.Execute(func() error { panic("boom") })
+		}()
+		if n := limiter.Inflight(); n != 0 {
+			t.Errorf("Expected the slot of the panicking call to be released, got %d in flight", n)
+		}
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Errorf("Expected the next call to run, got %v", err)
+		}
+	})
+
+	t.Run("HalfOpenProbe", func(t *testing.T) {
+		limiter := NewConcurrencyLimiter(1, 1, 1, NewAIMD(1, 0.5, 0))
+		cb := NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			20*time.Millisecond,
+			WithConcurrencyLimiter(limiter),
+			WithHalfOpenPolicy(NewSuccessCountPolicy(1, 1)),
+		)
+		cb.RecordFailure()
+		time.Sleep(30 * time.Millisecond)
+
+		limiter.TryAcquire()
+		if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrConcurrencyLimited) {
+			t.Fatalf("Expected %v, got %v", ErrConcurrencyLimited, err)
+		}
+		limiter.Release(0, false)
+
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Errorf("Expected the probe slot to be free, got %v", err)
+		}
+		if state := cb.State(); state != StateClosed {
+			t.Errorf("Expected the probe to close the circuit, got %s", state)
+		}
+	})
+}
+
+func TestFallback(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+	)
+
+	errDownstream := errors.New("downstream error")
+	value, err := ExecuteFallback(cb, func() (int, error) { return 0, errDownstream }, FallbackValue(7))
+	if err != nil || value != 7 {
+		t.Errorf("Expected fallback 7 and no error, got %d and %v", value, err)
+	}
+
+	value, err = ExecuteFallback(cb, func() (int, error) { return 42, nil }, FallbackFunc(func(err error) int {
+		if errors.Is(err, ErrOpenState) {
+			return -1
+		}
+		return 0
+	}))
+	if err != nil || value != -1 {
+		t.Errorf("Expected computed fallback -1 for open state, got %d and %v", value, err)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		50*time.Millisecond,
+	)
+
+	primaryErr := errors.New("primary down")
+	var primaryFails atomic.Bool
+	primaryFails.Store(true)
+
+	failover := NewFailover(cb,
+		func(context.Context) (string, error) {
+			if primaryFails.Load() {
+				return "", primaryErr
+			}
+			return "primary", nil
+		},
+		func(context.Context) (string, error) { return "secondary", nil },
+	)
+
+	var switches []string
+	failover.OnSwitchover(func(e Event) { switches = append(switches, e.To) })
+
+	if _, err := failover.Execute(context.Background()); !errors.Is(err, primaryErr) {
+		t.Errorf("Expected primary error, got %v", err)
+	}
+	if value, err := failover.Execute(context.Background()); err != nil || value != "secondary" {
+		t.Errorf("Expected secondary while open, got %s and %v", value, err)
+	}
+
+	primaryFails.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if value, err := failover.Execute(context.Background()); err != nil || value != "primary" {
+		t.Errorf("Expected primary after recovery, got %s and %v", value, err)
+	}
+
+	if len(switches) != 2 || switches[0] != FailoverSecondary || switches[1] != FailoverPrimary {
+		t.Errorf("Expected switchover to secondary and back, got %v", switches)
+	}
+}
+
+func TestTags(t *testing.T) {
+	var events []Event
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+		WithEventHandler(func(e Event) { events = append(events, e) }),
+	)
+
+	ctx := WithTags(context.Background(), Tags{TagOperation: "get-user"})
+	ctx = WithTags(ctx, Tags{"tenant": "acme"})
+
+	_ = cb.ExecuteContext(ctx, func(context.Context) error { return errors.New("downstream error") })
+
+	if len(events) != 1 || events[0].Tags[TagOperation] != "get-user" || events[0].Tags["tenant"] != "acme" {
+		t.Errorf("Expected state change event with call tags, got %+v", events)
+	}
+	if count := cb.Metrics().Operations["get-user"].Count; count != 1 {
+		t.Errorf("Expected 1 latency sample for get-user, got %d", count)
+	}
+}
+
+func TestErrorWrapping(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithName("payments"),
+		WithErrorWrapping(),
+	)
+
+	errDownstream := errors.New("downstream error")
+	err := cb.Execute(func() error { return errDownstream })
+	if !errors.Is(err, errDownstream) || err.Error() != `breaker "payments" (closed): downstream error` {
+		t.Errorf("Expected wrapped downstream error, got %v", err)
+	}
+
+	err = cb.Execute(func() error { return nil })
+	if !errors.Is(err, ErrOpenState) || !strings.HasPrefix(err.Error(), `breaker "payments" (open): `) {
+		t.Errorf("Expected wrapped %v, got %v", ErrOpenState, err)
+	}
+}
+
+func TestInflightDrainBeforeHalfOpen(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		10*time.Millisecond,
+		WithDrainBeforeHalfOpen(),
+	)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = cb.Execute(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	if n := cb.Inflight(); n != 1 {
+		t.Errorf("Expected 1 call in flight, got %d", n)
+	}
+
+	cb.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected open state while the previous call is in flight, got %s", state)
+	}
+
+	close(release)
+	<-done
+
+	if n := cb.Metrics().Inflight; n != 0 {
+		t.Errorf("Expected no calls in flight, got %d", n)
+	}
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected half-open state after drain, got %s", state)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+	)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		_ = cb.Execute(func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := cb.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v while the call is in flight, got %v", context.DeadlineExceeded, err)
+	}
+
+	if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrDraining) {
+		t.Errorf("Expected %v, got %v", ErrDraining, err)
+	}
+
+	close(release)
+	if err := cb.Drain(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestSharedProbe(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		10*time.Millisecond,
+	)
+	cb.RecordFailure()
+	time.Sleep(20 * time.Millisecond)
+
+	probe := NewSharedProbe[int](cb)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = probe.Execute(context.Background(), fn)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected a single probe, got %d calls", n)
+	}
+	for _, result := range results {
+		if result != 42 {
+			t.Errorf("Expected shared result 42, got %v", results)
+			break
+		}
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state after probe, got %s", state)
+	}
+}
+
+func TestStreamGuard(t *testing.T) {
+	cb := NewCircuitBreaker(NewInt64Threshold(2), NewInt64Threshold(1), 50*time.Millisecond)
+	guard := NewStreamGuard(cb)
+	guard.PollInterval = 5 * time.Millisecond
+	ctx := context.Background()
+	dial := func(context.Context) error { return nil }
+
+	stream, err := guard.Connect(ctx, dial)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	stream.Close(io.EOF)
+	stream, _ = guard.Connect(ctx, dial)
+	stream.Close(errors.New("connection reset"))
+	stream.Close(errors.New("connection reset"))
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("Expected normal closure and repeated Close not to count, got %s", state)
+	}
+
+	if _, err := guard.Connect(ctx, func(context.Context) error { return errors.New("handshake failed") }); err == nil {
+		t.Fatal("Expected dial error")
+	}
+	if _, err := guard.Connect(ctx, dial); !errors.Is(err, ErrOpenState) {
+		t.Fatalf("Expected %v, got %v", ErrOpenState, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := guard.Reconnect(ctx); err != nil {
+		t.Fatalf("Expected reconnect gate to open, got %v", err)
+	}
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected state %s after the gate opened, got %s", StateHalfOpen, state)
+	}
+}
+
+func TestSenderSpillsWhileOpen(t *testing.T) {
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), 20*time.Millisecond)
+	var (
+		mu        sync.Mutex
+		down      = true
+		delivered []string
+	)
+	sender := NewSender(cb, func(_ context.Context, payload string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			return errors.New("smtp unavailable")
+		}
+		delivered = append(delivered, payload)
+		return nil
+	}, NewMemoryBuffer[string](2))
+	ctx := context.Background()
+
+	for _, payload := range []string{"first", "second"} {
+		if err := sender.Send(ctx, payload); err != nil {
+			t.Fatalf("Expected %q to be buffered, got %v", payload, err)
+		}
+	}
+	if err := sender.Send(ctx, "third"); !errors.Is(err, ErrBufferFull) || !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected full buffer error, got %v", err)
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	time.Sleep(30 * time.Millisecond)
+
+	if sent, err := sender.Flush(ctx); err != nil || sent != 2 {
+		t.Fatalf("Expected 2 flushed payloads, got %d, %v", sent, err)
+	}
+	if len(delivered) != 2 || delivered[0] != "first" || sender.Pending() != 0 {
+		t.Errorf("Expected payloads delivered in order, got %v", delivered)
+	}
+}
+
+func TestTaskReschedulesWhileOpen(t *testing.T) {
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute)
+	var rescheduled []time.Duration
+	task := NewTask(cb, func(context.Context, string) error {
+		return errors.New("payment provider unavailable")
+	}, func(_ context.Context, _ string, delay time.Duration) error {
+		rescheduled = append(rescheduled, delay)
+		return nil
+	})
+	ctx := context.Background()
+
+	if err := task.Run(ctx, "invoice-1"); err == nil {
+		t.Fatal("Expected task error")
+	}
+	if err := task.Run(ctx, "invoice-2"); err != nil {
+		t.Fatalf("Expected rejected task to be rescheduled, got %v", err)
+	}
+	if len(rescheduled) != 1 || rescheduled[0] <= 59*time.Second {
+		t.Errorf("Expected reschedule after the open timeout, got %v", rescheduled)
+	}
+
+	var reschedule *RescheduleError
+	if err := NewTask(cb, func(context.Context, string) error { return nil }, nil).Run(ctx, "invoice-3"); !errors.As(err, &reschedule) || !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected reschedule error, got %v", err)
+	}
+}
+
+func TestFeedOutcomes(t *testing.T) {
+	start := time.Now()
+	cb := NewCircuitBreaker(
+		NewFloat64Threshold(0.5),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithMinimumCalls(4),
+		WithCounterRotation(time.Second),
+	)
+
+	ch := make(chan Outcome, 8)
+	ch <- Outcome{Time: start, Success: false}
+	ch <- Outcome{Time: start, Success: false}
+	// the outcome timestamps rotate the counters, the failures above are discarded
+	ch <- Outcome{Time: start.Add(2 * time.Second), Success: true, Latency: 20 * time.Millisecond, Tags: Tags{TagOperation: "charge"}}
+	ch <- Outcome{Time: start.Add(2 * time.Second), Success: false}
+	ch <- Outcome{Time: start.Add(2 * time.Second), Success: true}
+	close(ch)
+	cb.Feed(ch)
+
+	metrics := cb.Metrics()
+	if metrics.State != StateClosed || metrics.Counts.Total != 3 || metrics.Counts.Failures != 1 {
+		t.Errorf("Expected counters of the outcome interval only, got %+v", metrics)
+	}
+	if metrics.Operations["charge"].Count != 1 {
+		t.Errorf("Expected tagged latency to be recorded, got %+v", metrics.Operations)
+	}
+}
+
+func TestPermitAttribution(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		10*time.Second,
+		WithClock(clock),
+		WithPermitTimeout(time.Second),
+	)
+
+	late, err := cb.Acquire()
+	if err != nil || late.State != StateClosed {
+		t.Fatalf("Expected permit in closed state, got %+v, %v", late, err)
+	}
+	failing, _ := cb.Acquire()
+	failing.Failure()
+	if _, err := cb.Acquire(); !errors.Is(err, ErrOpenState) {
+		t.Fatalf("Expected %v, got %v", ErrOpenState, err)
+	}
+
+	clock.Add(11 * time.Second)
+	probe, err := cb.Acquire()
+	if err != nil || probe.State != StateHalfOpen {
+		t.Fatalf("Expected half-open probe, got %+v, %v", probe, err)
+	}
+	done := make(chan struct{})
+	go func() {
+		late.Success()
+		close(done)
+	}()
+	<-done
+	probe.Ignore()
+	probe.Success()
+	if state := cb.State(); state != StateHalfOpen {
+		t.Fatalf("Expected late and ignored results not to close the circuit, got %s", state)
+	}
+
+	probe, _ = cb.Acquire()
+	clock.Add(2 * time.Second)
+	probe.Success()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected result after the deadline to count as failure, got %s", state)
+	}
+	if inflight := cb.Inflight(); inflight != 0 {
+		t.Errorf("Expected all permits released, got %d", inflight)
+	}
+}
+
+func TestRecordIgnore(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(
+		NewFloat64Threshold(0.5),
+		NewInt64Threshold(1),
+		10*time.Second,
+		WithClock(clock),
+		WithMinimumCalls(2),
+		WithHalfOpenPolicy(NewSuccessCountPolicy(1, 1)),
+	)
+
+	cb.RecordSuccess()
+	cb.RecordIgnore()
+	if counts := cb.Counts(); counts.Total != 1 {
+		t.Errorf("Expected ignored call not to be counted, got %+v", counts)
+	}
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Fatalf("Expected state %s, got %s", StateOpened, state)
+	}
+
+	clock.Add(11 * time.Second)
+	probe, err := cb.Acquire()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cb.Allow() {
+		t.Fatal("Expected the only probe slot to be taken")
+	}
+	probe.Ignore()
+	if !cb.Allow() {
+		t.Error("Expected ignored probe to give its slot back")
+	}
+	if ignored := cb.Metrics().Ignored; ignored != 2 {
+		t.Errorf("Expected 2 ignored calls, got %d", ignored)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(NewInt64Threshold(3), NewInt64Threshold(1), time.Minute, WithClock(clock))
+	budget := NewRetryBudget(cb, 0.1, 1, 10*time.Second)
+
+	for range 10 {
+		budget.RecordRequest()
+	}
+	for i := range 2 {
+		if err := budget.AllowRetry(); err != nil {
+			t.Fatalf("Expected retry %d within the budget, got %v", i, err)
+		}
+	}
+	if err := budget.AllowRetry(); !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Errorf("Expected %v, got %v", ErrRetryBudgetExhausted, err)
+	}
+
+	clock.Add(11 * time.Second)
+	calls := 0
+	err := budget.Do(context.Background(), 5, nil, func(context.Context) error {
+		calls++
+		return cb.Execute(func() error { return errors.New("unavailable") })
+	})
+	if err == nil || calls != 2 {
+		t.Errorf("Expected one retry from the minimum budget, got %d calls, %v", calls, err)
+	}
+
+	cb.RecordFailure()
+	if err := budget.AllowRetry(); !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected retries suppressed by the open circuit, got %v", err)
+	}
+}
+
+func TestPartitionedBreaker(t *testing.T) {
+	partitioned := NewPartitioned(4, func(shard int) *CircuitBreaker {
+		return NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute, WithName(fmt.Sprintf("shard-%d", shard)))
+	})
+	partitioned.SetHash(func(key string) uint64 {
+		n, _ := strconv.ParseUint(strings.TrimPrefix(key, "user-"), 10, 64)
+		return n
+	})
+	ctx := context.Background()
+
+	_ = partitioned.Execute(ctx, "user-6", func(context.Context) error { return errors.New("shard down") })
+	if err := partitioned.Execute(ctx, "user-2", func(context.Context) error { return nil }); !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected keys of the failing shard to be rejected, got %v", err)
+	}
+	if err := partitioned.Execute(ctx, "user-3", func(context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected other shards to be served, got %v", err)
+	}
+
+	state := partitioned.State()
+	if state.Status != HealthDegraded || state.Open != 1 || state.Closed != 3 || state.Shards[2].State != StateOpened {
+		t.Errorf("Unexpected aggregate state: %+v", state)
+	}
+	if state.Counts.Successes != 1 {
+		t.Errorf("Expected summed counters, got %+v", state.Counts)
+	}
+}
+
+func TestPeerComparator(t *testing.T) {
+	breakers := NewKeyedBreaker(func(string) *CircuitBreaker {
+		return NewCircuitBreaker(NewFloat64Threshold(0.5), NewInt64Threshold(1), time.Minute, WithMinimumCalls(100))
+	})
+	comparator := NewPeerComparator(breakers)
+	comparator.Factor = 1
+
+	peers := []string{"orders-1", "orders-2", "orders-3", "orders-4"}
+	for i := range 20 {
+		for _, peer := range peers {
+			if peer == "orders-4" && i%3 == 0 {
+				breakers.Get(peer).RecordFailure()
+			} else {
+				breakers.Get(peer).RecordSuccess()
+			}
+		}
+	}
+
+	opened := comparator.Compare(peers...)
+	if len(opened) != 1 || opened[0] != "orders-4" {
+		t.Fatalf("Expected the deviating replica to be opened, got %v", opened)
+	}
+	if state := breakers.Get("orders-1").State(); state != StateClosed {
+		t.Errorf("Expected healthy peers to stay closed, got %s", state)
+	}
+	if opened := comparator.Compare(peers...); len(opened) != 0 {
+		t.Errorf("Expected too few closed peers to compare, got %v", opened)
+	}
+}
+
+func TestSoftOpen(t *testing.T) {
+	var events []EventType
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(4),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithSoftOpen(NewInt64Threshold(2), 0.5),
+		WithEventHandler(func(e Event) { events = append(events, e.Type) }),
+	)
+	draws := []float64{0.2, 0.7}
+	cb.soft.rand = func() float64 {
+		draw := draws[0]
+		draws = append(draws[1:], draw)
+		return draw
+	}
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if !cb.Degraded() || cb.State() != StateClosed {
+		t.Fatalf("Expected degraded closed breaker, got %s", cb.State())
+	}
+	if cb.Allow() || !cb.Allow() {
+		t.Error("Expected half of the calls to be shed")
+	}
+
+	cb.RecordSuccess()
+	if cb.Degraded() || !cb.Allow() || !cb.Allow() {
+		t.Error("Expected shedding to stop below the soft threshold")
+	}
+
+	for range 4 {
+		cb.RecordFailure()
+	}
+	if cb.Degraded() || cb.State() != StateOpened {
+		t.Errorf("Expected escalation to open, got %s", cb.State())
+	}
+	want := []EventType{EventDegraded, EventDegradedEnd, EventDegraded, EventDegradedEnd, EventStateChange}
+	if !slices.Equal(events, want) {
+		t.Errorf("Expected events %v, got %v", want, events)
+	}
+}
+
+func TestRecordLoad(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(5),
+		NewInt64Threshold(1),
+		10*time.Millisecond,
+		WithLoadLimit("cpu", 0.9),
+		WithTripPolicy(TripFunc(func(stats Stats) (bool, string) {
+			return stats.Load["queue_depth"] >= 100, "queue is full"
+		})),
+	)
+
+	cb.RecordLoad("cpu", 0.5)
+	cb.RecordLoad("queue_depth", 10)
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("Expected closed below the load limit, got %s", state)
+	}
+
+	cb.RecordLoad("cpu", 0.95)
+	if tr := cb.LastTransition(); tr.To != StateOpened || tr.Reason != "load cpu 0.95 >= 0.9" {
+		t.Fatalf("Expected the load limit to open the circuit, got %+v", tr)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	cb.RecordLoad("cpu", 0.3)
+	cb.Allow()
+	cb.RecordSuccess()
+	cb.RecordLoad("queue_depth", 150)
+	cb.RecordSuccess()
+	if tr := cb.LastTransition(); tr.To != StateOpened || tr.Reason != "queue is full" {
+		t.Errorf("Expected the trip policy to read the load, got %+v", tr)
+	}
+	if load := cb.Load(); load["cpu"] != 0.3 || load["queue_depth"] != 150 {
+		t.Errorf("Unexpected load %v", load)
+	}
+}
+
+func TestRuntimeThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(NewRuntimeThreshold(1_000_000, 0), NewInt64Threshold(1), time.Minute)
+	cb.RecordFailure()
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("Expected closed below the goroutine limit, got %s", state)
+	}
+
+	threshold := NewRuntimeThreshold(1, 0)
+	cb = NewCircuitBreaker(threshold, NewInt64Threshold(1), time.Minute)
+
+	cb.RecordFailure()
+	if tr := cb.LastTransition(); tr.To != StateOpened || !strings.Contains(tr.Reason, "goroutines") {
+		t.Errorf("Expected the goroutine limit to trip, got %+v", tr)
+	}
+
+	watched := NewCircuitBreaker(NewInt64Threshold(5), NewInt64Threshold(1), time.Minute, WithLoadLimit(LoadGoroutines, 1))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go WatchRuntime(ctx, watched, time.Millisecond)
+	for watched.State() != StateOpened && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if load := watched.Load(); load[LoadHeapBytes] <= 0 || watched.State() != StateOpened {
+		t.Errorf("Expected the watchdog to open the circuit, got %s and load %v", watched.State(), load)
+	}
+}
+
+func TestCostBudget(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(10),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithSoftOpen(NewFloat64Threshold(0.5), 0),
+		WithCostBudget(10, time.Minute),
+	)
+	if !cb.AllowCost(100) {
+		t.Fatal("Expected no budget while healthy")
+	}
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+	call := func(cost int64) error {
+		return cb.ExecuteContext(WithCost(context.Background(), cost), func(context.Context) error { return nil })
+	}
+	if err := call(8); err != nil {
+		t.Fatalf("Expected a call within the budget, got %v", err)
+	}
+	if err := call(8); !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected the heavy call to be shed, got %v", err)
+	}
+	if !cb.AllowCost(1) || !cb.AllowCost(1) || cb.AllowCost(1) {
+		t.Error("Expected light calls admitted while the budget lasts")
+	}
+}
+
+func TestDebugDump(t *testing.T) {
+	window := NewSlidingWindowThreshold(time.Minute, 3, "payments-window")
+	cb := NewCircuitBreaker(NewInt64Threshold(2), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	cb.UpdateValues(NewInt64Threshold(2), window, time.Minute)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_ = cb.Execute(func() error { return nil })
+				_ = cb.DebugDump()
+			}
+		}()
+	}
+	wg.Wait()
+
+	window.RecordFailure()
+	cb.RecordFailure()
+	cb.RecordFailure()
+	cb.ForceCloseBy("alice")
+
+	dump := cb.DebugDump()
+	for _, want := range []string{
+		`breaker "payments"`,
+		"state: open",
+		"forced: closed",
+		"counters: successes 0, failures 0",
+		"window: payments-window, 1 of 3 failures within 1m0s",
+		"closed -> open: failures 2 >= 2",
+		"open -> closed: forced closed (by alice)",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Expected %q in dump:\n%s", want, dump)
+		}
+	}
+	if n := len(cb.Transitions()); n != 2 {
+		t.Errorf("Expected 2 recent transitions, got %d", n)
+	}
+}
+
+func TestCandidateThreshold(t *testing.T) {
+	var reasons []string
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(3),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithCandidateThreshold(NewInt64Threshold(2)),
+		WithEventHandler(func(e Event) {
+			if e.Type == EventCandidateDivergence {
+				reasons = append(reasons, e.Reason)
+			}
+		}),
+	)
+
+	for range 3 {
+		cb.RecordFailure()
+	}
+	if stats := cb.CandidateStats(); stats.WouldHaveOpened != 1 || stats.WouldHaveStayed != 0 {
+		t.Errorf("Expected one would-have-opened divergence, got %+v", stats)
+	}
+	if len(reasons) != 1 || reasons[0] != "candidate would have opened: failures 2 >= 2" {
+		t.Errorf("Unexpected divergence events %v", reasons)
+	}
+
+	stayed := NewCircuitBreaker(NewInt64Threshold(2), NewInt64Threshold(1), time.Minute, WithCandidateThreshold(NewInt64Threshold(5)))
+	stayed.RecordFailure()
+	stayed.RecordFailure()
+	if stats := stayed.CandidateStats(); stats.WouldHaveStayed != 1 {
+		t.Errorf("Expected one would-have-stayed divergence, got %+v", stats)
+	}
+}
+
+func TestBypass(t *testing.T) {
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute)
+	cb.RecordFailure()
+
+	ok := func(context.Context) error { return nil }
+	if err := cb.ExecuteContext(context.Background(), ok); !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected %v, got %v", ErrOpenState, err)
+	}
+
+	privileged := WithBypass(context.Background())
+	if err := cb.ExecuteContext(privileged, ok); err != nil {
+		t.Errorf("Expected the bypassing call to run, got %v", err)
+	}
+	cb.ForceOpen()
+	if err := cb.ExecuteContext(privileged, func(context.Context) error { return errors.New("boom") }); err == nil || errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected the bypassing call to run while forced open, got %v", err)
+	}
+
+	metrics := cb.Metrics()
+	if metrics.Bypassed != 2 || metrics.Rejected != 1 {
+		t.Errorf("Expected 2 bypassed and 1 rejected calls, got %+v", metrics)
+	}
+	if samples := cb.RecentSamples(); len(samples) != 2 || !samples[1].Success || samples[1].State != StateOpened {
+		t.Errorf("Expected the outcome in open state to be recorded, got %+v", samples)
+	}
+}
+
+func TestSimulateOpen(t *testing.T) {
+	events := make(chan Event, 10)
+	cb := NewCircuitBreaker(NewInt64Threshold(3), NewInt64Threshold(1), time.Minute,
+		WithEventHandler(func(e Event) { events <- e }))
+
+	cb.SimulateOpen(50 * time.Millisecond)
+	if e := <-events; e.Type != EventSimulatedOpen || e.To != StateOpened {
+		t.Errorf("Expected a simulated open event, got %+v", e)
+	}
+
+	for range 3 {
+		if !cb.Allow() {
+			t.Fatal("Expected calls to be admitted during the simulation")
+		}
+	}
+	metrics := cb.Metrics()
+	if metrics.State != StateOpened || !metrics.Simulated || metrics.Rejected != 0 || metrics.Admitted != 3 {
+		t.Errorf("Expected the metrics of an open circuit, got %+v", metrics)
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected the real state to stay %s, got %s", StateClosed, state)
+	}
+
+	select {
+	case e := <-events:
+		if e.Type != EventSimulatedOpenEnd || e.From != StateOpened || e.To != StateClosed {
+			t.Errorf("Expected the simulated close event, got %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the simulation to end")
+	}
+	if cb.Simulating() || cb.Metrics().State != StateClosed {
+		t.Error("Expected the simulation to be over")
+	}
+}
+
+// registry and admin API
+
+func TestForceAndReset(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+	)
+
+	cb.ForceOpen()
+	if cb.Allow() || !cb.Forced() {
+		t.Error("Expected forced open breaker to reject calls")
+	}
+
+	cb.ForceClose()
+	cb.RecordFailure()
+	if !cb.Allow() {
+		t.Error("Expected forced closed breaker to allow calls")
+	}
+
+	cb.Reset()
+	if cb.Forced() {
+		t.Error("Expected Reset to clear override")
+	}
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected state %s after reset, got %s", StateOpened, state)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	registry := NewRegistry()
+	for _, name := range []string{"payments", "users"} {
+		cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second, WithName(name))
+		if err := registry.Register(cb); err != nil {
+			t.Fatalf("Unexpected register error: %v", err)
+		}
+	}
+
+	if err := registry.Register(NewCircuitBreaker(nil, nil, 0, WithName("users"))); !errors.Is(err, ErrAlreadyRegistered) {
+		t.Errorf("Expected %v, got %v", ErrAlreadyRegistered, err)
+	}
+	if err := registry.Register(NewCircuitBreaker(nil, nil, 0)); !errors.Is(err, ErrEmptyName) {
+		t.Errorf("Expected %v, got %v", ErrEmptyName, err)
+	}
+
+	server := httptest.NewServer(NewAdminHandler(registry, BearerIdentity(map[string]string{"secret": "alice"})))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/breakers/payments/force-open", "", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if payments, _ := registry.Get("payments"); resp.StatusCode != http.StatusUnauthorized || payments.Forced() {
+		t.Errorf("Expected the unauthenticated action to be rejected, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/breakers/payments/force-open", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/breakers")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var statuses []BreakerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].Name != "payments" || statuses[0].State != StateOpened || !statuses[0].Forced {
+		t.Errorf("Unexpected statuses: %+v", statuses)
+	}
+
+	resp, err = http.Get(server.URL + "/breakers/unknown")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestOverrideActor(t *testing.T) {
+	var events []Event
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithName("payments"),
+		WithEventHandler(func(e Event) { events = append(events, e) }),
+	)
+	registry := NewRegistry()
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+	server := httptest.NewServer(NewAdminHandler(registry, BearerIdentity(map[string]string{"secret": "alice"})))
+	defer server.Close()
+
+	// the actor comes from the authenticated identity, not from the client
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/breakers/payments/force-open", nil)
+	req.Header.Set("Authorization", "Bearer forged")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected an unknown token to be rejected, got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/breakers/payments/force-open", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var status BreakerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if tr := status.LastTransition; tr == nil || tr.Actor != "alice" || tr.To != StateOpened {
+		t.Errorf("Expected the forced transition by alice, got %+v", tr)
+	}
+
+	cb.ResetBy("deploy-bot")
+	if len(events) != 2 || events[0].Actor != "alice" || events[1].Type != EventOverride || events[1].Actor != "deploy-bot" {
+		t.Errorf("Expected override events with actors, got %+v", events)
+	}
+	if tr := cb.LastTransition(); tr.Actor != "deploy-bot" || tr.To != StateClosed {
+		t.Errorf("Expected the reset by deploy-bot, got %+v", tr)
+	}
+}
+
+func TestAdminDebugPage(t *testing.T) {
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(2), NewInt64Threshold(1), time.Minute, WithName("payments<1>"))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	cb.RecordFailure()
+
+	server := httptest.NewServer(NewAdminHandler(registry, nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/debug")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	page := string(body)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || strings.Count(page, `class="failure"`) != 2 {
+		t.Errorf("Expected recent samples on the page, got %s", page)
+	}
+	if !strings.Contains(page, "payments&lt;1&gt;") || !strings.Contains(page, "failures 2 &gt;= 2") {
+		t.Errorf("Expected the escaped breaker and its transition, got %s", page)
+	}
+}
+
+func TestAdminRecentErrors(t *testing.T) {
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(3), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+
+	timeout := errors.New("upstream timeout")
+	cb.RecordFailureErr(timeout)
+	cb.RecordFailureErr(fmt.Errorf("dial: %w", ErrNotFound))
+	_ = cb.Execute(func() error { return timeout })
+	if state := cb.State(); state != StateOpened {
+		t.Fatalf("Expected %s, got %s", StateOpened, state)
+	}
+	for i := range errorHistory - 1 {
+		cb.RecordFailureErr(fmt.Errorf("error %d", i))
+	}
+	cb.RecordFailureErr(timeout)
+
+	server := httptest.NewServer(NewAdminHandler(registry, nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/breakers/payments/errors")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var samples []ErrorSample
+	if err := json.NewDecoder(resp.Body).Decode(&samples); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if len(samples) != errorHistory {
+		t.Fatalf("Expected %d distinct errors, got %d", errorHistory, len(samples))
+	}
+	if first := samples[0]; first.Message != "upstream timeout" || first.Count != 3 || first.State != StateOpened {
+		t.Errorf("Expected the deduplicated timeout first, got %+v", first)
+	}
+	for _, sample := range samples {
+		if strings.HasPrefix(sample.Message, "dial") {
+			t.Errorf("Expected the least recently seen error to be dropped, got %+v", sample)
+		}
+	}
+}
+
+func TestRejectionSink(t *testing.T) {
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithName("payments"),
+		WithRejectionSink(2, 1),
+	)
+	cb.RecordFailure()
+
+	for _, method := range []string{"GET", "POST", "PUT"} {
+		ctx := WithTags(context.Background(), Tags{TagMethod: method})
+		_ = cb.ExecuteContext(ctx, func(context.Context) error { return nil })
+	}
+
+	registry := NewRegistry()
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+	server := httptest.NewServer(NewAdminHandler(registry, nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/breakers/payments/rejections")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var rejections []RejectedCall
+	if err := json.NewDecoder(resp.Body).Decode(&rejections); err != nil {
+		t.Fatalf("Unexpected decode error: %v", err)
+	}
+	if len(rejections) != 2 || rejections[0].Tags[TagMethod] != "POST" || rejections[1].Tags[TagMethod] != "PUT" {
+		t.Errorf("Expected the last 2 rejected calls, got %+v", rejections)
+	}
+}
+
+func TestRegistryApplyConfigFile(t *testing.T) {
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(5), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "breakers.json")
+	config := `{
+		"payments": {
+			"failure": {"type": "int64", "params": {"value": 1}},
+			"success": {"type": "int64", "params": {"value": 1}},
+			"open_timeout": "1m"
+		},
+		"unknown": {"failure": {"type": "int64"}}
+	}`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := reload(registry, path); err != nil {
+		t.Fatalf("Unexpected reload error: %v", err)
+	}
+
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected reloaded threshold to open the breaker, got %s", state)
+	}
+}
+
+func TestRegistryReportEvery(t *testing.T) {
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+	cb.RecordFailure()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan map[string]Metrics, 1)
+	go registry.ReportEvery(ctx, 5*time.Millisecond, func(snapshot map[string]Metrics) {
+		select {
+		case reports <- snapshot:
+		default:
+		}
+	})
+	defer cancel()
+
+	select {
+	case snapshot := <-reports:
+		if snapshot["payments"].State != StateOpened {
+			t.Errorf("Expected open payments breaker, got %+v", snapshot)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a report")
+	}
+}
+
+func TestRegistryHealthCheck(t *testing.T) {
+	registry := NewRegistry()
+	critical := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second, WithName("db"))
+	optional := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second, WithName("cache"))
+	_ = registry.Register(critical)
+	_ = registry.Register(optional)
+	registry.SetCritical("db", true)
+
+	handler := NewHealthHandler(registry)
+
+	optional.RecordFailure()
+	if err := registry.Check(context.Background()); err != nil {
+		t.Errorf("Expected healthy registry with open optional breaker, got %v", err)
+	}
+
+	critical.RecordFailure()
+	err := registry.Check(context.Background())
+	if !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected %v, got %v", ErrOpenState, err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+}
+
+func TestGroupHealth(t *testing.T) {
+	newBreaker := func(name string) *CircuitBreaker {
+		return NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute, WithName(name))
+	}
+	db, cache, search := newBreaker("db"), newBreaker("cache"), newBreaker("search")
+
+	group := NewGroup(0.5)
+	group.Add(db, true)
+	group.Add(cache, false)
+	group.Add(search, false)
+
+	if health := group.Health(); health.Status != HealthUp || health.Score != 1 {
+		t.Errorf("Expected up, got %+v", health)
+	}
+
+	db.RecordFailure()
+	if health := group.Health(); health.Status != HealthDegraded {
+		t.Errorf("Expected degraded with critical member open, got %+v", health)
+	}
+	if err := group.Check(context.Background()); err != nil {
+		t.Errorf("Expected degraded group to stay ready, got %v", err)
+	}
+
+	cache.RecordFailure()
+	if health := group.Health(); health.Status != HealthDown || len(health.Open) != 2 {
+		t.Errorf("Expected down with 2 of 3 open, got %+v", health)
+	}
+	if err := group.Check(context.Background()); !errors.Is(err, ErrOpenState) {
+		t.Errorf("Expected %v, got %v", ErrOpenState, err)
+	}
+}
+
+func TestKeyedBreakerZones(t *testing.T) {
+	breakers := NewKeyedBreaker(func(string) *CircuitBreaker {
+		return NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute)
+	})
+
+	breakers.GetZone("payments", "eu-west-1").RecordFailure()
+	breakers.GetZone("payments", "eu-central-1")
+	breakers.GetZone("search", "eu-west-1")
+
+	zones := breakers.Zones("payments")
+	if len(zones) != 2 || zones[1] != (ZoneState{Zone: "eu-west-1", State: StateOpened}) {
+		t.Errorf("Unexpected zones: %+v", zones)
+	}
+
+	preferred := breakers.PreferredZones("payments", []string{"eu-west-1", "us-east-1", "eu-central-1"})
+	if strings.Join(preferred, ",") != "us-east-1,eu-central-1,eu-west-1" {
+		t.Errorf("Expected open zone last, got %v", preferred)
+	}
+}
+
+func TestReadOnlyView(t *testing.T) {
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(2), NewInt64Threshold(1), time.Minute, WithName("payments"))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cb.RecordFailure()
+
+	views := registry.Views()
+	if len(views) != 1 {
+		t.Fatalf("Expected 1 view, got %d", len(views))
+	}
+	view := views[0]
+	if view.Name() != "payments" || view.State() != StateClosed || view.Counts().Failures != 1 {
+		t.Errorf("Unexpected view: %s %s %+v", view.Name(), view.State(), view.Counts())
+	}
+	if _, ok := view.(Breaker); ok {
+		t.Error("Expected view without write methods")
+	}
+}
+
+func TestRejectionAlerter(t *testing.T) {
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Hour, WithName("payments"))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+
+	var alerts []RejectionAlert
+	alerter := NewRejectionAlerter(registry, 0.5, 5*time.Minute, func(a RejectionAlert) {
+		alerts = append(alerts, a)
+	})
+	start := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	alerter.Evaluate(start)
+
+	cb.RecordFailure()
+	for minute := 1; minute <= 6; minute++ {
+		cb.Allow()
+		cb.Allow()
+		alerter.Evaluate(start.Add(time.Duration(minute) * time.Minute))
+	}
+	if len(alerts) != 1 || !alerts[0].Firing || alerts[0].Ratio != 1 || !alerts[0].Since.Equal(start.Add(time.Minute)) {
+		t.Fatalf("Expected one firing alert after 5 minutes, got %+v", alerts)
+	}
+	if firing := alerter.Firing(); len(firing) != 1 || firing[0] != "payments" {
+		t.Errorf("Expected payments firing, got %v", firing)
+	}
+
+	alerter.Evaluate(start.Add(7 * time.Minute))
+	cb.Reset()
+	cb.Allow()
+	alerter.Evaluate(start.Add(8 * time.Minute))
+	if len(alerts) != 2 || alerts[1].Firing || len(alerter.Firing()) != 0 {
+		t.Errorf("Expected the alert to resolve, got %+v", alerts)
+	}
+}
+
+func TestFactory(t *testing.T) {
+	registry := NewRegistry()
+	factory := LocalFactory(registry, PresetHTTPAPI())
+
+	payments, err := factory("payments")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	again, _ := factory("payments")
+	if payments != again {
+		t.Error("Expected the same breaker for the same name")
+	}
+	if cb, ok := registry.Get("payments"); !ok || Breaker(cb) != payments {
+		t.Error("Expected the breaker to be registered")
+	}
+	if b, err := RegistryFactory(registry)("payments"); err != nil || b != payments {
+		t.Errorf("Expected the registered breaker, got %v, %v", b, err)
+	}
+	if _, err := RegistryFactory(registry)("search"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected %v, got %v", ErrNotFound, err)
+	}
+
+	opts := make([]Option, 0, 1)
+	if _, err := LocalFactory(NewRegistry(), PresetHTTPAPI(), opts...)("search"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if opts[:1][0] != nil {
+		t.Error("Expected the options of the caller not to be written")
+	}
+
+	noop, _ := NoopFactory()("payments")
+	for range 100 {
+		if err := Do(noop, func() error { return errors.New("boom") }); errors.Is(err, ErrOpenState) {
+			t.Fatal("Expected the noop breaker to admit every call")
+		}
+	}
+	if state := noop.State(); state != StateClosed {
+		t.Errorf("Expected %s, got %s", StateClosed, state)
+	}
+}
+
+func TestNewRegistryFromConfig(t *testing.T) {
+	spec := func(kind string, value any) ThresholdSpec {
+		return ThresholdSpec{Type: kind, Params: map[string]any{"value": value}}
+	}
+	registry, err := NewRegistryFromConfig(map[string]Config{
+		"payments": {Failure: spec("int64", 3.0), Success: spec("int64", 1.0), OpenTimeout: Duration(time.Minute)},
+		"search":   {Failure: spec("float64", 0.5), Success: spec("int64", 2.0), OpenTimeout: Duration(time.Second)},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n := len(registry.All()); n != 2 {
+		t.Fatalf("Expected 2 breakers, got %d", n)
+	}
+
+	cb, _ := registry.Get("payments")
+	permit, err := cb.Acquire()
+	if err != nil {
+		t.Fatalf("Expected a permit, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := registry.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "payments") {
+		t.Errorf("Expected payments to be still busy, got %v", err)
+	}
+	permit.Success()
+	if err := registry.Drain(context.Background()); err != nil {
+		t.Errorf("Expected the registry to be drained, got %v", err)
+	}
+
+	_, err = NewRegistryFromConfig(map[string]Config{"broken": {Failure: ThresholdSpec{Type: "unknown"}}})
+	if !errors.Is(err, ErrUnknownThreshold) {
+		t.Errorf("Expected %v, got %v", ErrUnknownThreshold, err)
+	}
+}
+
+// clock handling
+
+// wallClock is a Clock without monotonic readings, it can jump in both directions
+type wallClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *wallClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *wallClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (c *wallClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestRealClockIsMonotonic(t *testing.T) {
+	if now := (realClock{}).Now(); !strings.Contains(now.String(), "m=") {
+		t.Errorf("Expected monotonic clock reading, got %s", now)
+	}
+}
+
+func TestOpenTimeoutSurvivesClockJump(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		10*time.Second,
+		WithClock(clock),
+	)
+
+	cb.RecordFailure()
+	clock.Add(-time.Hour)
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected state %s right after clock jump, got %s", StateOpened, state)
+	}
+
+	clock.Add(11 * time.Second)
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected state %s one timeout after clock jump, got %s", StateHalfOpen, state)
+	}
+}
+
+func TestSlidingWindowSurvivesClockJump(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	threshold := NewSlidingWindowThreshold(time.Minute, 2, "skew-test")
+	threshold.SetClock(clock)
+
+	threshold.RecordFailure()
+	threshold.RecordFailure()
+	clock.Add(-time.Hour)
+	if !threshold.Check(nil) {
+		t.Error("Expected failures to stay in window right after clock jump")
+	}
+
+	clock.Add(2 * time.Minute)
+	if threshold.Check(nil) {
+		t.Error("Expected failures to expire one window after clock jump")
+	}
+}
+
+func TestCounterRotation(t *testing.T) {
+	clock := &wallClock{now: time.Now()}
+	cb := NewCircuitBreaker(
+		NewFloat64Threshold(0.5),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithClock(clock),
+		WithMinimumCalls(4),
+		WithCounterRotation(time.Hour),
+	)
+
+	for range 3 {
+		cb.RecordFailure()
+	}
+	clock.Add(2 * time.Hour)
+
+	cb.RecordSuccess()
+	if counts := cb.Metrics().Counts; counts.Total != 1 || counts.Failures != 0 {
+		t.Errorf("Expected counters of the new interval only, got %+v", counts)
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected closed state, got %s", state)
+	}
+}
+
+func TestMultiWindowTrip(t *testing.T) {
+	newBreaker := func(mode WindowMode) (*CircuitBreaker, *wallClock) {
+		clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+		return NewCircuitBreaker(
+			NewInt64Threshold(1),
+			NewInt64Threshold(1),
+			time.Minute,
+			WithClock(clock),
+			WithTripPolicy(NewMultiWindowTrip(mode,
+				BurnWindow{Window: time.Hour, Rate: 0.05, MinCalls: 10},
+				BurnWindow{Window: 5 * time.Minute, Rate: 0.2, MinCalls: 5},
+			)),
+		), clock
+	}
+	// 100 successes over 50 minutes and a spike of 3 failures
+	traffic := func(cb *CircuitBreaker, clock *wallClock) {
+		for range 100 {
+			cb.RecordSuccess()
+			clock.Add(30 * time.Second)
+		}
+		for range 3 {
+			cb.RecordFailure()
+		}
+	}
+
+	cb, clock := newBreaker(AllWindows)
+	traffic(cb, clock)
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("Expected spike below the long window rate not to trip, got %s", state)
+	}
+	for range 3 {
+		cb.RecordFailure()
+	}
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected sustained failures to trip both windows, got %s", state)
+	}
+
+	cb, clock = newBreaker(AnyWindow)
+	traffic(cb, clock)
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected spike to trip the short window, got %s", state)
+	}
+}
+
+func TestAdaptiveOpenTimeout(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		10*time.Second,
+		WithClock(clock),
+		WithAdaptiveOpenTimeout(0.9, 10, time.Second, time.Minute),
+	)
+
+	// the first recovery takes two open periods of the initial timeout
+	cb.RecordFailure()
+	clock.Add(11 * time.Second)
+	cb.State()
+	cb.RecordFailure()
+	clock.Add(11 * time.Second)
+	if state := cb.State(); state != StateHalfOpen {
+		t.Fatalf("Expected initial timeout before the first recovery, got %s", state)
+	}
+	cb.RecordSuccess()
+
+	cb.RecordFailure()
+	clock.Add(15 * time.Second)
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected open timeout adapted to the 22s recovery, got %s", state)
+	}
+	clock.Add(8 * time.Second)
+	if state := cb.State(); state != StateHalfOpen {
+		t.Errorf("Expected %s after the adapted timeout, got %s", StateHalfOpen, state)
+	}
+}
+
+func TestDwellTime(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+		WithClock(clock),
+		WithDwellTime(StateClosed, 10*time.Second),
+		WithDwellTime(StateHalfOpen, 5*time.Second),
+		WithDwellTime(StateOpened, 3*time.Second),
+	)
+
+	clock.Add(10 * time.Second)
+	cb.RecordFailure()
+	clock.Add(2 * time.Second)
+	if state := cb.State(); state != StateOpened {
+		t.Fatalf("Expected open dwell time to extend the open timeout, got %s", state)
+	}
+	clock.Add(2 * time.Second)
+	if !cb.Allow() {
+		t.Fatal("Expected probe after the open dwell time")
+	}
+	cb.RecordSuccess()
+	if state := cb.State(); state != StateHalfOpen {
+		t.Fatalf("Expected close to wait for the half-open dwell time, got %s", state)
+	}
+
+	clock.Add(5 * time.Second)
+	if !cb.Allow() || cb.State() != StateClosed {
+		t.Fatalf("Expected deferred close after the dwell time, got %s", cb.State())
+	}
+	cb.RecordFailure()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected no trip within the closed dwell time, got %s", state)
+	}
+	clock.Add(10 * time.Second)
+	cb.RecordFailure()
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected trip after the closed dwell time, got %s", state)
+	}
+}
+
+func TestFlapDetection(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	var events []Event
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+		WithClock(clock),
+		WithFlapDetection(4, time.Minute, 3),
+		WithEventHandler(func(e Event) {
+			if e.Type == EventFlapping || e.Type == EventFlappingEnd {
+				events = append(events, e)
+			}
+		}),
+	)
+
+	cb.RecordFailure()
+	clock.Add(2 * time.Second)
+	cb.State()
+	cb.RecordSuccess()
+	cb.RecordFailure()
+	if !cb.Flapping() || len(events) != 1 || events[0].Type != EventFlapping {
+		t.Fatalf("Expected flapping after 4 transitions, got %+v", events)
+	}
+
+	clock.Add(2 * time.Second)
+	if state := cb.State(); state != StateOpened {
+		t.Errorf("Expected damped open timeout, got %s", state)
+	}
+	clock.Add(2 * time.Minute)
+	cb.State()
+	cb.RecordSuccess()
+	if cb.Flapping() || len(events) != 2 || events[1].Type != EventFlappingEnd {
+		t.Errorf("Expected flapping to end, got %+v", events)
+	}
+}
+
+func TestFlappingEndsAfterSettling(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	var ended []Event
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(1),
+		NewInt64Threshold(1),
+		time.Second,
+		WithClock(clock),
+		WithFlapDetection(4, time.Minute, 3),
+		WithEventHandler(func(e Event) {
+			if e.Type == EventFlappingEnd {
+				ended = append(ended, e)
+			}
+		}),
+	)
+
+	for range 2 {
+		cb.RecordFailure()
+		clock.Add(4 * time.Second)
+		cb.State()
+		cb.RecordSuccess()
+	}
+	if !cb.Flapping() {
+		t.Fatal("Expected flapping after 4 transitions")
+	}
+
+	clock.Add(2 * time.Minute)
+	if cb.Flapping() || len(ended) != 1 {
+		t.Errorf("Expected flapping to end once the closed breaker settled, got %+v", ended)
+	}
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected the breaker to stay closed, got %s", state)
+	}
+}
+
+func TestOutlierEjection(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	breakers := NewKeyedBreaker(func(string) *CircuitBreaker {
+		return NewCircuitBreaker(NewInt64Threshold(100), NewInt64Threshold(1), time.Second, WithClock(clock))
+	})
+	detector := NewOutlierDetector(breakers, OutlierConfig{
+		ConsecutiveFailures:      3,
+		SuccessRateStdevFactor:   1,
+		SuccessRateMinHosts:      3,
+		SuccessRateRequestVolume: 10,
+		BaseEjectionTime:         10 * time.Second,
+	})
+	failing := errors.New("503")
+
+	for range 3 {
+		_ = detector.Execute(context.Background(), "a", func(context.Context) error { return failing })
+	}
+	if ejected := detector.Ejected(); len(ejected) != 1 || ejected[0] != "a" {
+		t.Fatalf("Expected host a ejected after consecutive failures, got %v", ejected)
+	}
+
+	clock.Add(11 * time.Second)
+	_ = detector.Execute(context.Background(), "a", func(context.Context) error { return failing })
+	clock.Add(5 * time.Second)
+	if state := breakers.Get("a").State(); state != StateOpened {
+		t.Errorf("Expected a failed probe to re-eject for the ejection time, got %s", state)
+	}
+	clock.Add(6 * time.Second)
+	_ = detector.Execute(context.Background(), "a", func(context.Context) error { return nil })
+	if ejected := detector.Ejected(); len(ejected) != 0 {
+		t.Errorf("Expected host a reinserted after a successful probe, got %v", ejected)
+	}
+
+	for i := range 10 {
+		for _, host := range []string{"b", "c", "d"} {
+			detector.Record(host, host != "d" || i%2 == 0)
+		}
+	}
+	detector.Sweep()
+	if ejected := detector.Ejected(); len(ejected) != 1 || ejected[0] != "d" {
+		t.Errorf("Expected host d ejected for its success rate, got %v", ejected)
+	}
+}
+
+func TestTripGrace(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	cb := NewCircuitBreaker(
+		NewInt64Threshold(2),
+		NewInt64Threshold(1),
+		time.Minute,
+		WithClock(clock),
+		WithTripGrace(5*time.Second, 0),
+	)
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+	cb.RecordSuccess()
+	clock.Add(10 * time.Second)
+	cb.RecordFailure()
+	if state := cb.State(); state != StateClosed {
+		t.Fatalf("Expected a blip cleared within the grace period to keep the circuit closed, got %s", state)
+	}
+
+	cb.RecordFailure()
+	clock.Add(3 * time.Second)
+	cb.RecordFailure()
+	if state := cb.State(); state != StateClosed {
+		t.Errorf("Expected calls admitted during the grace period, got %s", state)
+	}
+	clock.Add(3 * time.Second)
+	cb.RecordFailure()
+	if tr := cb.LastTransition(); tr.To != StateOpened || tr.Reason != "failures 4 >= 2" {
+		t.Errorf("Expected the circuit to open after the grace period, got %+v", tr)
+	}
+
+	counted := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Minute, WithTripGrace(0, 2))
+	counted.RecordFailure()
+	counted.RecordFailure()
+	if state := counted.State(); state != StateClosed {
+		t.Errorf("Expected the grace calls to be admitted, got %s", state)
+	}
+	counted.RecordFailure()
+	if state := counted.State(); state != StateOpened {
+		t.Errorf("Expected the circuit to open after the grace calls, got %s", state)
+	}
+
+	// the load is recorded during warmup, so only the results trip on it
+	loaded := NewCircuitBreaker(NewInt64Threshold(10), NewInt64Threshold(1), time.Minute,
+		WithLoadLimit("cpu", 0.9), WithWarmup(0, 1), WithTripGrace(0, 2))
+	loaded.RecordLoad("cpu", 0.95)
+	for range 3 {
+		loaded.RecordSuccess()
+	}
+	if state := loaded.State(); state != StateClosed {
+		t.Errorf("Expected the load trip to wait for the grace calls, got %s", state)
+	}
+	loaded.RecordSuccess()
+	if tr := loaded.LastTransition(); tr.To != StateOpened || tr.Reason != "load cpu 0.95 >= 0.9" {
+		t.Errorf("Expected the circuit to open on load after the grace calls, got %+v", tr)
+	}
+}
+
+func TestThresholdMigration(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	window := func(d time.Duration, maxFailures int) *SlidingWindowThreshold {
+		sw := NewSlidingWindowThreshold(d, maxFailures, "errors")
+		sw.SetClock(clock)
+		return sw
+	}
+
+	old := window(time.Minute, 10)
+	cb := NewCircuitBreaker(old, NewInt64Threshold(1), time.Minute, WithClock(clock), WithThresholdMigration())
+	for range 3 {
+		old.RecordFailure()
+		clock.Add(10 * time.Second)
+	}
+
+	replacement := window(25*time.Second, 5)
+	cb.UpdateValues(replacement, NewInt64Threshold(1), time.Minute)
+	if n := replacement.GetCurrentFailures(); n != 2 {
+		t.Errorf("Expected the failures within the new window to be migrated, got %d", n)
+	}
+
+	unmigrated := window(time.Minute, 5)
+	plain := NewCircuitBreaker(old, NewInt64Threshold(1), time.Minute, WithClock(clock))
+	plain.UpdateValues(unmigrated, NewInt64Threshold(1), time.Minute)
+	if n := unmigrated.GetCurrentFailures(); n != 0 {
+		t.Errorf("Expected no migration without the option, got %d", n)
+	}
+}
+
+func TestExponentialWindow(t *testing.T) {
+	start := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	window := NewExponentialWindow(time.Hour, time.Second, 4)
+	for i := range 2 * 3600 {
+		window.RecordAt(start.Add(time.Duration(i)*time.Second), i%10 != 0)
+	}
+
+	now := start.Add(2 * time.Hour)
+	counts := window.CountsAt(now)
+	if counts.Total < 3400 || counts.Total > 3800 || counts.Failures < 340 || counts.Failures > 380 {
+		t.Errorf("Expected about 3600 calls and 360 failures within the hour, got %+v", counts)
+	}
+	if n := window.Buckets(); n > 4*14 {
+		t.Errorf("Expected bounded buckets, got %d", n)
+	}
+
+	clock := &wallClock{now: now}
+	trip := NewWindowRateTrip(window, 0.12, 100)
+	cb := NewCircuitBreaker(nil, NewInt64Threshold(1), time.Minute, WithClock(clock), WithTripPolicy(trip))
+	for range 100 {
+		cb.RecordFailure()
+	}
+	if tr := cb.LastTransition(); tr.To != StateOpened || !strings.HasPrefix(tr.Reason, "failure rate") {
+		t.Errorf("Expected the hourly failure rate to trip, got %+v", tr)
+	}
+}
+
+func TestWindowReset(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	open := func(opts ...Option) *SlidingWindowThreshold {
+		sw := NewSlidingWindowThreshold(time.Minute, 3, "errors")
+		sw.SetClock(clock)
+		cb := NewCircuitBreaker(sw, NewInt64Threshold(1), time.Minute, append(opts, WithClock(clock))...)
+		for range 3 {
+			sw.RecordFailure()
+			cb.RecordFailure()
+		}
+		if state := cb.State(); state != StateOpened {
+			t.Fatalf("Expected the window to open the circuit, got %s", state)
+		}
+		return sw
+	}
+
+	if n := open().GetCurrentFailures(); n == 0 {
+		t.Errorf("Expected the window to be kept by default")
+	}
+	if n := open(WithWindowReset(ResetOnOpen)).GetCurrentFailures(); n != 0 {
+		t.Errorf("Expected the window to be cleared on open, got %d failures", n)
+	}
+	if n := open(WithWindowReset(ResetOnHalfOpen | ResetOnClose)).GetCurrentFailures(); n == 0 {
+		t.Errorf("Expected the window to be kept on open")
+	}
+}
+
+// windowProbe is a WindowThreshold keeping the last value it checked
+type windowProbe struct {
+	last any
+}
+
+func (p *windowProbe) Check(value any) bool      { p.last = value; return false }
+func (p *windowProbe) GetThreshold() any         { return nil }
+func (p *windowProbe) WindowSize() time.Duration { return time.Minute }
+
+func TestWindowStats(t *testing.T) {
+	start := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := &wallClock{now: start}
+	probe := &windowProbe{}
+	cb := NewCircuitBreaker(probe, NewInt64Threshold(1), time.Minute, WithClock(clock))
+
+	clock.Add(20 * time.Second)
+	cb.RecordSuccess()
+	cb.RecordFailure()
+
+	stats, ok := probe.last.(WindowStats)
+	if !ok {
+		t.Fatalf("Expected WindowStats, got %T", probe.last)
+	}
+	if stats.Total != 2 || stats.Failures != 1 || stats.ConsecutiveFailures != 1 {
+		t.Errorf("Expected the breaker counters, got %+v", stats)
+	}
+	if !stats.Since.Equal(start) || stats.Elapsed != 20*time.Second || stats.Window != time.Minute {
+		t.Errorf("Expected 20s elapsed of the minute window since start, got %+v", stats)
+	}
+
+	clock.Add(2 * time.Minute)
+	cb.RecordFailure()
+	if stats := probe.last.(WindowStats); stats.Elapsed != time.Minute {
+		t.Errorf("Expected the elapsed time capped at the window, got %s", stats.Elapsed)
+	}
+}
+
+func TestClockOfRuntimeThresholdAndDebugPage(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+	threshold := NewRuntimeThreshold(1_000_000, 0)
+	threshold.SetClock(clock)
+	threshold.Check(nil)
+	sampled := clock.Now()
+	clock.Add(50 * time.Millisecond)
+	threshold.Check(nil)
+	if !threshold.sampled.Equal(sampled) {
+		t.Errorf("Expected the runtime sample to be kept within the sample interval, sampled at %s", threshold.sampled)
+	}
+	clock.Add(time.Second)
+	threshold.Check(nil)
+	if !threshold.sampled.Equal(clock.Now()) {
+		t.Errorf("Expected the runtime to be sampled again by the clock, sampled at %s", threshold.sampled)
+	}
+
+	registry := NewRegistry()
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Hour, WithName("payments"), WithClock(clock))
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+	cb.RecordFailure()
+	clock.Add(90 * time.Second)
+
+	rec := httptest.NewRecorder()
+	NewAdminHandler(registry, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug", nil))
+	if page := rec.Body.String(); !strings.Contains(page, "<td>1m30s</td>") {
+		t.Errorf("Expected the age of the transition by the breaker clock, got %s", page)
+	}
+}
+
+func TestSuccessAfterRecoveryKeepsBreakerClosed(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+	window := NewSlidingWindowThreshold(time.Minute, 2, "recovery")
+	window.SetClock(clock)
+	cb := NewCircuitBreaker(window, NewInt64Threshold(1), time.Second, WithClock(clock), WithOutcomeFeed())
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if cb.State() != StateOpened {
+		t.Fatalf("Expected the window to open the breaker, got %s", cb.State())
+	}
+
+	clock.Add(2 * time.Second)
+	if !cb.Allow() {
+		t.Fatal("Expected a probe after the timeout")
+	}
+	cb.RecordSuccess()
+	if cb.State() != StateClosed {
+		t.Fatalf("Expected the probe to close the breaker, got %s", cb.State())
+	}
+
+	cb.RecordSuccess()
+	if cb.State() != StateClosed {
+		t.Errorf("Expected a success after recovery to keep the breaker closed, got %s", cb.State())
+	}
+}
+
+func TestRateLimitedProbesAreRefunded(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+	limiter := NewRateLimiter(0.1, 2)
+	limiter.SetClock(clock)
+	limiter.Allow()
+	limiter.Allow()
+	cb := NewCircuitBreaker(NewInt64Threshold(1), NewInt64Threshold(1), time.Second,
+		WithClock(clock), WithHalfOpenSuccessRate(0.5, 2), WithRateLimiter(limiter, LimitAfterBreaker))
+
+	cb.RecordFailure()
+	clock.Add(2 * time.Second)
+	for range 2 {
+		if err := cb.Execute(func() error { return nil }); !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Expected %v, got %v", ErrRateLimited, err)
+		}
+	}
+	if cb.State() != StateHalfOpen {
+		t.Fatalf("Expected half-open state, got %s", cb.State())
+	}
+
+	clock.Add(20 * time.Second)
+	for range 2 {
+		if err := cb.Execute(func() error { return nil }); err != nil {
+			t.Fatalf("Expected the rate limited probes to be given back, got %v", err)
+		}
+	}
+	if cb.State() != StateClosed {
+		t.Errorf("Expected the probes to close the breaker, got %s", cb.State())
+	}
+}
+
+func TestPresetRatesFollowRecentTraffic(t *testing.T) {
+	clock := &wallClock{now: time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)}
+
+	cb := PresetDatabase().New(WithClock(clock))
+	for range 10000 {
+		cb.RecordSuccess()
+		clock.Add(60 * time.Millisecond)
+	}
+	for range 1000 {
+		cb.RecordFailure()
+		if cb.State() == StateOpened {
+			return
+		}
+		clock.Add(60 * time.Millisecond)
+	}
+	t.Errorf("Expected 1000 consecutive failures to open the database preset, got %s", cb.State())
+}
@@ -0,0 +1,43 @@
+package circuitbreaker
+
+import "time"
+
+// - is a source of time, allows virtual time in simulations and tests
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// - is a timer created by Clock
+type Timer interface {
+	Stop() bool
+}
+
+// realClock uses the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// - replaces the wall clock used by the breaker
+func WithClock(clock Clock) Option {
+	return func(cb *CircuitBreaker) {
+		cb.clock = clock
+	}
+}
+
+// elapsed returns time passed from t to now, readings of the real clock carry a
+// monotonic component so wall-clock jumps do not affect them, a Clock without
+// monotonic readings that went backwards is reported as skewed with zero duration
+func elapsed(now, t time.Time) (time.Duration, bool) {
+	d := now.Sub(t)
+	if d < 0 {
+		return 0, true
+	}
+	return d, false
+}
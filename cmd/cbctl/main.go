@@ -0,0 +1,154 @@
+// Command cbctl inspects and controls circuit breakers through the admin HTTP handler.
+//
+// Usage:
+//
+//	cbctl [-addr URL] list
+//	cbctl [-addr URL] show NAME
+//	cbctl [-addr URL] [-token TOKEN] force-open NAME
+//	cbctl [-addr URL] [-token TOKEN] force-close NAME
+//	cbctl [-addr URL] [-token TOKEN] reset NAME
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+func main() {
+	addr := flag.String("addr", envOr("CBCTL_ADDR", "http://localhost:8080"), "base URL the admin handler is mounted at")
+	timeout := flag.Duration("timeout", 5*time.Second, "request timeout")
+	token := flag.String("token", os.Getenv("CBCTL_TOKEN"), "bearer token authenticating force-open, force-close and reset, the server records who it belongs to")
+	flag.Usage = usage
+	flag.Parse()
+
+	client := &client{base: strings.TrimRight(*addr, "/"), http: &http.Client{Timeout: *timeout}, token: *token}
+	if err := run(client, flag.Args(), os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "cbctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cbctl [flags] list | show NAME | force-open NAME | force-close NAME | reset NAME")
+	flag.PrintDefaults()
+}
+
+func run(c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		usage()
+		return errors.New("command is required")
+	}
+
+	command, args := args[0], args[1:]
+	if command == "list" {
+		var statuses []circuitbreaker.BreakerStatus
+		if err := c.do(http.MethodGet, "/breakers", &statuses); err != nil {
+			return err
+		}
+		printTable(out, statuses)
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("%s: breaker name is required", command)
+	}
+	path := "/breakers/" + url.PathEscape(args[0])
+
+	var status circuitbreaker.BreakerStatus
+	switch command {
+	case "show":
+		if err := c.do(http.MethodGet, path, &status); err != nil {
+			return err
+		}
+		printDetails(out, status)
+	case "force-open", "force-close", "reset":
+		if err := c.do(http.MethodPost, path+"/"+command, &status); err != nil {
+			return err
+		}
+		printTable(out, []circuitbreaker.BreakerStatus{status})
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return nil
+}
+
+type client struct {
+	base  string
+	http  *http.Client
+	token string
+}
+
+// do sends the request and decodes the JSON response into v
+func (c *client) do(method, path string, v any) error {
+	req, err := http.NewRequest(method, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s", method, path, apiErr.Error)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func printTable(out io.Writer, statuses []circuitbreaker.BreakerStatus) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATE\tFORCED\tSUCCESSES\tFAILURES\tREJECTED")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%d\t%d\t%d\n", s.Name, s.State, s.Forced, s.Successes, s.Failures, s.Rejected)
+	}
+	tw.Flush()
+}
+
+func printDetails(out io.Writer, s circuitbreaker.BreakerStatus) {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", s.Name)
+	fmt.Fprintf(tw, "State:\t%s\n", s.State)
+	fmt.Fprintf(tw, "Forced:\t%v\n", s.Forced)
+	fmt.Fprintf(tw, "Successes:\t%d\n", s.Successes)
+	fmt.Fprintf(tw, "Failures:\t%d\n", s.Failures)
+	fmt.Fprintf(tw, "Rejected:\t%d (%d in current state)\n", s.Rejected, s.RejectedInState)
+	if tr := s.LastTransition; tr != nil {
+		fmt.Fprintf(tw, "Last transition:\t%s -> %s at %s\n", tr.From, tr.To, tr.At.Format(time.RFC3339))
+		fmt.Fprintf(tw, "Reason:\t%s\n", tr.Reason)
+		if tr.Actor != "" {
+			fmt.Fprintf(tw, "Actor:\t%s\n", tr.Actor)
+		}
+	}
+	tw.Flush()
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,84 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// - passes when any of its thresholds passes, used by a breaker every threshold gets
+// the value it would get on its own (e.g. the consecutive count for Int64Threshold and
+// the rate for Float64Threshold), checked directly every threshold gets the same value
+type AnyThreshold struct {
+	thresholds []CustomThreshold
+}
+
+func NewAnyThreshold(thresholds ...CustomThreshold) *AnyThreshold {
+	return &AnyThreshold{thresholds: thresholds}
+}
+
+func (t *AnyThreshold) Check(value any) bool {
+	ok, _ := t.CheckErr(value)
+	return ok
+}
+
+func (t *AnyThreshold) CheckErr(value any) (bool, error) {
+	values, perThreshold := value.(childValues)
+
+	var errs []error
+	for i, threshold := range t.thresholds {
+		if perThreshold {
+			if !values[i].ok {
+				continue
+			}
+			value = values[i].value
+		}
+		ok, err := checkThreshold(threshold, value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, errors.Join(errs...)
+}
+
+// - feeds the result to the thresholds keeping their own history, see OutcomeRecorder
+func (t *AnyThreshold) RecordOutcome(outcome Outcome) {
+	for _, threshold := range t.thresholds {
+		if recorder, ok := threshold.(OutcomeRecorder); ok {
+			recorder.RecordOutcome(outcome)
+		}
+	}
+}
+
+func (t *AnyThreshold) GetThreshold() any {
+	return t.thresholds
+}
+
+func (t *AnyThreshold) String() string {
+	names := make([]string, 0, len(t.thresholds))
+	for _, threshold := range t.thresholds {
+		names = append(names, fmt.Sprint(threshold))
+	}
+	return "AnyThreshold: " + strings.Join(names, ", ")
+}
+
+// childValues are the values of the thresholds of an AnyThreshold computed by the breaker
+type childValues []childValue
+
+type childValue struct {
+	value any
+	// false when the threshold must not be checked yet, see thresholdValue
+	ok bool
+}
+
+// checkThreshold checks value, reporting the error when threshold implements ErrorThreshold
+func checkThreshold(threshold CustomThreshold, value any) (bool, error) {
+	if et, ok := threshold.(ErrorThreshold); ok {
+		return et.CheckErr(value)
+	}
+	return threshold.Check(value), nil
+}
@@ -0,0 +1,159 @@
+package circuitbreaker
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// - computes the next concurrency limit from an observed call
+type LimitAlgorithm interface {
+	Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64
+}
+
+// - is additive increase / multiplicative decrease of the limit
+type AIMD struct {
+	increase float64
+	backoff  float64
+	timeout  time.Duration
+}
+
+// - is a constructor, the limit grows by increase after a successful call made while
+// the limiter was well utilized and is multiplied by backoff (0..1) after a dropped call
+// or a call slower than timeout (zero disables the latency check)
+func NewAIMD(increase, backoff float64, timeout time.Duration) *AIMD {
+	return &AIMD{increase: increase, backoff: backoff, timeout: timeout}
+}
+
+func (a *AIMD) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+	if dropped || (a.timeout > 0 && rtt > a.timeout) {
+		return limit * a.backoff
+	}
+	if float64(inflight)*2 >= limit {
+		return limit + a.increase
+	}
+	return limit
+}
+
+// - is a Gradient2-style algorithm, the limit follows the ratio of the long-term
+// average latency to the current latency
+type Gradient struct {
+	tolerance float64
+	smoothing float64
+	window    float64
+	longRTT   float64
+}
+
+// - is a constructor, tolerance (e.g. 1.5) is how much latency may grow over the
+// long-term average before the limit shrinks, smoothing (0..1) dampens changes,
+// window is the number of samples in the long-term average
+func NewGradient(tolerance, smoothing float64, window int) *Gradient {
+	if window < 1 {
+		window = 1
+	}
+	return &Gradient{tolerance: tolerance, smoothing: smoothing, window: float64(window)}
+}
+
+func (g *Gradient) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+	sample := float64(rtt)
+	if sample <= 0 {
+		return limit
+	}
+
+	if g.longRTT == 0 {
+		g.longRTT = sample
+	} else {
+		g.longRTT += (sample - g.longRTT) / g.window
+	}
+
+	// the limiter is application limited, latency says nothing about capacity
+	if !dropped && float64(inflight) < limit/2 {
+		return limit
+	}
+
+	gradient := math.Max(0.5, math.Min(1, g.tolerance*g.longRTT/sample))
+	if dropped {
+		gradient = 0.5
+	}
+	next := limit*gradient + math.Sqrt(limit)
+
+	return limit*(1-g.smoothing) + next*g.smoothing
+}
+
+// - limits in-flight calls with a limit adapted from observed latencies,
+// for backpressure alongside the open/closed breaker
+type ConcurrencyLimiter struct {
+	mu sync.Mutex
+
+	limit     float64
+	minLimit  float64
+	maxLimit  float64
+	inflight  int
+	algorithm LimitAlgorithm
+}
+
+// - is a constructor, minLimit is raised to 1 and initial is kept within the limits,
+// so the limiter always has a slot to admit calls and learn from
+func NewConcurrencyLimiter(initial, minLimit, maxLimit int, algorithm LimitAlgorithm) *ConcurrencyLimiter {
+	minLimit = max(minLimit, 1)
+	maxLimit = max(maxLimit, minLimit)
+	return &ConcurrencyLimiter{
+		limit:     float64(min(max(initial, minLimit), maxLimit)),
+		minLimit:  float64(minLimit),
+		maxLimit:  float64(maxLimit),
+		algorithm: algorithm,
+	}
+}
+
+// - takes a slot, returns false when the limit is reached
+func (l *ConcurrencyLimiter) TryAcquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inflight >= int(l.limit) {
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+// - frees the slot and adapts the limit, dropped marks a failed or timed out call
+func (l *ConcurrencyLimiter) Release(rtt time.Duration, dropped bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inflight := l.inflight
+	l.inflight--
+
+	limit := l.algorithm.Update(l.limit, rtt, inflight, dropped)
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, limit))
+}
+
+// cancel frees the slot of a call that did not run, the limit is kept
+func (l *ConcurrencyLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+}
+
+// - returns the current limit
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// - returns the number of calls holding a slot
+func (l *ConcurrencyLimiter) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// - makes Execute hold a limiter slot for the call, returns ErrConcurrencyLimited
+// when no slot is free, failed calls are reported to the limiter as dropped
+func WithConcurrencyLimiter(limiter *ConcurrencyLimiter) Option {
+	return func(cb *CircuitBreaker) {
+		cb.concurrency = limiter
+	}
+}
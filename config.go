@@ -1,9 +1,72 @@
 package circuitbreaker
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type CircuitBreakerCfg struct {
 	FailureThreshold int
 	SuccessThreshold int
 	OpenTimeout      time.Duration
 }
+
+// - is a duration written as "30s" in config files
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// - is the declarative configuration of a breaker, read from a config file
+// or from the environment with ConfigFromEnv
+type Config struct {
+	Failure      ThresholdSpec `json:"failure" yaml:"failure"`
+	Success      ThresholdSpec `json:"success" yaml:"success"`
+	OpenTimeout  Duration      `json:"open_timeout" yaml:"open_timeout"`
+	MinimumCalls int64         `json:"minimum_calls,omitempty" yaml:"minimum_calls,omitempty"`
+	Warmup       Duration      `json:"warmup,omitempty" yaml:"warmup,omitempty"`
+	WarmupCalls  int64         `json:"warmup_calls,omitempty" yaml:"warmup_calls,omitempty"`
+}
+
+// - instantiates the failure and success thresholds
+func (c Config) Thresholds() (failure, success CustomThreshold, err error) {
+	if failure, err = NewThreshold(c.Failure); err != nil {
+		return nil, nil, fmt.Errorf("failure threshold: %w", err)
+	}
+	if success, err = NewThreshold(c.Success); err != nil {
+		return nil, nil, fmt.Errorf("success threshold: %w", err)
+	}
+	return failure, success, nil
+}
+
+// - returns the options configured besides the thresholds and the open timeout,
+// the breaker feeds the configured thresholds itself, see WithOutcomeFeed
+func (c Config) Options() []Option {
+	opts := []Option{WithOutcomeFeed()}
+	if c.MinimumCalls > 0 {
+		opts = append(opts, WithMinimumCalls(c.MinimumCalls))
+	}
+	if c.Warmup > 0 || c.WarmupCalls > 0 {
+		opts = append(opts, WithWarmup(time.Duration(c.Warmup), c.WarmupCalls))
+	}
+	return opts
+}
+
+// - creates the configured breaker, opts are applied after the configured options
+func (c Config) New(opts ...Option) (*CircuitBreaker, error) {
+	failure, success, err := c.Thresholds()
+	if err != nil {
+		return nil, err
+	}
+	return NewCircuitBreaker(failure, success, time.Duration(c.OpenTimeout), append(c.Options(), opts...)...), nil
+}
@@ -1,6 +1,7 @@
 package circuitbreaker
 
 import (
+	"fmt"
 	"sync"
 	"time"
 )
@@ -18,15 +19,138 @@ type CircuitBreaker struct {
 	failures  int64
 	successes int64
 
+	consecutiveFailures  int64
+	consecutiveSuccesses int64
+
+	// start of the counting interval in closed state, see WithCounterRotation
+	rotationInterval time.Duration
+	countersStart    time.Time
+
+	// calls rejected over the lifetime and since the last transition
+	admitted        int64
+	rejected        int64
+	rejectedInState int64
+	// calls admitted with WithBypass
+	bypassed int64
+	// open circuit simulated with SimulateOpen, nil when none
+	drill *drill
+	// distinct errors of the failed calls, least recently seen first, see RecentErrors
+	recentErrors []ErrorSample
+	// calls recorded with RecordIgnore
+	ignored int64
+	// every n-th rejection is emitted as EventRejected, see WithRejectionEventSampling
+	rejectionSampling int64
+	rejections        *rejectionSink
+
+	// fraction of results recorded in closed state, see WithClosedStateSampling
+	sampleFraction float64
+	sampleRand     func() float64
+
 	state           string
 	lastStateChange time.Time
+	lastTransition  TransitionInfo
+	history         []TransitionInfo
+	samples         [sampleHistory]Sample
+	sampled         int
+	generation      uint64
 
 	failureThreshold CustomThreshold
 	successThreshold CustomThreshold
 	failureSwitch    Switch
 	successSwitch    Switch
+	tripPolicy       TripPolicy
+	candidate        *candidateThreshold
+	// transitions clearing the windows, see WithWindowReset
+	windowReset    WindowReset
+	windowResetSet bool
+	// carry the history of replaced thresholds over, see WithThresholdMigration
+	migrateThresholds bool
+	// feed results to the thresholds keeping their own history, see WithOutcomeFeed
+	feedOutcomes bool
 
+	soft          *softOpen
+	load          map[string]float64
+	loadLimits    map[string]float64
 	openedTimeout time.Duration
+	backoff       BackoffPolicy
+	flap          *flapDetector
+	// open timeout set by OutlierDetector until the breaker closes
+	ejectTimeout time.Duration
+
+	// minimum time per state, see WithDwellTime
+	dwell         map[string]time.Duration
+	closeDeferred bool
+
+	// trip delay and when the pending trip was first decided, see WithTripGrace
+	graceTime  time.Duration
+	graceCalls int64
+	graceStart time.Time
+	graceSeen  int64
+
+	// consecutive open periods since the breaker was closed and when the first one started
+	openStreak  int
+	streakStart time.Time
+
+	clock           Clock
+	transitionTimer bool
+	timer           Timer
+
+	override    string
+	maintenance []MaintenanceWindow
+
+	warmupDuration time.Duration
+	warmupCalls    int64
+	warmupStart    time.Time
+	recordedCalls  int64
+
+	chaos *ChaosConfig
+
+	minimumCalls int64
+
+	// calls let through per interval while open, see WithProbeBudget
+	probeBudget      int
+	probeInterval    time.Duration
+	probeWindowStart time.Time
+	probesUsed       int
+
+	counterPolicy HalfOpenCounterPolicy
+
+	// recovery strategy replacing the success threshold, see WithHalfOpenPolicy
+	halfOpenPolicy HalfOpenPolicy
+	probesAdmitted int64
+	carried        Counts
+	// counts carried into the current half-open state, not probe results
+	carriedIn Counts
+
+	// cost admitted per window while degraded, see WithCostBudget
+	costCapacity    int64
+	costWindow      time.Duration
+	costWindowStart time.Time
+	costSpent       int64
+	costGeneration  uint64
+
+	limiter     *RateLimiter
+	limitOrder  LimitOrder
+	concurrency *ConcurrencyLimiter
+
+	inflight             int64
+	inflightByGeneration map[uint64]int64
+	drainBeforeHalfOpen  bool
+	halfOpenDeferred     bool
+	draining             bool
+	drained              chan struct{}
+
+	latency             *LatencyHistogram
+	operations          map[string]*LatencyHistogram
+	ignoreContextErrors bool
+	wrapErrors          bool
+	permitTimeout       time.Duration
+
+	name string
+
+	eventHandlers []EventHandler
+	callObservers []CallObserver
+	pending       []Event
 }
 
 // - is a constructor
@@ -34,136 +158,449 @@ func NewCircuitBreaker(
 	failureThreshold,
 	successThreshold CustomThreshold,
 	openedTimeout time.Duration,
+	opts ...Option,
 ) *CircuitBreaker {
-	return &CircuitBreaker{
+	cb := &CircuitBreaker{
 		state:            StateClosed,
 		failureThreshold: failureThreshold,
 		successThreshold: successThreshold,
 		failureSwitch:    ChooseSwitch(failureThreshold),
 		successSwitch:    ChooseSwitch(successThreshold),
 		openedTimeout:    openedTimeout,
-		lastStateChange:  time.Now(),
+		clock:            realClock{},
+		latency:          NewLatencyHistogram(),
 	}
+
+	for _, opt := range opts {
+		opt(cb)
+	}
+
+	now := cb.clock.Now()
+	cb.lastStateChange = now
+	cb.countersStart = now
+	cb.warmupStart = now
+
+	return cb
 }
 
 // - updates values of thresholds
 func (cb *CircuitBreaker) UpdateValues(newFailure, newSuccess CustomThreshold, newTimeout time.Duration) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
+	cb.migrateHistory(cb.failureThreshold, newFailure)
+	cb.migrateHistory(cb.successThreshold, newSuccess)
 	cb.failureThreshold = newFailure
 	cb.successThreshold = newSuccess
 	cb.failureSwitch = ChooseSwitch(newFailure)
 	cb.successSwitch = ChooseSwitch(newSuccess)
 	cb.openedTimeout = newTimeout
+	cb.queueEvent(Event{
+		Type: EventConfigChange,
+		From: cb.state,
+		To:   cb.state,
+		Time: cb.clock.Now(),
+		Reason: fmt.Sprintf("failure threshold %s, success threshold %s, open timeout %s",
+			describeThreshold(newFailure), describeThreshold(newSuccess), newTimeout),
+	})
+
+	if cb.transitionTimer && cb.state == StateOpened {
+		cb.scheduleHalfOpen()
+	}
 }
 
 // resetCounters reset counters
 func (cb *CircuitBreaker) resetCounters() {
 	cb.failures = 0
 	cb.successes = 0
+	cb.consecutiveFailures = 0
+	cb.consecutiveSuccesses = 0
+}
+
+// unlock releases the write lock and delivers the events collected under it
+func (cb *CircuitBreaker) unlock() {
+	events := cb.pending
+	cb.pending = nil
+	cb.mu.Unlock()
+
+	cb.emit(events)
+}
+
+// setState switches the state and queues a state change event, must be called under write lock
+func (cb *CircuitBreaker) setState(state, reason string) {
+	cb.setStateBy(state, reason, "")
+}
+
+// setStateBy is setState for a transition forced by actor, must be called under write lock
+func (cb *CircuitBreaker) setStateBy(state, reason, actor string) {
+	from := cb.state
+	now := cb.clock.Now()
+	counts := cb.counts()
+
+	cb.lastTransition = TransitionInfo{
+		From:   from,
+		To:     state,
+		At:     now,
+		Reason: reason,
+		Counts: counts,
+		Actor:  actor,
+	}
+	cb.recordHistory(cb.lastTransition)
+
+	switch state {
+	case StateOpened:
+		if cb.openStreak == 0 {
+			cb.streakStart = now
+		}
+		cb.openStreak++
+	case StateClosed:
+		cb.observeRecovery(now)
+		cb.openStreak = 0
+		cb.ejectTimeout = 0
+	}
+
+	cb.state = state
+	cb.lastStateChange = now
+	cb.countersStart = now
+	cb.generation++
+	cb.rejectedInState = 0
+	cb.probesAdmitted = 0
+	cb.halfOpenDeferred = false
+	cb.closeDeferred = false
+	cb.cancelGrace()
+	cb.resetCandidate()
+	cb.endDegraded(now, "circuit "+state)
+	cb.resetCounters()
+	cb.applyCounterPolicy(from, state, counts)
+	cb.resetWindows(state)
+	flapping := cb.trackFlapping(now)
+
+	if cb.timer != nil {
+		cb.timer.Stop()
+		cb.timer = nil
+	}
+	if cb.transitionTimer && state == StateOpened {
+		cb.scheduleHalfOpen()
+	}
+
+	cb.queueEvent(Event{
+		Type:   EventStateChange,
+		From:   from,
+		To:     state,
+		Time:   now,
+		Reason: reason,
+		Actor:  actor,
+	})
+	if flapping != nil {
+		cb.queueEvent(*flapping)
+	}
+}
+
+// scheduleHalfOpen arms the timer driving the open -> half-open transition, must be called under write lock
+func (cb *CircuitBreaker) scheduleHalfOpen() {
+	if cb.timer != nil {
+		cb.timer.Stop()
+	}
+
+	generation := cb.generation
+	spent, _ := elapsed(cb.clock.Now(), cb.lastStateChange)
+	delay := cb.openTimeout() - spent
+	cb.timer = cb.clock.AfterFunc(delay, func() {
+		cb.mu.Lock()
+		defer cb.unlock()
+
+		if cb.generation == generation && cb.state == StateOpened {
+			cb.timer = nil
+			if cb.waitingForDrain() {
+				cb.halfOpenDeferred = true
+				return
+			}
+			cb.setState(StateHalfOpen, cb.openTimeoutReason())
+		}
+	})
 }
 
 // - checks is the operation allowed
 func (cb *CircuitBreaker) Allow() bool {
+	return cb.admit(1) == nil
+}
+
+// admit checks the rate limiter and the breaker in the configured order,
+// returns ErrDraining, ErrRateLimited or ErrOpenState when the call is not admitted
+func (cb *CircuitBreaker) admit(cost int64) error {
+	if cb.isDraining() {
+		return ErrDraining
+	}
+	if cb.limiter != nil && cb.limitOrder == LimitBeforeBreaker && !cb.limiter.Allow() {
+		return ErrRateLimited
+	}
+	a, ok := cb.allow(cost)
+	if !ok {
+		return ErrOpenState
+	}
+	if cb.limiter != nil && cb.limitOrder == LimitAfterBreaker && !cb.limiter.Allow() {
+		cb.refund(a)
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// admission is what the breaker charged for an admitted call
+type admission struct {
+	generation uint64
+	probe      bool
+	cost       int64
+}
+
+// allow is the breaker part of Allow
+func (cb *CircuitBreaker) allow(cost int64) (admission, bool) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
 
-	if cb.state == StateOpened && time.Since(cb.lastStateChange) > cb.openedTimeout {
-		cb.state = StateHalfOpen
-		cb.lastStateChange = time.Now()
-		cb.resetCounters()
+	var a admission
+
+	cb.checkOpenTimeout()
+
+	now := cb.clock.Now()
+	cb.applyDeferredClose(now)
+	allowed := (cb.state != StateOpened || cb.takeProbe(now)) && !cb.chaosReject(now) && !cb.shed() && cb.costFits(now, cost)
+	if allowed && cb.state == StateHalfOpen {
+		allowed = cb.admitProbe()
+		a.probe = allowed
+	}
+	a.generation = cb.generation
+	if forced, ok := cb.forcedState(now); ok {
+		allowed = forced != StateOpened
+	} else if allowed {
+		cb.spendCost(cost)
+		a.cost = cost
 	}
 
-	return cb.state != StateOpened
+	if allowed {
+		cb.admitted++
+	} else {
+		cb.rejected++
+		cb.rejectedInState++
+		cb.queueRejection()
+	}
+
+	return a, allowed
 }
 
-// - calculates value to check threshold
-func (cb *CircuitBreaker) calculateCheckValue(counter int64, threshold CustomThreshold) interface{} {
-	switch threshold.(type) {
-	case *Int64Threshold:
-		return counter
-	case *Float64Threshold:
-		total := cb.successes + cb.failures
-		if total == 0 {
-			return 0.0
-		}
-		return float64(counter) / float64(total)
-	default:
-		return struct {
-			Successes int64
-			Failures  int64
-			Total     int64
-		}{
-			Successes: cb.successes,
-			Failures:  cb.failures,
-			Total:     cb.successes + cb.failures,
-		}
+// refund returns the probe slot, the cost and the admission of a call rejected
+// after the breaker admitted it
+func (cb *CircuitBreaker) refund(a admission) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.admitted--
+	if a.generation != cb.generation {
+		return
+	}
+	if a.probe && cb.probesAdmitted > 0 {
+		cb.probesAdmitted--
+	}
+	if a.cost > 0 && cb.costGeneration == cb.generation {
+		cb.costSpent = max(cb.costSpent-a.cost, 0)
 	}
 }
 
+// check evaluates the switch, values the threshold can not check are reported
+// as EventThresholdError, must be called under write lock
+func (cb *CircuitBreaker) check(sw Switch, value any) bool {
+	es, ok := sw.(interface {
+		CheckErr(value any) (bool, error)
+	})
+	if !ok {
+		return sw.Check(value)
+	}
+
+	passed, err := es.CheckErr(value)
+	if err != nil {
+		cb.queueEvent(Event{
+			Type:   EventThresholdError,
+			From:   cb.state,
+			To:     cb.state,
+			Time:   cb.clock.Now(),
+			Reason: err.Error(),
+		})
+	}
+	return passed
+}
+
 // - records a success call
 func (cb *CircuitBreaker) RecordSuccess() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
+
+	cb.recordSuccess(cb.clock.Now())
+}
+
+// recordSuccess must be called under write lock
+func (cb *CircuitBreaker) recordSuccess(now time.Time) {
+	if _, ok := cb.forcedState(now); ok {
+		return
+	}
+
+	if cb.chaosFailure(now) {
+		cb.recordFailure(now)
+		return
+	}
+
+	if cb.state == StateClosed && cb.skipSample() {
+		return
+	}
+
+	cb.recordedCalls++
+	cb.rotateCounters(now)
+	cb.recordSample(now, true)
 
 	switch cb.state {
 	case StateClosed:
 		cb.successes++
-		cb.failures = 0
+		cb.consecutiveSuccesses++
+		cb.consecutiveFailures = 0
+		cb.recordOutcome(now, true)
+		cb.evaluateTrip(now, true)
 
 	case StateHalfOpen:
 		cb.successes++
-		cb.failures = 0
+		if cb.halfOpenPolicy != nil {
+			cb.applyHalfOpenPolicy()
+			return
+		}
+
+		if rate, ok := cb.successThreshold.(*SuccessRateThreshold); ok {
+			cb.decideProbeRate(now, rate)
+			return
+		}
 
-		checkValue := cb.calculateCheckValue(cb.successes, cb.successThreshold)
-		if cb.successSwitch.Check(checkValue) {
-			cb.state = StateClosed
-			cb.lastStateChange = time.Now()
-			cb.resetCounters()
+		checkValue, ok := cb.thresholdValue(cb.successThreshold, cb.successes, cb.successes, cb.counts())
+		if ok && cb.check(cb.successSwitch, checkValue) {
+			cb.closeHalfOpen(now, describeCheck("success", checkValue, cb.successThreshold))
 		}
 
 	case StateOpened:
-		return
+		if cb.probeBudget > 0 && !cb.dwelling(now) {
+			cb.setState(StateHalfOpen, "probe succeeded in open state")
+		}
 	}
 }
 
 // - records failure call
 func (cb *CircuitBreaker) RecordFailure() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	defer cb.unlock()
+
+	cb.recordFailure(cb.clock.Now())
+}
+
+// recordFailure must be called under write lock
+func (cb *CircuitBreaker) recordFailure(now time.Time) {
+	if _, ok := cb.forcedState(now); ok {
+		return
+	}
+
+	if cb.state == StateClosed && cb.skipSample() {
+		return
+	}
+
+	cb.recordedCalls++
+	cb.rotateCounters(now)
+	cb.recordSample(now, false)
 
 	switch cb.state {
 	case StateClosed:
 		cb.failures++
-		cb.successes = 0
-
-		checkValue := cb.calculateCheckValue(cb.failures, cb.failureThreshold)
-		if cb.failureSwitch.Check(checkValue) {
-			cb.state = StateOpened
-			cb.lastStateChange = time.Now()
-			cb.resetCounters()
-		}
+		cb.consecutiveFailures++
+		cb.consecutiveSuccesses = 0
+		cb.recordOutcome(now, false)
+		cb.evaluateTrip(now, false)
 
 	case StateHalfOpen:
-		cb.state = StateOpened
-		cb.lastStateChange = time.Now()
-		cb.resetCounters()
+		cb.failures++
+		if cb.halfOpenPolicy != nil {
+			cb.applyHalfOpenPolicy()
+			return
+		}
+		if rate, ok := cb.successThreshold.(*SuccessRateThreshold); ok {
+			cb.decideProbeRate(now, rate)
+			return
+		}
+		cb.setState(StateOpened, "probe failed in half-open state")
 
 	case StateOpened:
 		return
 	}
 }
 
-// - returns current state
+// - returns current state, the state forced manually or by an active maintenance window takes precedence
+//
+// The read path only takes the read lock, so monitoring loops do not contend
+// with the data path. The write lock is taken only when the open timeout has
+// expired and the lazy open -> half-open transition must be applied.
 func (cb *CircuitBreaker) State() string {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.mu.RLock()
+	now := cb.clock.Now()
+	state, pending := cb.state, cb.openTimeoutPending(now)
+	forced, isForced := cb.forcedState(now)
+	cb.mu.RUnlock()
 
-	if cb.state == StateOpened && time.Since(cb.lastStateChange) > cb.openedTimeout {
-		cb.state = StateHalfOpen
-		cb.lastStateChange = time.Now()
-		cb.resetCounters()
+	if isForced {
+		return forced
+	}
+	if !pending {
+		return state
 	}
+
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.checkOpenTimeout()
 	return cb.state
 }
+
+// openTimeoutPending reports whether checkOpenTimeout has work to do: the opened state
+// should switch to half-open or the clock went backwards, always false when the
+// transition is driven by the timer
+func (cb *CircuitBreaker) openTimeoutPending(now time.Time) bool {
+	if cb.transitionTimer || cb.state != StateOpened {
+		return false
+	}
+	d, skewed := elapsed(now, cb.lastStateChange)
+	return skewed || d > cb.openTimeout() && !cb.waitingForDrain()
+}
+
+// checkOpenTimeout applies the lazy open -> half-open transition, must be called under write lock
+func (cb *CircuitBreaker) checkOpenTimeout() {
+	now := cb.clock.Now()
+	if !cb.openTimeoutPending(now) {
+		return
+	}
+
+	if _, skewed := elapsed(now, cb.lastStateChange); skewed {
+		// the clock jumped backwards, restart the open period instead of
+		// staying open until the clock catches up
+		cb.lastStateChange = now
+		return
+	}
+
+	cb.setState(StateHalfOpen, cb.openTimeoutReason())
+}
+
+// openTimeoutReason describes the open -> half-open transition
+func (cb *CircuitBreaker) openTimeoutReason() string {
+	return fmt.Sprintf("open timeout %s elapsed", cb.openTimeout())
+}
+
+// - returns the time left until the open timeout elapses and probing starts, zero when not open
+func (cb *CircuitBreaker) OpenRemaining() time.Duration {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	if cb.state != StateOpened {
+		return 0
+	}
+	spent, _ := elapsed(cb.clock.Now(), cb.lastStateChange)
+	return max(cb.openTimeout()-spent, 0)
+}
@@ -0,0 +1,68 @@
+package circuitbreaker
+
+import (
+	"context"
+	"time"
+)
+
+type costKey struct{}
+
+// - declares the cost of the call run with ctx, e.g. the tokens of a request or the rows
+// of a query, calls without a cost cost 1, see WithCostBudget
+func WithCost(ctx context.Context, cost int64) context.Context {
+	return context.WithValue(ctx, costKey{}, cost)
+}
+
+// - returns the cost declared with WithCost, 1 when none was declared
+func CostFrom(ctx context.Context) int64 {
+	if ctx == nil {
+		return 1
+	}
+	if cost, ok := ctx.Value(costKey{}).(int64); ok {
+		return cost
+	}
+	return 1
+}
+
+// - limits the cost admitted per window while the breaker is degraded, i.e. in half-open
+// state or shedding in closed state (see WithSoftOpen): a call is admitted only while
+// its cost fits into the remaining capacity, so heavy calls are shed first and light
+// ones keep going, the budget restarts every window and on every transition
+func WithCostBudget(capacity int64, window time.Duration) Option {
+	return func(cb *CircuitBreaker) {
+		cb.costCapacity = capacity
+		cb.costWindow = window
+	}
+}
+
+// - is Allow for a call of the given cost, see WithCostBudget
+func (cb *CircuitBreaker) AllowCost(cost int64) bool {
+	return cb.admit(cost) == nil
+}
+
+// costFits reports whether cost fits into the remaining budget, must be called under write lock
+func (cb *CircuitBreaker) costFits(now time.Time, cost int64) bool {
+	if cb.costCapacity <= 0 || !cb.degraded() {
+		return true
+	}
+
+	spent, skewed := elapsed(now, cb.costWindowStart)
+	if cb.costGeneration != cb.generation || skewed || spent >= cb.costWindow {
+		cb.costGeneration = cb.generation
+		cb.costWindowStart = now
+		cb.costSpent = 0
+	}
+	return cb.costSpent+cost <= cb.costCapacity
+}
+
+// spendCost charges an admitted call to the budget, must be called under write lock
+func (cb *CircuitBreaker) spendCost(cost int64) {
+	if cb.costCapacity > 0 && cb.degraded() {
+		cb.costSpent += cost
+	}
+}
+
+// degraded reports whether the breaker admits only part of the traffic, must be called under lock
+func (cb *CircuitBreaker) degraded() bool {
+	return cb.state == StateHalfOpen || (cb.state == StateClosed && cb.soft != nil && cb.soft.degraded)
+}
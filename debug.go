@@ -0,0 +1,160 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// number of recent transitions kept for Transitions and DebugDump
+	transitionHistory = 16
+	// number of recent results kept for RecentSamples
+	sampleHistory = 64
+)
+
+// - is a recorded call result
+type Sample struct {
+	Time    time.Time
+	Success bool
+	// state the result was recorded in
+	State string
+}
+
+// - is an optional extension of thresholds and trip policies describing their window
+// contents in DebugDump, it is called under the breaker read lock and must not block
+type DebugWindow interface {
+	DebugWindow() string
+}
+
+// - returns the recent transitions, oldest first
+func (cb *CircuitBreaker) Transitions() []TransitionInfo {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return slices.Clone(cb.history)
+}
+
+// recordHistory keeps tr in the recent transitions, must be called under write lock
+func (cb *CircuitBreaker) recordHistory(tr TransitionInfo) {
+	if len(cb.history) == transitionHistory {
+		cb.history = slices.Delete(cb.history, 0, 1)
+	}
+	cb.history = append(cb.history, tr)
+}
+
+// - returns the recent call results, oldest first
+func (cb *CircuitBreaker) RecentSamples() []Sample {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	n := min(cb.sampled, sampleHistory)
+	samples := make([]Sample, 0, n)
+	for i := cb.sampled - n; i < cb.sampled; i++ {
+		samples = append(samples, cb.samples[i%sampleHistory])
+	}
+	return samples
+}
+
+// recordSample keeps a result in the recent samples, must be called under write lock
+func (cb *CircuitBreaker) recordSample(now time.Time, success bool) {
+	cb.samples[cb.sampled%sampleHistory] = Sample{Time: now, Success: success, State: cb.state}
+	cb.sampled++
+}
+
+// - returns a multi-line dump of state, config, counters, window contents and recent
+// transitions taken under a single read lock, so the values are consistent with each
+// other, it applies no pending transition and is safe to call from a signal handler
+// or a debug endpoint while calls are running
+func (cb *CircuitBreaker) DebugDump() string {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	now := cb.clock.Now()
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "breaker %q\n", cb.name)
+	since, _ := elapsed(now, cb.lastStateChange)
+	fmt.Fprintf(&b, "state: %s for %s, generation %d\n", cb.state, since.Round(time.Millisecond), cb.generation)
+	if forced, ok := cb.forcedState(now); ok {
+		fmt.Fprintf(&b, "forced: %s\n", forced)
+	}
+	if cb.state == StateOpened {
+		fmt.Fprintf(&b, "open: streak %d, timeout %s, pending half-open %v\n", cb.openStreak, cb.openTimeout(), cb.openTimeoutPending(now))
+	}
+	if cb.soft != nil && cb.soft.degraded {
+		fmt.Fprintf(&b, "degraded: shedding %.0f%%\n", cb.soft.shed*100)
+	}
+	if cb.flap != nil && cb.flap.damped {
+		b.WriteString("flapping: open timeout damped\n")
+	}
+
+	fmt.Fprintf(&b, "config: failure %s, success %s, open timeout %s\n",
+		describeThreshold(cb.failureThreshold), describeThreshold(cb.successThreshold), cb.openedTimeout)
+	if cb.tripPolicy != nil {
+		fmt.Fprintf(&b, "config: trip policy %T\n", cb.tripPolicy)
+	}
+
+	fmt.Fprintf(&b, "counters: successes %d, failures %d, consecutive successes %d, consecutive failures %d\n",
+		cb.successes, cb.failures, cb.consecutiveSuccesses, cb.consecutiveFailures)
+	fmt.Fprintf(&b, "calls: rejected %d (%d in state), ignored %d, inflight %d\n",
+		cb.rejected, cb.rejectedInState, cb.ignored, cb.inflight)
+
+	if len(cb.load) > 0 {
+		metrics := make([]string, 0, len(cb.load))
+		for metric, value := range cb.load {
+			metrics = append(metrics, fmt.Sprintf("%s=%g", metric, value))
+		}
+		sort.Strings(metrics)
+		fmt.Fprintf(&b, "load: %s\n", strings.Join(metrics, " "))
+	}
+
+	for _, source := range []any{cb.failureThreshold, cb.successThreshold, cb.tripPolicy} {
+		if w, ok := source.(DebugWindow); ok {
+			fmt.Fprintf(&b, "window: %s\n", w.DebugWindow())
+		}
+	}
+
+	b.WriteString("transitions:\n")
+	if len(cb.history) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, tr := range cb.history {
+		fmt.Fprintf(&b, "  %s %s -> %s: %s", tr.At.Format(time.RFC3339Nano), tr.From, tr.To, tr.Reason)
+		if tr.Actor != "" {
+			fmt.Fprintf(&b, " (by %s)", tr.Actor)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// - describes the failures within the window without pruning them
+func (sw *SlidingWindowThreshold) DebugWindow() string {
+	sw.mu.RLock()
+	defer sw.mu.RUnlock()
+
+	windowStart := sw.clock.Now().Add(-sw.windowSize)
+	n := 0
+	for _, ft := range sw.failureTimes {
+		if ft.After(windowStart) {
+			n++
+		}
+	}
+	return fmt.Sprintf("%s, %d of %d failures within %s", sw.name, n, sw.maxFailures, sw.windowSize)
+}
+
+// - describes the results within every window as of the last result
+func (t *MultiWindowTrip) DebugWindow() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	windows := make([]string, len(t.windows))
+	for i, w := range t.windows {
+		calls, failures := w.counts(t.last)
+		windows[i] = fmt.Sprintf("%d/%d failed over %s (rate %.1f%%)", failures, calls, w.Window, w.Rate*100)
+	}
+	return strings.Join(windows, ", ")
+}
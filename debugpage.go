@@ -0,0 +1,83 @@
+package circuitbreaker
+
+import (
+	"html/template"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// debugPage renders the breakers of the registry like /debug/requests, a row of recent
+// results per breaker, the transitions as a timeline and the DebugDump
+var debugPage = template.Must(template.New("debug").Funcs(template.FuncMap{
+	"since": func(now, t time.Time) string { return now.Sub(t).Round(time.Millisecond).String() },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>circuit breakers</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; }
+h2 { margin-bottom: 4px; }
+.closed { color: #2a7d2a; } .open { color: #c0392b; } .half-open { color: #b9770e; }
+.samples span { display: inline-block; width: 8px; height: 14px; margin-right: 1px; }
+.success { background: #52be80; } .failure { background: #e74c3c; }
+table { border-collapse: collapse; } td, th { padding: 2px 8px; text-align: left; }
+pre { background: #f4f4f4; padding: 6px; }
+</style>
+</head>
+<body>
+<h1>circuit breakers</h1>
+<p>{{len .Breakers}} breakers at {{.Now.Format "2006-01-02T15:04:05.000Z07:00"}}</p>
+{{range $breaker := .Breakers}}
+<h2 id="{{.Name}}">{{.Name}} <span class="{{.State}}">{{.State}}</span>{{if .Forced}} (forced){{end}}</h2>
+<div class="samples">
+{{range .Samples}}<span class="{{if .Success}}success{{else}}failure{{end}}" title="{{.Time.Format "15:04:05.000"}} {{.State}}"></span>{{else}}no recent results{{end}}
+</div>
+<table>
+<tr><th>ago</th><th>transition</th><th>reason</th><th>actor</th></tr>
+{{range .Transitions}}<tr><td>{{since $breaker.Now .At}}</td><td><span class="{{.From}}">{{.From}}</span> &rarr; <span class="{{.To}}">{{.To}}</span></td><td>{{.Reason}}</td><td>{{.Actor}}</td></tr>
+{{else}}<tr><td colspan="4">no transitions</td></tr>
+{{end}}
+</table>
+<details><summary>dump</summary><pre>{{.Dump}}</pre></details>
+{{end}}
+</body>
+</html>
+`))
+
+type debugBreaker struct {
+	// time of the breaker clock, the ages of its transitions are computed from it
+	Now         time.Time
+	Name        string
+	State       string
+	Forced      bool
+	Samples     []Sample
+	Transitions []TransitionInfo
+	Dump        string
+}
+
+// writeDebugPage renders debugPage for the breakers of the registry, newest transitions first,
+// the page is stamped with the wall time it was rendered at
+func writeDebugPage(w http.ResponseWriter, registry *Registry) {
+	data := struct {
+		Now      time.Time
+		Breakers []debugBreaker
+	}{Now: realClock{}.Now()}
+
+	for _, cb := range registry.All() {
+		transitions := cb.Transitions()
+		slices.Reverse(transitions)
+		data.Breakers = append(data.Breakers, debugBreaker{
+			Now:         cb.clock.Now(),
+			Name:        cb.Name(),
+			State:       cb.State(),
+			Forced:      cb.Forced(),
+			Samples:     cb.RecentSamples(),
+			Transitions: transitions,
+			Dump:        cb.DebugDump(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = debugPage.Execute(w, data)
+}
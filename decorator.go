@@ -0,0 +1,108 @@
+package circuitbreaker
+
+import (
+	"context"
+	"log/slog"
+)
+
+// - is the minimal breaker API, implemented by *CircuitBreaker and by decorators
+type Breaker interface {
+	Allow() bool
+	RecordSuccess()
+	RecordFailure()
+	State() string
+}
+
+var _ Breaker = (*CircuitBreaker)(nil)
+
+// - wraps a breaker to add a concern (instrumentation, logging, shadow mode, rate limiting)
+type Decorator func(Breaker) Breaker
+
+// - applies decorators to the breaker, the first decorator is the outermost
+func Decorate(b Breaker, decorators ...Decorator) Breaker {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		b = decorators[i](b)
+	}
+	return b
+}
+
+// - runs fn if the breaker allows it and records its outcome,
+// returns ErrOpenState without calling fn otherwise
+func Do(b Breaker, fn func() error) error {
+	if !b.Allow() {
+		return ErrOpenState
+	}
+
+	if err := fn(); err != nil {
+		b.RecordFailure()
+		return err
+	}
+
+	b.RecordSuccess()
+	return nil
+}
+
+// - admits calls the inner breaker rejects while still recording outcomes,
+// onReject is called for each call that would have been rejected
+func Shadow(onReject func()) Decorator {
+	return func(inner Breaker) Breaker {
+		return &shadowBreaker{Breaker: inner, onReject: onReject}
+	}
+}
+
+type shadowBreaker struct {
+	Breaker
+	onReject func()
+}
+
+func (s *shadowBreaker) Allow() bool {
+	if !s.Breaker.Allow() && s.onReject != nil {
+		s.onReject()
+	}
+	return true
+}
+
+// - logs rejected calls and state changes caused by recorded outcomes
+func Logging(logger *slog.Logger, name string) Decorator {
+	return func(inner Breaker) Breaker {
+		return &loggingBreaker{Breaker: inner, logger: logger, name: name}
+	}
+}
+
+type loggingBreaker struct {
+	Breaker
+	logger *slog.Logger
+	name   string
+}
+
+func (l *loggingBreaker) Allow() bool {
+	allowed := l.Breaker.Allow()
+	if !allowed {
+		l.logger.LogAttrs(context.Background(), slog.LevelDebug, "circuit breaker rejected call",
+			slog.String("breaker", l.name),
+			slog.String("state", l.Breaker.State()),
+		)
+	}
+	return allowed
+}
+
+func (l *loggingBreaker) RecordSuccess() {
+	l.logTransition(l.Breaker.RecordSuccess)
+}
+
+func (l *loggingBreaker) RecordFailure() {
+	l.logTransition(l.Breaker.RecordFailure)
+}
+
+// logTransition runs record and logs the state change it caused
+func (l *loggingBreaker) logTransition(record func()) {
+	from := l.Breaker.State()
+	record()
+	if to := l.Breaker.State(); to != from {
+		l.logger.LogAttrs(context.Background(), slog.LevelInfo, "circuit breaker state changed",
+			slog.String("breaker", l.name),
+			slog.String("from", from),
+			slog.String("to", to),
+		)
+	}
+}
@@ -0,0 +1,233 @@
+// Package dnsbreaker wraps DNS lookups with per-zone circuit breakers.
+package dnsbreaker
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is the subset of *net.Resolver wrapped by Resolver, LookupIP, LookupCNAME, LookupMX,
+// LookupSRV and LookupTXT are wrapped too when the Lookuper implements them (as *net.Resolver
+// does), reverse (LookupAddr), NS and port lookups are not wrapped, call the resolver directly
+type Lookuper interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// - maps a host name to the zone its breaker is keyed by
+type ZoneFunc func(host string) string
+
+// - returns the last two labels of the host, e.g. "example.com" for "api.eu.example.com"
+func LastTwoLabels(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// - applies a breaker per lookup zone, so NXDOMAIN and timeout storms fail fast,
+// and optionally answers from a stale cache of previous answers while the zone is failing
+type Resolver struct {
+	resolver Lookuper
+	breakers *circuitbreaker.KeyedBreaker
+	zone     ZoneFunc
+	staleTTL time.Duration
+	clock    circuitbreaker.Clock
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	// expired entries are swept from the cache at most once per staleTTL
+	swept time.Time
+}
+
+type cacheEntry struct {
+	value any
+	at    time.Time
+}
+
+// - is a constructor, nil resolver means net.DefaultResolver
+func NewResolver(resolver Lookuper, breakers *circuitbreaker.KeyedBreaker) *Resolver {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &Resolver{
+		resolver: resolver,
+		breakers: breakers,
+		zone:     LastTwoLabels,
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+// - replaces LastTwoLabels as the zone function
+func (r *Resolver) SetZoneFunc(zone ZoneFunc) {
+	r.zone = zone
+}
+
+// - enables answering from previous successful lookups not older than ttl
+// when the zone circuit is open or the lookup fails, zero disables the fallback,
+// answers older than ttl are evicted, so the cache holds the names looked up recently
+func (r *Resolver) SetStaleCache(ttl time.Duration) {
+	r.staleTTL = ttl
+}
+
+// - replaces the wall clock used for the age of cached answers, must be called before use
+func (r *Resolver) SetClock(clock circuitbreaker.Clock) {
+	r.clock = clock
+}
+
+// - LookupHost
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return lookup(ctx, r, "host", host, r.resolver.LookupHost)
+}
+
+// - LookupIPAddr
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return lookup(ctx, r, "ip", host, r.resolver.LookupIPAddr)
+}
+
+// - LookupIP, fails with ErrNotImplemented when the Lookuper does not implement it
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	resolver, ok := r.resolver.(interface {
+		LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	})
+	if !ok {
+		return nil, circuitbreaker.ErrNotImplemented
+	}
+	return lookup(ctx, r, "ip/"+network, host, func(ctx context.Context, host string) ([]net.IP, error) {
+		return resolver.LookupIP(ctx, network, host)
+	})
+}
+
+// - LookupCNAME, fails with ErrNotImplemented when the Lookuper does not implement it
+func (r *Resolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	resolver, ok := r.resolver.(interface {
+		LookupCNAME(ctx context.Context, host string) (string, error)
+	})
+	if !ok {
+		return "", circuitbreaker.ErrNotImplemented
+	}
+	return lookup(ctx, r, "cname", host, resolver.LookupCNAME)
+}
+
+// - LookupMX, fails with ErrNotImplemented when the Lookuper does not implement it
+func (r *Resolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	resolver, ok := r.resolver.(interface {
+		LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	})
+	if !ok {
+		return nil, circuitbreaker.ErrNotImplemented
+	}
+	return lookup(ctx, r, "mx", name, resolver.LookupMX)
+}
+
+// - LookupTXT, fails with ErrNotImplemented when the Lookuper does not implement it
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	resolver, ok := r.resolver.(interface {
+		LookupTXT(ctx context.Context, name string) ([]string, error)
+	})
+	if !ok {
+		return nil, circuitbreaker.ErrNotImplemented
+	}
+	return lookup(ctx, r, "txt", name, resolver.LookupTXT)
+}
+
+// - LookupSRV, the breaker is chosen by the zone of name, fails with ErrNotImplemented
+// when the Lookuper does not implement it
+func (r *Resolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	resolver, ok := r.resolver.(interface {
+		LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	})
+	if !ok {
+		return "", nil, circuitbreaker.ErrNotImplemented
+	}
+
+	type answer struct {
+		cname string
+		addrs []*net.SRV
+	}
+	result, err := lookup(ctx, r, "srv/"+service+"/"+proto, name, func(ctx context.Context, name string) (answer, error) {
+		cname, addrs, err := resolver.LookupSRV(ctx, service, proto, name)
+		return answer{cname: cname, addrs: addrs}, err
+	})
+	return result.cname, result.addrs, err
+}
+
+// lookup runs fn through the zone breaker and maintains the stale cache
+func lookup[T any](ctx context.Context, r *Resolver, kind, host string, fn func(context.Context, string) (T, error)) (T, error) {
+	cacheKey := kind + ":" + host
+	cb := r.breakers.Get(r.zone(host))
+
+	result, err := circuitbreaker.ExecuteContext(ctx, cb, func(ctx context.Context) (T, error) {
+		return fn(ctx, host)
+	})
+	if err == nil {
+		r.store(cacheKey, result)
+		return result, nil
+	}
+
+	if cached, ok := r.load(cacheKey); ok {
+		return cached.(T), nil
+	}
+	return result, err
+}
+
+func (r *Resolver) store(key string, value any) {
+	if r.staleTTL <= 0 {
+		return
+	}
+
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cache[key] = cacheEntry{value: value, at: now}
+	if now.Sub(r.swept) >= r.staleTTL {
+		r.sweep(now)
+	}
+}
+
+// sweep evicts the expired answers, must be called under lock
+func (r *Resolver) sweep(now time.Time) {
+	for key, entry := range r.cache {
+		if now.Sub(entry.at) > r.staleTTL {
+			delete(r.cache, key)
+		}
+	}
+	r.swept = now
+}
+
+func (r *Resolver) load(key string) (any, bool) {
+	if r.staleTTL <= 0 {
+		return nil, false
+	}
+
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if now.Sub(entry.at) > r.staleTTL {
+		delete(r.cache, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (r *Resolver) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
@@ -0,0 +1,125 @@
+package dnsbreaker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/nick1jesky/circuit_breaker/cbtest"
+)
+
+type fakeLookuper struct {
+	fail  bool
+	calls int
+}
+
+func (f *fakeLookuper) LookupHost(_ context.Context, host string) ([]string, error) {
+	f.calls++
+	if f.fail {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []string{"10.0.0.1"}, nil
+}
+
+func (f *fakeLookuper) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	return nil, errors.New("not used")
+}
+
+func (f *fakeLookuper) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	f.calls++
+	if f.fail {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	return []*net.MX{{Host: "mx." + name, Pref: 10}}, nil
+}
+
+func TestResolver(t *testing.T) {
+	lookuper := &fakeLookuper{}
+	breakers := circuitbreaker.NewKeyedBreaker(func(string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(2),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+		)
+	})
+
+	resolver := NewResolver(lookuper, breakers)
+	resolver.SetStaleCache(time.Minute)
+
+	if _, err := resolver.LookupHost(context.Background(), "api.example.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lookuper.fail = true
+	for range 2 {
+		addrs, err := resolver.LookupHost(context.Background(), "api.example.com")
+		if err != nil || len(addrs) != 1 {
+			t.Errorf("Expected stale answer, got %v and %v", addrs, err)
+		}
+	}
+
+	calls := lookuper.calls
+	if _, err := resolver.LookupHost(context.Background(), "www.example.com"); !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Errorf("Expected zone circuit to be open, got %v", err)
+	}
+	if lookuper.calls != calls {
+		t.Error("Expected open zone to fail fast without lookup")
+	}
+
+	if zone := LastTwoLabels("api.eu.example.com."); zone != "example.com" {
+		t.Errorf("Expected example.com, got %s", zone)
+	}
+}
+
+func TestResolverStaleCacheExpiry(t *testing.T) {
+	lookuper := &fakeLookuper{}
+	breakers := circuitbreaker.NewKeyedBreaker(func(string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(100),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+		)
+	})
+	clock := cbtest.NewFakeClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	resolver := NewResolver(lookuper, breakers)
+	resolver.SetStaleCache(time.Minute)
+	resolver.SetClock(clock)
+
+	if _, err := resolver.LookupMX(context.Background(), "example.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := resolver.LookupHost(context.Background(), "api.example.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lookuper.fail = true
+	clock.Advance(30 * time.Second)
+	if mx, err := resolver.LookupMX(context.Background(), "example.com"); err != nil || len(mx) != 1 {
+		t.Errorf("Expected stale MX answer, got %v and %v", mx, err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := resolver.LookupMX(context.Background(), "example.com"); err == nil {
+		t.Error("Expected the stale answer to expire with the clock")
+	}
+
+	// storing an answer sweeps the answers of names never looked up again
+	lookuper.fail = false
+	if _, err := resolver.LookupHost(context.Background(), "www.example.com"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resolver.mu.Lock()
+	size := len(resolver.cache)
+	resolver.mu.Unlock()
+	if size != 1 {
+		t.Errorf("Expected the expired answers to be swept, got %d entries", size)
+	}
+
+	if _, err := resolver.LookupTXT(context.Background(), "example.com"); !errors.Is(err, circuitbreaker.ErrNotImplemented) {
+		t.Errorf("Expected %v, got %v", circuitbreaker.ErrNotImplemented, err)
+	}
+}
@@ -0,0 +1,69 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// - stops admitting new calls, Allow returns false and Execute ErrDraining from now on,
+// and waits until the protected calls in flight have completed or ctx is done,
+// for graceful shutdown of services using Execute
+func (cb *CircuitBreaker) Drain(ctx context.Context) error {
+	cb.mu.Lock()
+	cb.draining = true
+	if cb.inflight == 0 {
+		cb.unlock()
+		return nil
+	}
+	if cb.drained == nil {
+		cb.drained = make(chan struct{})
+	}
+	drained := cb.drained
+	cb.unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isDraining reports whether Drain was called
+func (cb *CircuitBreaker) isDraining() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	return cb.draining
+}
+
+// notifyDrained wakes Drain once the last call in flight completed, must be called under write lock
+func (cb *CircuitBreaker) notifyDrained() {
+	if cb.inflight == 0 && cb.drained != nil {
+		close(cb.drained)
+		cb.drained = nil
+	}
+}
+
+// - drains every registered breaker concurrently, see CircuitBreaker.Drain,
+// the returned error joins the errors of the breakers still busy when ctx is done
+func (r *Registry) Drain(ctx context.Context) error {
+	all := r.All()
+	errs := make([]error, len(all))
+
+	var wg sync.WaitGroup
+	for i, cb := range all {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cb.Drain(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", cb.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+)
+
+// drill is an open circuit simulated with SimulateOpen
+type drill struct {
+	until time.Time
+	timer Timer
+}
+
+// - simulates an open circuit for d, for game days validating alerting pipelines without
+// impacting users: EventSimulatedOpen and EventSimulatedOpenEnd are emitted instead of state
+// changes and Metrics reports the open state, but the calls are still admitted and counted as
+// admitted, and State, results and transitions of the real circuit are not affected, calling
+// it again during the simulation extends it, d <= 0 ends it
+func (cb *CircuitBreaker) SimulateOpen(d time.Duration) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	now := cb.clock.Now()
+	if cb.drill != nil {
+		cb.drill.timer.Stop()
+		if d <= 0 {
+			cb.endDrill(now, "simulation stopped")
+			return
+		}
+	} else {
+		if d <= 0 {
+			return
+		}
+		cb.drill = &drill{}
+		cb.queueEvent(Event{
+			Type:   EventSimulatedOpen,
+			From:   cb.state,
+			To:     StateOpened,
+			Time:   now,
+			Reason: fmt.Sprintf("simulated open for %s", d),
+		})
+	}
+
+	current := cb.drill
+	current.until = now.Add(d)
+	current.timer = cb.clock.AfterFunc(d, func() {
+		cb.mu.Lock()
+		defer cb.unlock()
+
+		if cb.drill == current {
+			cb.endDrill(cb.clock.Now(), "simulation ended")
+		}
+	})
+}
+
+// - reports whether an open circuit is simulated, see SimulateOpen
+func (cb *CircuitBreaker) Simulating() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.drill != nil
+}
+
+// endDrill queues the simulated return to the real state, must be called under write lock
+func (cb *CircuitBreaker) endDrill(now time.Time, reason string) {
+	cb.drill = nil
+	cb.queueEvent(Event{
+		Type:   EventSimulatedOpenEnd,
+		From:   StateOpened,
+		To:     cb.state,
+		Time:   now,
+		Reason: reason,
+	})
+}
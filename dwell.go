@@ -0,0 +1,49 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+)
+
+// - keeps the breaker in state for at least d after entering it, so a dependency
+// hovering around the threshold does not flap the circuit and thrash downstream
+// connection pools: in closed state the circuit does not trip, in half-open state
+// it does not close (a failed probe still reopens it) and the open state lasts at
+// least d regardless of the open timeout, results are still counted meanwhile
+func WithDwellTime(state string, d time.Duration) Option {
+	return func(cb *CircuitBreaker) {
+		if cb.dwell == nil {
+			cb.dwell = make(map[string]time.Duration)
+		}
+		cb.dwell[state] = d
+	}
+}
+
+// dwelling reports whether the minimum dwell time of the current state has not elapsed,
+// must be called under lock
+func (cb *CircuitBreaker) dwelling(now time.Time) bool {
+	d, ok := cb.dwell[cb.state]
+	if !ok {
+		return false
+	}
+	spent, skewed := elapsed(now, cb.lastStateChange)
+	return !skewed && spent < d
+}
+
+// closeHalfOpen closes the circuit unless the half-open dwell time has not elapsed,
+// then the close is applied by the first call admitted afterwards, must be called under write lock
+func (cb *CircuitBreaker) closeHalfOpen(now time.Time, reason string) {
+	if cb.dwelling(now) {
+		cb.closeDeferred = true
+		return
+	}
+	cb.setState(StateClosed, reason)
+}
+
+// applyDeferredClose closes the circuit whose close was deferred by the dwell time,
+// must be called under write lock
+func (cb *CircuitBreaker) applyDeferredClose(now time.Time) {
+	if cb.closeDeferred && cb.state == StateHalfOpen && !cb.dwelling(now) {
+		cb.setState(StateClosed, fmt.Sprintf("half-open dwell time %s elapsed", cb.dwell[StateHalfOpen]))
+	}
+}
@@ -0,0 +1,97 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// - reads the breaker configuration from environment variables named prefix + "_" + key,
+// e.g. CB_PAYMENTS_FAILURE_RATE=0.5 for prefix "CB_PAYMENTS", unset keys keep the defaults:
+//
+//	FAILURE_THRESHOLD  consecutive failures opening the circuit, default 5
+//	FAILURE_RATE       failure rate opening the circuit, replaces FAILURE_THRESHOLD
+//	WINDOW             sliding window of MAX_FAILURES failures, replaces both of the above
+//	MAX_FAILURES       failures within WINDOW opening the circuit
+//	SUCCESS_THRESHOLD  successes in half-open state closing the circuit, default 1
+//	OPEN_TIMEOUT       duration of the open state, default 30s
+//	MINIMUM_CALLS      see WithMinimumCalls
+//	WARMUP             warmup duration, see WithWarmup
+//	WARMUP_CALLS       warmup calls, see WithWarmup
+func ConfigFromEnv(prefix string) (Config, error) {
+	env := envReader{prefix: prefix}
+
+	config := Config{
+		Failure:      ThresholdSpec{Type: "int64", Params: map[string]any{"value": env.int64("FAILURE_THRESHOLD", 5)}},
+		Success:      ThresholdSpec{Type: "int64", Params: map[string]any{"value": env.int64("SUCCESS_THRESHOLD", 1)}},
+		OpenTimeout:  Duration(env.duration("OPEN_TIMEOUT", 30*time.Second)),
+		MinimumCalls: env.int64("MINIMUM_CALLS", 0),
+		Warmup:       Duration(env.duration("WARMUP", 0)),
+		WarmupCalls:  env.int64("WARMUP_CALLS", 0),
+	}
+
+	if env.isSet("FAILURE_RATE") {
+		config.Failure = ThresholdSpec{Type: "float64", Params: map[string]any{"value": env.float64("FAILURE_RATE", 0)}}
+	}
+	if env.isSet("WINDOW") {
+		config.Failure = ThresholdSpec{Type: "sliding-window", Params: map[string]any{
+			"window":       env.duration("WINDOW", 0).String(),
+			"max_failures": env.int64("MAX_FAILURES", 5),
+			"name":         prefix,
+		}}
+	}
+
+	return config, env.err
+}
+
+// envReader reads prefixed variables and keeps the first parse error
+type envReader struct {
+	prefix string
+	err    error
+}
+
+func (r *envReader) lookup(key string) (string, bool) {
+	return os.LookupEnv(r.prefix + "_" + key)
+}
+
+func (r *envReader) isSet(key string) bool {
+	_, ok := r.lookup(key)
+	return ok
+}
+
+func (r *envReader) int64(key string, def int64) int64 {
+	value, ok := r.lookup(key)
+	if !ok {
+		return def
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	r.fail(key, err)
+	return v
+}
+
+func (r *envReader) float64(key string, def float64) float64 {
+	value, ok := r.lookup(key)
+	if !ok {
+		return def
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	r.fail(key, err)
+	return v
+}
+
+func (r *envReader) duration(key string, def time.Duration) time.Duration {
+	value, ok := r.lookup(key)
+	if !ok {
+		return def
+	}
+	v, err := time.ParseDuration(value)
+	r.fail(key, err)
+	return v
+}
+
+func (r *envReader) fail(key string, err error) {
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("%s_%s: %w", r.prefix, key, err)
+	}
+}
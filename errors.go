@@ -3,6 +3,21 @@ package circuitbreaker
 import "errors"
 
 var (
-	ErrUnsupporterType = errors.New("unsupported type")
-	ErrNotImplemented  = errors.New("not implemented")
+	ErrUnsupporterType      = errors.New("unsupported type")
+	ErrNotImplemented       = errors.New("not implemented")
+	ErrPoolStopped          = errors.New("pool is stopped")
+	ErrOpenState            = errors.New("circuit breaker is open")
+	ErrEmptyName            = errors.New("circuit breaker name is empty")
+	ErrAlreadyRegistered    = errors.New("circuit breaker is already registered")
+	ErrNotFound             = errors.New("circuit breaker not found")
+	ErrRateLimited          = errors.New("rate limit exceeded")
+	ErrConcurrencyLimited   = errors.New("concurrency limit exceeded")
+	ErrDraining             = errors.New("circuit breaker is draining")
+	ErrUnknownThreshold     = errors.New("unknown threshold type")
+	ErrInvalidThreshold     = errors.New("invalid threshold spec")
+	ErrInvalidState         = errors.New("invalid circuit breaker state")
+	ErrBufferFull           = errors.New("spill buffer is full")
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+	ErrJournalClosed        = errors.New("event journal is closed")
+	ErrUnauthenticated      = errors.New("admin request is not authenticated")
 )
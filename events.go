@@ -0,0 +1,67 @@
+package circuitbreaker
+
+import "time"
+
+// - is a kind of breaker event
+type EventType string
+
+const (
+	EventStateChange EventType = "state-change"
+	EventOverride    EventType = "override"
+	// threshold could not check the value, Reason holds the error
+	EventThresholdError EventType = "threshold-error"
+	// Failover switched between primary and secondary, From and To hold the targets
+	EventFailover EventType = "failover"
+	// a call was rejected, only emitted with WithRejectionEventSampling
+	EventRejected EventType = "rejected"
+	// the breaker started or stopped flapping, see WithFlapDetection
+	EventFlapping    EventType = "flapping"
+	EventFlappingEnd EventType = "flapping-end"
+	// thresholds or open timeout were updated, Reason holds the new values
+	EventConfigChange EventType = "config-change"
+	// the closed breaker started or stopped shedding calls, see WithSoftOpen
+	EventDegraded    EventType = "degraded"
+	EventDegradedEnd EventType = "degraded-end"
+	// a candidate threshold decided differently from the active one, see WithCandidateThreshold
+	EventCandidateDivergence EventType = "candidate-divergence"
+	// an open circuit is simulated and the simulation ended, From and To hold the simulated
+	// transition, the real state does not change, see SimulateOpen
+	EventSimulatedOpen    EventType = "simulated-open"
+	EventSimulatedOpenEnd EventType = "simulated-open-end"
+)
+
+// - describes something that happened to the circuit breaker
+type Event struct {
+	// name of the breaker, see WithName
+	Breaker string
+	Type    EventType
+	From    string
+	To      string
+	Time    time.Time
+	Reason  string
+	// Tags of the call that caused the event, see WithTags
+	Tags Tags
+	// who forced the state, see ForceOpenBy
+	Actor string
+}
+
+// - is called for every breaker event, outside of the breaker lock
+type EventHandler func(Event)
+
+// queueEvent stores event until the write lock is released, must be called under write lock
+func (cb *CircuitBreaker) queueEvent(event Event) {
+	if len(cb.eventHandlers) == 0 {
+		return
+	}
+	event.Breaker = cb.name
+	cb.pending = append(cb.pending, event)
+}
+
+// emit delivers events to the handlers
+func (cb *CircuitBreaker) emit(events []Event) {
+	for _, event := range events {
+		for _, handler := range cb.eventHandlers {
+			handler(event)
+		}
+	}
+}
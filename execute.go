@@ -0,0 +1,122 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// - runs fn if the breaker allows it, records its latency and outcome,
+// returns ErrOpenState (or ErrRateLimited, see WithRateLimiter) without calling fn otherwise
+func Execute[T any](cb *CircuitBreaker, fn func() (T, error)) (T, error) {
+	return execute(nil, cb, func(context.Context) (T, error) {
+		return fn()
+	})
+}
+
+// - is Execute passing ctx to fn, with IgnoreContextErrors a context error
+// is not recorded when ctx itself is done, Tags in ctx (see WithTags) label the call,
+// calls with a ctx marked by WithBypass are admitted in any state
+func ExecuteContext[T any](ctx context.Context, cb *CircuitBreaker, fn func(ctx context.Context) (T, error)) (T, error) {
+	return execute(ctx, cb, fn)
+}
+
+// - runs fn if the breaker allows it, see Execute
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	_, err := Execute(cb, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return err
+}
+
+// - runs fn if the breaker allows it, see ExecuteContext
+func (cb *CircuitBreaker) ExecuteContext(ctx context.Context, fn func(ctx context.Context) error) error {
+	_, err := ExecuteContext(ctx, cb, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// execute is shared by Execute and ExecuteContext, ctx is nil for Execute
+func execute[T any](ctx context.Context, cb *CircuitBreaker, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+
+	// the limiter slot is taken first, so a call it rejects holds no probe slot or cost
+	if cb.concurrency != nil && !cb.concurrency.TryAcquire() {
+		cb.observeCall(ctx, CallInfo{Err: ErrConcurrencyLimited, Rejected: true})
+		return zero, cb.wrapError(ErrConcurrencyLimited, "")
+	}
+
+	var err error
+	if Bypassed(ctx) {
+		err = cb.admitBypass()
+	} else {
+		err = cb.admit(CostFrom(ctx))
+	}
+	if err != nil {
+		if cb.concurrency != nil {
+			cb.concurrency.cancel()
+		}
+		cb.sinkRejection(ctx, err)
+		cb.observeCall(ctx, CallInfo{Err: err, Rejected: true})
+		return zero, cb.wrapError(err, "")
+	}
+
+	var state string
+	if cb.wrapErrors {
+		state = cb.State()
+	}
+
+	generation := cb.beginCall()
+	defer cb.endCall(generation)
+
+	start := cb.clock.Now()
+	returned := false
+	if cb.concurrency != nil {
+		// a panicking fn frees its slot as a dropped call
+		defer func() {
+			cb.concurrency.Release(cb.clock.Now().Sub(start), !returned || err != nil)
+		}()
+	}
+	result, err := fn(ctx)
+	returned = true
+	latency := cb.clock.Now().Sub(start)
+
+	cb.observeCall(ctx, CallInfo{Latency: latency, Err: err})
+
+	if cb.isCallerContextError(ctx, err) {
+		cb.RecordIgnore()
+		return result, cb.wrapError(err, state)
+	}
+
+	tags := TagsFrom(ctx)
+	cb.recordLatencyTagged(latency, tags)
+	cb.recordTagged(err == nil, err, tags)
+
+	return result, cb.wrapError(err, state)
+}
+
+// isCallerContextError reports whether err is a context error caused by the caller
+// and must not count as a dependency failure
+func (cb *CircuitBreaker) isCallerContextError(ctx context.Context, err error) bool {
+	if !cb.ignoreContextErrors || err == nil {
+		return false
+	}
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// without a caller ctx the error comes from fn itself, e.g. its own deadline
+	return ctx != nil && ctx.Err() != nil
+}
+
+// wrapError adds the breaker name and state to err when WithErrorWrapping is set,
+// empty state means the current state
+func (cb *CircuitBreaker) wrapError(err error, state string) error {
+	if !cb.wrapErrors || err == nil {
+		return err
+	}
+	if state == "" {
+		state = cb.State()
+	}
+	return fmt.Errorf("breaker %q (%s): %w", cb.Name(), state, err)
+}
@@ -0,0 +1,199 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// - is a sliding window of call results with exponentially sized buckets: the most recent
+// results are kept in buckets of the resolution, older ones are merged into buckets twice
+// as wide whenever a level holds more than perLevel buckets, so a window of hours needs
+// about perLevel*log2(window/resolution) buckets, the bucket crossing the start of the
+// window is counted in proportion to its overlap
+type ExponentialWindow struct {
+	window     time.Duration
+	resolution time.Duration
+	perLevel   int
+	clock      Clock
+
+	mu      sync.Mutex
+	buckets []expBucket // oldest first, levels are non-increasing
+}
+
+type expBucket struct {
+	start    time.Time
+	end      time.Time
+	level    int
+	calls    int64
+	failures int64
+}
+
+// - is a constructor, perLevel below 2 is raised to 2
+func NewExponentialWindow(window, resolution time.Duration, perLevel int) *ExponentialWindow {
+	return &ExponentialWindow{
+		window:     window,
+		resolution: max(resolution, time.Nanosecond),
+		perLevel:   max(perLevel, 2),
+		clock:      realClock{},
+	}
+}
+
+// - replaces the wall clock used by Record and Counts, must be called before use
+func (w *ExponentialWindow) SetClock(clock Clock) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.clock = clock
+}
+
+// - records a result at the current time
+func (w *ExponentialWindow) Record(success bool) {
+	w.RecordAt(w.clock.Now(), success)
+}
+
+// - records a result at t, results older than the newest bucket are added to it
+func (w *ExponentialWindow) RecordAt(t time.Time, success bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.expire(t)
+
+	n := len(w.buckets)
+	if n == 0 || !t.Before(w.buckets[n-1].end) {
+		start := t.Truncate(w.resolution)
+		w.buckets = append(w.buckets, expBucket{start: start, end: start.Add(w.resolution)})
+		w.compact()
+		n = len(w.buckets)
+	}
+
+	last := &w.buckets[n-1]
+	last.calls++
+	if !success {
+		last.failures++
+	}
+}
+
+// - returns the results within the window ending now
+func (w *ExponentialWindow) Counts() Counts {
+	return w.CountsAt(w.clock.Now())
+}
+
+// - returns the results within the window ending at now
+func (w *ExponentialWindow) CountsAt(now time.Time) Counts {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	windowStart := now.Add(-w.window)
+	var calls, failures float64
+	for _, b := range w.buckets {
+		if !b.end.After(windowStart) {
+			continue
+		}
+		share := 1.0
+		if b.start.Before(windowStart) {
+			share = float64(b.end.Sub(windowStart)) / float64(b.end.Sub(b.start))
+		}
+		calls += float64(b.calls) * share
+		failures += float64(b.failures) * share
+	}
+
+	total, failed := int64(calls+0.5), int64(failures+0.5)
+	return Counts{Successes: total - failed, Failures: failed, Total: total}
+}
+
+// - returns the number of buckets held
+func (w *ExponentialWindow) Buckets() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.buckets)
+}
+
+// - drops every result
+func (w *ExponentialWindow) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buckets = nil
+}
+
+func (w *ExponentialWindow) String() string {
+	return fmt.Sprintf("ExponentialWindow: %s by %s", w.window, w.resolution)
+}
+
+// expire drops the buckets ending before the window, must be called under lock
+func (w *ExponentialWindow) expire(now time.Time) {
+	windowStart := now.Add(-w.window)
+	i := 0
+	for i < len(w.buckets) && !w.buckets[i].end.After(windowStart) {
+		i++
+	}
+	w.buckets = w.buckets[i:]
+}
+
+// compact merges the two oldest buckets of every level holding more than perLevel
+// buckets into one bucket of the next level, must be called under lock
+func (w *ExponentialWindow) compact() {
+	for level := 0; ; level++ {
+		first, count := -1, 0
+		for i, b := range w.buckets {
+			if b.level == level {
+				if first < 0 {
+					first = i
+				}
+				count++
+			}
+		}
+		if count <= w.perLevel {
+			return
+		}
+
+		older, newer := w.buckets[first], w.buckets[first+1]
+		w.buckets[first] = expBucket{
+			start:    older.start,
+			end:      newer.end,
+			level:    level + 1,
+			calls:    older.calls + newer.calls,
+			failures: older.failures + newer.failures,
+		}
+		w.buckets = append(w.buckets[:first+1], w.buckets[first+2:]...)
+	}
+}
+
+// - is a TripPolicy tripping when the failure rate of an ExponentialWindow reaches Rate,
+// suited for long windows such as an hourly error budget, the window is reset when
+// the circuit closes
+type WindowRateTrip struct {
+	Window   *ExponentialWindow
+	Rate     float64
+	MinCalls int64
+}
+
+// - is a constructor
+func NewWindowRateTrip(window *ExponentialWindow, rate float64, minCalls int64) *WindowRateTrip {
+	return &WindowRateTrip{Window: window, Rate: rate, MinCalls: minCalls}
+}
+
+// - records the result of stats and checks the failure rate of the window
+func (t *WindowRateTrip) ShouldTrip(stats Stats) (bool, string) {
+	t.Window.RecordAt(stats.Time, stats.Success)
+
+	counts := t.Window.CountsAt(stats.Time)
+	if counts.Total == 0 || counts.Total < t.MinCalls {
+		return false, ""
+	}
+	rate := float64(counts.Failures) / float64(counts.Total)
+	if rate < t.Rate {
+		return false, ""
+	}
+	return true, fmt.Sprintf("failure rate %.1f%% over %s", rate*100, t.Window.window)
+}
+
+// - drops the results of the window
+func (t *WindowRateTrip) Reset() {
+	t.Window.Reset()
+}
+
+// - describes the results within the window
+func (t *WindowRateTrip) DebugWindow() string {
+	counts := t.Window.Counts()
+	return fmt.Sprintf("%d/%d failed over %s in %d buckets", counts.Failures, counts.Total, t.Window.window, t.Window.Buckets())
+}
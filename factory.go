@@ -0,0 +1,68 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"slices"
+)
+
+// - creates the breaker named name, dependency injection containers (wire, fx) provide
+// a Factory instead of concrete breakers, so the backend (local, distributed, noop)
+// is chosen in one place and the consumers only depend on Breaker
+type Factory func(name string) (Breaker, error)
+
+// - returns a Factory of local breakers built by preset with the name and opts applied,
+// the breakers are registered in registry and a name asked for twice gets the same breaker
+func LocalFactory(registry *Registry, preset Preset, opts ...Option) Factory {
+	return func(name string) (Breaker, error) {
+		if cb, ok := registry.Get(name); ok {
+			return cb, nil
+		}
+
+		// clipped, concurrent calls must not append into the backing array of opts
+		cb := preset.New(append(slices.Clip(opts), WithName(name))...)
+		if err := registry.Register(cb); err != nil {
+			// registered concurrently under the same name
+			if existing, ok := registry.Get(name); ok {
+				return existing, nil
+			}
+			return nil, err
+		}
+		return cb, nil
+	}
+}
+
+// - returns a Factory of NoopBreaker, to disable circuit breaking by configuration or in tests
+func NoopFactory() Factory {
+	return func(string) (Breaker, error) {
+		return NoopBreaker{}, nil
+	}
+}
+
+// - is a Breaker admitting every call and ignoring the results, it is always closed
+type NoopBreaker struct{}
+
+var _ Breaker = NoopBreaker{}
+
+func (NoopBreaker) Allow() bool {
+	return true
+}
+
+func (NoopBreaker) RecordSuccess() {}
+
+func (NoopBreaker) RecordFailure() {}
+
+func (NoopBreaker) State() string {
+	return StateClosed
+}
+
+// - returns a Factory of the breakers of registry, unknown names get ErrNotFound,
+// for breakers created up front, e.g. with NewRegistryFromConfig
+func RegistryFactory(registry *Registry) Factory {
+	return func(name string) (Breaker, error) {
+		cb, ok := registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+		}
+		return cb, nil
+	}
+}
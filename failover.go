@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const (
+	FailoverPrimary   = "primary"
+	FailoverSecondary = "secondary"
+)
+
+// - routes calls to the primary function guarded by the breaker and to the secondary
+// while the primary circuit is open, calls return to the primary as soon as the breaker
+// admits them again, every switchover is reported as EventFailover
+type Failover[T any] struct {
+	cb        *CircuitBreaker
+	primary   func(ctx context.Context) (T, error)
+	secondary func(ctx context.Context) (T, error)
+
+	mu       sync.Mutex
+	active   string
+	handlers []EventHandler
+}
+
+// - is a constructor
+func NewFailover[T any](
+	cb *CircuitBreaker,
+	primary func(ctx context.Context) (T, error),
+	secondary func(ctx context.Context) (T, error),
+) *Failover[T] {
+	return &Failover[T]{
+		cb:        cb,
+		primary:   primary,
+		secondary: secondary,
+		active:    FailoverPrimary,
+	}
+}
+
+// - registers handler for switchover events, must be called before Execute
+func (f *Failover[T]) OnSwitchover(handler EventHandler) {
+	f.handlers = append(f.handlers, handler)
+}
+
+// - returns FailoverPrimary or FailoverSecondary, the target of the last call
+func (f *Failover[T]) Active() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.active
+}
+
+// - runs the primary through the breaker, the secondary when the primary circuit is open
+func (f *Failover[T]) Execute(ctx context.Context) (T, error) {
+	result, err := ExecuteContext(ctx, f.cb, f.primary)
+	if !errors.Is(err, ErrOpenState) {
+		f.switchTo(FailoverPrimary)
+		return result, err
+	}
+
+	f.switchTo(FailoverSecondary)
+	return f.secondary(ctx)
+}
+
+// switchTo records the active target and emits EventFailover when it changed
+func (f *Failover[T]) switchTo(target string) {
+	f.mu.Lock()
+	from := f.active
+	f.active = target
+	f.mu.Unlock()
+
+	if from == target {
+		return
+	}
+
+	reason := "primary circuit is open"
+	if target == FailoverPrimary {
+		reason = "primary circuit admits calls again"
+	}
+	event := Event{
+		Breaker: f.cb.name,
+		Type:    EventFailover,
+		From:    from,
+		To:      target,
+		Time:    f.cb.clock.Now(),
+		Reason:  reason,
+	}
+	for _, handler := range f.handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,70 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// number of distinct errors kept for RecentErrors
+const errorHistory = 16
+
+// - is a distinct error of the failed calls, errors of the same type and message
+// are counted together
+type ErrorSample struct {
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+	Count   int64     `json:"count"`
+	First   time.Time `json:"first"`
+	Last    time.Time `json:"last"`
+	// state the error was last recorded in
+	State string `json:"state"`
+}
+
+// - records a failed call like RecordFailure and keeps err in the recent errors,
+// so operators can see why the circuit opened, see RecentErrors
+func (cb *CircuitBreaker) RecordFailureErr(err error) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	now := cb.clock.Now()
+	cb.keepError(now, err)
+	cb.recordFailure(now)
+}
+
+// - returns the distinct errors of the calls failed with RecordFailureErr, Execute or
+// StreamGuard, most recently seen first, the least recently seen ones are dropped
+// beyond 16 distinct errors
+func (cb *CircuitBreaker) RecentErrors() []ErrorSample {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	samples := slices.Clone(cb.recentErrors)
+	slices.Reverse(samples)
+	return samples
+}
+
+// keepError counts err in the recent errors, kept ordered by the last time seen,
+// nil errors are not kept, must be called under write lock
+func (cb *CircuitBreaker) keepError(now time.Time, err error) {
+	if err == nil {
+		return
+	}
+
+	kind, message := fmt.Sprintf("%T", err), err.Error()
+	sample := ErrorSample{Type: kind, Message: message, First: now}
+	i := slices.IndexFunc(cb.recentErrors, func(s ErrorSample) bool {
+		return s.Type == kind && s.Message == message
+	})
+	if i >= 0 {
+		sample = cb.recentErrors[i]
+		cb.recentErrors = slices.Delete(cb.recentErrors, i, i+1)
+	} else if len(cb.recentErrors) == errorHistory {
+		cb.recentErrors = slices.Delete(cb.recentErrors, 0, 1)
+	}
+
+	sample.Count++
+	sample.Last = now
+	sample.State = cb.state
+	cb.recentErrors = append(cb.recentErrors, sample)
+}
@@ -0,0 +1,44 @@
+package circuitbreaker
+
+import "context"
+
+// - produces the result of a call that failed or was rejected, err is the original error,
+// returning a non-nil error passes the failure on to the caller
+type Fallback[T any] func(err error) (T, error)
+
+// - is a Fallback returning the static default v
+func FallbackValue[T any](v T) Fallback[T] {
+	return func(error) (T, error) {
+		return v, nil
+	}
+}
+
+// - is a Fallback computing the default from the error
+func FallbackFunc[T any](fn func(err error) T) Fallback[T] {
+	return func(err error) (T, error) {
+		return fn(err), nil
+	}
+}
+
+// - is Execute returning the fallback result when fn fails or the call is rejected
+func ExecuteFallback[T any](cb *CircuitBreaker, fn func() (T, error), fallback Fallback[T]) (T, error) {
+	result, err := Execute(cb, fn)
+	if err != nil {
+		return fallback(err)
+	}
+	return result, nil
+}
+
+// - is ExecuteContext returning the fallback result when fn fails or the call is rejected
+func ExecuteContextFallback[T any](
+	ctx context.Context,
+	cb *CircuitBreaker,
+	fn func(ctx context.Context) (T, error),
+	fallback Fallback[T],
+) (T, error) {
+	result, err := ExecuteContext(ctx, cb, fn)
+	if err != nil {
+		return fallback(err)
+	}
+	return result, nil
+}
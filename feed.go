@@ -0,0 +1,25 @@
+package circuitbreaker
+
+import "time"
+
+// - is a call result aggregated outside the breaker, see Feed
+type Outcome struct {
+	// when the call finished, zero means when the outcome is consumed
+	Time    time.Time
+	Success bool
+	// zero means the latency is unknown and is not recorded
+	Latency time.Duration
+	Tags    Tags
+}
+
+// - drives the breaker by outcomes received from ch until it is closed, for systems
+// that aggregate call results elsewhere (sidecars, log pipelines) and do not run the
+// calls through Execute, outcomes should arrive in time order
+func (cb *CircuitBreaker) Feed(ch <-chan Outcome) {
+	for outcome := range ch {
+		if outcome.Latency > 0 {
+			cb.recordLatencyTagged(outcome.Latency, outcome.Tags)
+		}
+		cb.recordTaggedAt(outcome.Time, outcome.Success, nil, outcome.Tags)
+	}
+}
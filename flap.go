@@ -0,0 +1,103 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+)
+
+// flapDetector counts transitions within a window, see WithFlapDetection
+type flapDetector struct {
+	transitions int
+	window      time.Duration
+	factor      float64
+
+	times   []time.Time
+	damped  bool
+	started time.Time
+}
+
+// - detects flapping like Nagios: when the breaker changes state at least transitions
+// times within window it enters the damped mode, open timeouts are multiplied by factor
+// and EventFlapping is emitted, the mode ends with EventFlappingEnd when fewer than half
+// of the transitions remain within the window
+func WithFlapDetection(transitions int, window time.Duration, factor float64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.flap = &flapDetector{
+			transitions: max(transitions, 2),
+			window:      window,
+			factor:      max(factor, 1),
+		}
+	}
+}
+
+// - reports whether the breaker is in the damped mode of WithFlapDetection, the mode
+// ends when the transitions age out of the window even if the breaker settled
+func (cb *CircuitBreaker) Flapping() bool {
+	cb.mu.RLock()
+	damped := cb.flap != nil && cb.flap.damped
+	cb.mu.RUnlock()
+	if !damped {
+		return false
+	}
+
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if end := cb.updateFlapping(cb.clock.Now()); end != nil {
+		cb.queueEvent(*end)
+	}
+	return cb.flap.damped
+}
+
+// trackFlapping records a transition at now and returns the event of a damped mode
+// change, must be called under write lock
+func (cb *CircuitBreaker) trackFlapping(now time.Time) *Event {
+	if cb.flap == nil {
+		return nil
+	}
+	cb.flap.times = append(cb.flap.times, now)
+	return cb.updateFlapping(now)
+}
+
+// updateFlapping drops the transitions out of the window at now and returns the event
+// of a damped mode change, must be called under write lock
+func (cb *CircuitBreaker) updateFlapping(now time.Time) *Event {
+	f := cb.flap
+	kept := f.times[:0]
+	for _, t := range f.times {
+		if d, skewed := elapsed(now, t); skewed || d < f.window {
+			kept = append(kept, t)
+		}
+	}
+	f.times = kept
+
+	switch n := len(f.times); {
+	case !f.damped && n >= f.transitions:
+		f.damped, f.started = true, now
+		return &Event{
+			Type:   EventFlapping,
+			From:   cb.state,
+			To:     cb.state,
+			Time:   now,
+			Reason: fmt.Sprintf("%d transitions within %s, open timeout multiplied by %g", n, f.window, f.factor),
+		}
+	case f.damped && n < (f.transitions+1)/2:
+		f.damped = false
+		return &Event{
+			Type:   EventFlappingEnd,
+			From:   cb.state,
+			To:     cb.state,
+			Time:   now,
+			Reason: fmt.Sprintf("flapping for %s, %d transitions within %s", now.Sub(f.started), n, f.window),
+		}
+	}
+	return nil
+}
+
+// dampedTimeout applies the flapping factor to timeout, must be called under lock
+func (cb *CircuitBreaker) dampedTimeout(timeout time.Duration) time.Duration {
+	if cb.flap == nil || !cb.flap.damped {
+		return timeout
+	}
+	return time.Duration(float64(timeout) * cb.flap.factor)
+}
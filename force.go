@@ -0,0 +1,110 @@
+package circuitbreaker
+
+import "time"
+
+// - returns the breaker name
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// - keeps the breaker open until ForceClose or Reset, results are ignored meanwhile
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.ForceOpenBy("")
+}
+
+// - is ForceOpen recording actor, e.g. the user or automation forcing the state,
+// in the override event and the transition
+func (cb *CircuitBreaker) ForceOpenBy(actor string) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.setOverride(StateOpened, "forced open", actor)
+}
+
+// - keeps the breaker closed until ForceOpen or Reset, results are ignored meanwhile
+func (cb *CircuitBreaker) ForceClose() {
+	cb.ForceCloseBy("")
+}
+
+// - is ForceClose recording actor, see ForceOpenBy
+func (cb *CircuitBreaker) ForceCloseBy(actor string) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.setOverride(StateClosed, "forced closed", actor)
+}
+
+// - clears manual override and returns the breaker to closed state
+// with empty counters, warmup is restarted
+func (cb *CircuitBreaker) Reset() {
+	cb.ResetBy("")
+}
+
+// - is Reset recording actor, see ForceOpenBy
+func (cb *CircuitBreaker) ResetBy(actor string) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.override != "" {
+		cb.setOverride("", "reset", actor)
+	}
+
+	if cb.state != StateClosed {
+		cb.setStateBy(StateClosed, "reset", actor)
+	} else {
+		cb.resetCounters()
+	}
+
+	cb.warmupStart = cb.clock.Now()
+	cb.recordedCalls = 0
+}
+
+// - reports whether the state is forced manually
+func (cb *CircuitBreaker) Forced() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.override != ""
+}
+
+// setOverride queues the override event, a change of the effective state by an actor
+// is kept as the last transition, must be called under write lock
+func (cb *CircuitBreaker) setOverride(state, reason, actor string) {
+	from := cb.override
+	if from == "" {
+		from = cb.state
+	}
+	to := state
+	if to == "" {
+		to = cb.state
+	}
+
+	now := cb.clock.Now()
+	cb.override = state
+	if actor != "" && from != to {
+		cb.lastTransition = TransitionInfo{
+			From:   from,
+			To:     to,
+			At:     now,
+			Reason: reason,
+			Counts: cb.counts(),
+			Actor:  actor,
+		}
+		cb.recordHistory(cb.lastTransition)
+	}
+	cb.queueEvent(Event{
+		Type:   EventOverride,
+		From:   from,
+		To:     to,
+		Time:   now,
+		Reason: reason,
+		Actor:  actor,
+	})
+}
+
+// forcedState returns the state forced manually or by an active maintenance window
+func (cb *CircuitBreaker) forcedState(now time.Time) (string, bool) {
+	if cb.override != "" {
+		return cb.override, true
+	}
+	return cb.maintenanceState(now)
+}
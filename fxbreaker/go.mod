@@ -0,0 +1,17 @@
+module github.com/nick1jesky/circuit_breaker/fxbreaker
+
+go 1.24.2
+
+require (
+	github.com/nick1jesky/circuit_breaker v0.0.0
+	go.uber.org/fx v1.24.0
+)
+
+require (
+	go.uber.org/dig v1.19.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	go.uber.org/zap v1.26.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
+
+replace github.com/nick1jesky/circuit_breaker => ../
@@ -0,0 +1,83 @@
+// Package fxbreaker provides circuit breakers to go.uber.org/fx applications: a registry
+// built from config, the admin handler and draining of the breakers on shutdown.
+//
+//	fx.New(
+//		fx.Supply(configs), // map[string]circuitbreaker.Config, e.g. from LoadConfigFile
+//		fxbreaker.Module,
+//		fx.Invoke(func(breakers circuitbreaker.Factory) { ... }),
+//	)
+package fxbreaker
+
+import (
+	"net/http"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.uber.org/fx"
+)
+
+// - is the path prefix the admin handler is mounted under on the *http.ServeMux of the application
+const AdminPrefix = "/circuitbreakers"
+
+// - is the admin API of the registry, see circuitbreaker.NewAdminHandler
+type AdminHandler http.Handler
+
+// - provides the *circuitbreaker.Registry built from the map[string]circuitbreaker.Config
+// of the application, its AdminHandler and a circuitbreaker.Factory of its breakers,
+// mounts the admin handler under AdminPrefix when the application provides an *http.ServeMux
+// and drains the registry when the application stops
+var Module = fx.Module("circuitbreaker",
+	fx.Provide(NewRegistry, NewAdminHandler, circuitbreaker.RegistryFactory),
+	fx.Invoke(MountAdmin),
+)
+
+// - are the dependencies of NewRegistry
+type RegistryParams struct {
+	fx.In
+
+	Configs   map[string]circuitbreaker.Config
+	Options   []circuitbreaker.Option `optional:"true"`
+	Lifecycle fx.Lifecycle
+}
+
+// - creates the registry with circuitbreaker.NewRegistryFromConfig, the options are applied
+// to every breaker, the registry is drained in the stop hook, bounded by the stop timeout
+// of the application
+func NewRegistry(p RegistryParams) (*circuitbreaker.Registry, error) {
+	registry, err := circuitbreaker.NewRegistryFromConfig(p.Configs, p.Options...)
+	if err != nil {
+		return nil, err
+	}
+	p.Lifecycle.Append(fx.StopHook(registry.Drain))
+	return registry, nil
+}
+
+// - are the dependencies of NewAdminHandler
+type AdminParams struct {
+	fx.In
+
+	Registry *circuitbreaker.Registry
+	// authenticates the admin actions, every action is rejected when the application
+	// provides none
+	Identify circuitbreaker.Identify `optional:"true"`
+}
+
+// - returns the admin API of the registry
+func NewAdminHandler(p AdminParams) AdminHandler {
+	return circuitbreaker.NewAdminHandler(p.Registry, p.Identify)
+}
+
+// - are the dependencies of MountAdmin
+type MountParams struct {
+	fx.In
+
+	Mux     *http.ServeMux `optional:"true"`
+	Handler AdminHandler
+}
+
+// - mounts the admin handler under AdminPrefix, nothing is mounted without a mux
+func MountAdmin(p MountParams) {
+	if p.Mux == nil {
+		return
+	}
+	p.Mux.Handle(AdminPrefix+"/", http.StripPrefix(AdminPrefix, p.Handler))
+}
@@ -0,0 +1,61 @@
+package fxbreaker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestModule(t *testing.T) {
+	configs := map[string]circuitbreaker.Config{
+		"payments": {
+			Failure:     circuitbreaker.ThresholdSpec{Type: "int64", Params: map[string]any{"value": 3.0}},
+			Success:     circuitbreaker.ThresholdSpec{Type: "int64", Params: map[string]any{"value": 1.0}},
+			OpenTimeout: circuitbreaker.Duration(time.Minute),
+		},
+	}
+
+	var (
+		mux     *http.ServeMux
+		factory circuitbreaker.Factory
+	)
+	app := fxtest.New(t,
+		fx.Supply(configs),
+		fx.Provide(http.NewServeMux),
+		Module,
+		fx.Populate(&mux, &factory),
+	)
+	app.RequireStart()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, AdminPrefix+"/breakers/payments", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "payments") {
+		t.Errorf("Expected the admin handler to be mounted, got %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, AdminPrefix+"/breakers/payments/force-open", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the admin actions to be rejected without Identify, got %d", rec.Code)
+	}
+
+	payments, err := factory("payments")
+	if err != nil {
+		t.Fatalf("Expected the configured breaker, got %v", err)
+	}
+	if err := circuitbreaker.Do(payments, func() error { return nil }); err != nil {
+		t.Errorf("Expected the call to pass, got %v", err)
+	}
+
+	app.RequireStop()
+	cb := payments.(*circuitbreaker.CircuitBreaker)
+	if err := cb.ExecuteContext(context.Background(), func(context.Context) error { return nil }); err == nil {
+		t.Error("Expected the breaker to be drained when the application stopped")
+	}
+}
@@ -0,0 +1,42 @@
+package circuitbreaker
+
+import "time"
+
+// - delays the trip: once the trip condition is met the circuit keeps admitting calls
+// for d or the next calls results, whichever ends first when both are set, and opens
+// only when the condition still holds at the end, a result clearing the condition
+// cancels the grace period, so momentary blips do not open the circuit
+func WithTripGrace(d time.Duration, calls int64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.graceTime = d
+		cb.graceCalls = calls
+	}
+}
+
+// inGrace reports whether the trip decided at now is held back by the grace period,
+// must be called under write lock
+func (cb *CircuitBreaker) inGrace(now time.Time) bool {
+	if cb.graceTime <= 0 && cb.graceCalls <= 0 {
+		return false
+	}
+
+	if cb.graceStart.IsZero() {
+		cb.graceStart = now
+		cb.graceSeen = 0
+		return true
+	}
+
+	cb.graceSeen++
+	if cb.graceCalls > 0 && cb.graceSeen >= cb.graceCalls {
+		return false
+	}
+	spent, skewed := elapsed(now, cb.graceStart)
+	return cb.graceTime <= 0 || skewed || spent < cb.graceTime
+}
+
+// cancelGrace ends the grace period when the trip condition no longer holds,
+// must be called under write lock
+func (cb *CircuitBreaker) cancelGrace() {
+	cb.graceStart = time.Time{}
+	cb.graceSeen = 0
+}
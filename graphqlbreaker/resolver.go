@@ -0,0 +1,84 @@
+// Package graphqlbreaker protects GraphQL field resolvers with circuit breakers keyed by resolver name.
+package graphqlbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is the extensions code of errors for fields whose circuit is open
+const CodeCircuitOpen = "CIRCUIT_OPEN"
+
+// - adds err to the errors of the response without failing the field,
+// e.g. graphql.AddError of gqlgen
+type ErrorReporter func(ctx context.Context, err error)
+
+// - is the error of a field whose resolver was not called because its circuit is open
+type FieldError struct {
+	Resolver string
+	Err      error
+}
+
+// - implements error
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("resolver %q: %v", e.Resolver, e.Err)
+}
+
+// - returns the breaker error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// - returns the GraphQL error extensions, recognized by gqlgen and graph-gophers
+func (e *FieldError) Extensions() map[string]any {
+	return map[string]any{
+		"code":     CodeCircuitOpen,
+		"resolver": e.Resolver,
+	}
+}
+
+// - holds the breakers of field resolvers, keyed by resolver name like "Query.orders"
+type Resolvers struct {
+	breakers *circuitbreaker.KeyedBreaker
+	report   ErrorReporter
+}
+
+// - is a constructor, with a nil report the error of an open circuit is returned
+// by the resolver, which nulls the field, otherwise it is reported and the fallback
+// value is returned so the rest of the query is answered
+func NewResolvers(breakers *circuitbreaker.KeyedBreaker, report ErrorReporter) *Resolvers {
+	return &Resolvers{breakers: breakers, report: report}
+}
+
+// - returns the breaker of the resolver
+func (r *Resolvers) Breaker(name string) *circuitbreaker.CircuitBreaker {
+	return r.breakers.Get(name)
+}
+
+// - runs the named resolver through its breaker, when the circuit is open the
+// resolver is skipped and fallback is returned as a partial result, see NewResolvers
+func Resolve[T any](ctx context.Context, r *Resolvers, name string, fallback T, resolve func(ctx context.Context) (T, error)) (T, error) {
+	ctx = circuitbreaker.WithTags(ctx, circuitbreaker.Tags{circuitbreaker.TagOperation: name})
+	result, err := circuitbreaker.ExecuteContext(ctx, r.Breaker(name), resolve)
+	if !errors.Is(err, circuitbreaker.ErrOpenState) {
+		return result, err
+	}
+
+	fieldErr := &FieldError{Resolver: name, Err: err}
+	if r.report == nil {
+		var zero T
+		return zero, fieldErr
+	}
+	r.report(ctx, fieldErr)
+	return fallback, nil
+}
+
+// - returns resolve guarded by Resolve, for resolvers registered as functions
+func Wrap[T any](r *Resolvers, name string, fallback T, resolve func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		return Resolve(ctx, r, name, fallback, resolve)
+	}
+}
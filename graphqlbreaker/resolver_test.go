@@ -0,0 +1,64 @@
+package graphqlbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+func newBreakers() *circuitbreaker.KeyedBreaker {
+	return circuitbreaker.NewKeyedBreaker(func(key string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(1),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+			circuitbreaker.WithName(key),
+		)
+	})
+}
+
+func TestOpenCircuitReturnsPartialResult(t *testing.T) {
+	var reported []error
+	resolvers := NewResolvers(newBreakers(), func(_ context.Context, err error) {
+		reported = append(reported, err)
+	})
+	ctx := context.Background()
+
+	recommendations := Wrap(resolvers, "Query.recommendations", []string{}, func(context.Context) ([]string, error) {
+		return nil, errors.New("recommender unavailable")
+	})
+	if _, err := recommendations(ctx); err == nil {
+		t.Fatal("Expected resolver error")
+	}
+
+	result, err := recommendations(ctx)
+	if err != nil || result == nil || len(result) != 0 {
+		t.Fatalf("Expected fallback value for open circuit, got %v, %v", result, err)
+	}
+	var fieldErr *FieldError
+	if len(reported) != 1 || !errors.As(reported[0], &fieldErr) || fieldErr.Extensions()["code"] != CodeCircuitOpen {
+		t.Errorf("Expected reported circuit open error, got %v", reported)
+	}
+
+	user, err := Resolve(ctx, resolvers, "Query.user", "", func(context.Context) (string, error) {
+		return "alice", nil
+	})
+	if err != nil || user != "alice" {
+		t.Errorf("Expected other resolvers to be served, got %q, %v", user, err)
+	}
+}
+
+func TestOpenCircuitWithoutReporter(t *testing.T) {
+	resolvers := NewResolvers(newBreakers(), nil)
+	resolvers.Breaker("Query.orders").RecordFailure()
+
+	_, err := Resolve(context.Background(), resolvers, "Query.orders", 0, func(context.Context) (int, error) {
+		return 1, nil
+	})
+	if !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Errorf("Expected %v, got %v", circuitbreaker.ErrOpenState, err)
+	}
+}
@@ -0,0 +1,100 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// - is the combined health of a Group
+type HealthStatus string
+
+const (
+	HealthUp       HealthStatus = "up"
+	HealthDegraded HealthStatus = "degraded"
+	HealthDown     HealthStatus = "down"
+)
+
+// - is a snapshot of the combined health of a Group
+type GroupHealth struct {
+	Status HealthStatus `json:"status"`
+	// fraction of members that are not open, 1 when the group is empty
+	Score float64 `json:"score"`
+	// names of open members
+	Open []string `json:"open,omitempty"`
+}
+
+// - aggregates the breakers of a dependency group: the group is degraded if any critical
+// member is open and down if more than the down ratio of members are open
+type Group struct {
+	downRatio float64
+
+	mu      sync.RWMutex
+	members []groupMember
+}
+
+type groupMember struct {
+	cb       *CircuitBreaker
+	critical bool
+}
+
+// - is a constructor, downRatio <= 0 means 0.5
+func NewGroup(downRatio float64) *Group {
+	if downRatio <= 0 {
+		downRatio = 0.5
+	}
+	return &Group{downRatio: downRatio}
+}
+
+// - adds the breaker to the group
+func (g *Group) Add(cb *CircuitBreaker, critical bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.members = append(g.members, groupMember{cb: cb, critical: critical})
+}
+
+// - returns the combined health of the members
+func (g *Group) Health() GroupHealth {
+	g.mu.RLock()
+	members := append([]groupMember(nil), g.members...)
+	g.mu.RUnlock()
+
+	health := GroupHealth{Status: HealthUp, Score: 1}
+	if len(members) == 0 {
+		return health
+	}
+
+	criticalOpen := false
+	for _, member := range members {
+		if member.cb.State() != StateOpened {
+			continue
+		}
+		health.Open = append(health.Open, member.cb.Name())
+		criticalOpen = criticalOpen || member.critical
+	}
+
+	openRatio := float64(len(health.Open)) / float64(len(members))
+	health.Score = 1 - openRatio
+
+	switch {
+	case openRatio > g.downRatio:
+		health.Status = HealthDown
+	case criticalOpen:
+		health.Status = HealthDegraded
+	}
+	return health
+}
+
+// - reports an error wrapping ErrOpenState when the group is down, for readiness probes
+func (g *Group) Check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	health := g.Health()
+	if health.Status == HealthDown {
+		return fmt.Errorf("dependency group is down, open %v: %w", health.Open, ErrOpenState)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package grpcbreaker
+
+import (
+	"context"
+	"path"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"google.golang.org/grpc"
+)
+
+// - configures the client interceptors
+type ClientOptions struct {
+	// KeyByFullMethod when nil, so one broken RPC does not open the circuit
+	// for every method of the connection, KeyByService shares a circuit per service
+	Key MethodKeyFunc
+	// path.Match patterns of full method names guarded by the breaker, e.g.
+	// "/shop.v1.Orders/*", empty means every method
+	Include []string
+	// patterns of methods called without the breaker, e.g. "/grpc.health.v1.Health/*",
+	// they take precedence over Include
+	Exclude []string
+	// ServerErrors when nil
+	IsFailure Classifier
+}
+
+// - applies keyed breakers to unary calls, calls rejected by an open circuit fail with
+// an error wrapping ErrOpenState without reaching the server
+func UnaryClientInterceptor(breakers *circuitbreaker.KeyedBreaker, opts ClientOptions) grpc.UnaryClientInterceptor {
+	isFailure := orServerErrors(opts.IsFailure)
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if !opts.guarded(method) {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		}
+		return guardClient(ctx, breakers.Get(opts.key(method)), isFailure, func(ctx context.Context) error {
+			return invoker(ctx, method, req, reply, cc, callOpts...)
+		})
+	}
+}
+
+// - is UnaryClientInterceptor for streams, only establishing the stream is guarded
+func StreamClientInterceptor(breakers *circuitbreaker.KeyedBreaker, opts ClientOptions) grpc.StreamClientInterceptor {
+	isFailure := orServerErrors(opts.IsFailure)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !opts.guarded(method) {
+			return streamer(ctx, desc, cc, method, callOpts...)
+		}
+		var stream grpc.ClientStream
+		err := guardClient(ctx, breakers.Get(opts.key(method)), isFailure, func(ctx context.Context) error {
+			var err error
+			stream, err = streamer(ctx, desc, cc, method, callOpts...)
+			return err
+		})
+		return stream, err
+	}
+}
+
+// guardClient runs call through the breaker, rejections are returned as is
+func guardClient(ctx context.Context, cb *circuitbreaker.CircuitBreaker, isFailure Classifier, call func(ctx context.Context) error) error {
+	err, _ := run(ctx, cb, isFailure, call)
+	return err
+}
+
+// key returns the breaker key of method
+func (o ClientOptions) key(method string) string {
+	if o.Key == nil {
+		return KeyByFullMethod(method)
+	}
+	return o.Key(method)
+}
+
+// guarded applies the include and exclude patterns to method
+func (o ClientOptions) guarded(method string) bool {
+	if matchAny(o.Exclude, method) {
+		return false
+	}
+	return len(o.Include) == 0 || matchAny(o.Include, method)
+}
+
+// matchAny reports whether method matches any of patterns
+func matchAny(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// Package grpcbreaker integrates circuit breakers with gRPC.
+package grpcbreaker
+
+import (
+	"context"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// - is satisfied by *health.Server from google.golang.org/grpc/health
+type StatusSetter interface {
+	SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus)
+}
+
+// - updates the serving status of a grpc.health.v1 service from a health checker,
+// typically a Registry whose critical breakers decide readiness
+type HealthReporter struct {
+	server  StatusSetter
+	service string
+	checker circuitbreaker.HealthChecker
+}
+
+// - is a constructor, empty service means the overall server status
+func NewHealthReporter(server StatusSetter, service string, checker circuitbreaker.HealthChecker) *HealthReporter {
+	return &HealthReporter{server: server, service: service, checker: checker}
+}
+
+// - sets NOT_SERVING while the checker fails and SERVING otherwise, returns the check error
+func (r *HealthReporter) Update(ctx context.Context) error {
+	err := r.checker.Check(ctx)
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	r.server.SetServingStatus(r.service, status)
+
+	return err
+}
+
+// - updates the status every interval until ctx is done
+func (r *HealthReporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = r.Update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package grpcbreaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthReporter(t *testing.T) {
+	registry := circuitbreaker.NewRegistry()
+	cb := circuitbreaker.NewCircuitBreaker(
+		circuitbreaker.NewInt64Threshold(1),
+		circuitbreaker.NewInt64Threshold(1),
+		time.Second,
+		circuitbreaker.WithName("db"),
+	)
+	_ = registry.Register(cb)
+	registry.SetCritical("db", true)
+
+	server := health.NewServer()
+	reporter := NewHealthReporter(server, "orders", registry)
+
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "orders"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return resp.Status
+	}
+
+	if err := reporter.Update(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if s := status(); s != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected SERVING, got %s", s)
+	}
+
+	cb.RecordFailure()
+	if err := reporter.Update(context.Background()); err == nil {
+		t.Error("Expected error while critical breaker is open")
+	}
+	if s := status(); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING, got %s", s)
+	}
+}
@@ -0,0 +1,16 @@
+package grpcbreaker
+
+import "github.com/nick1jesky/circuit_breaker/keys"
+
+// - maps a gRPC full method name to a breaker key
+type MethodKeyFunc func(fullMethod string) string
+
+// - keys by full method name, one circuit per method
+func KeyByFullMethod(fullMethod string) string {
+	return fullMethod
+}
+
+// - keys by service name, one circuit for all methods of a service
+func KeyByService(fullMethod string) string {
+	return keys.GRPCService(fullMethod)
+}
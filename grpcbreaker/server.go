@@ -0,0 +1,88 @@
+package grpcbreaker
+
+import (
+	"context"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// - reports whether a handler error counts as a breaker failure
+type Classifier func(err error) bool
+
+// - counts errors with codes Unknown, DeadlineExceeded, ResourceExhausted, Internal
+// and Unavailable as failures, client errors such as InvalidArgument or NotFound do not
+func ServerErrors(err error) bool {
+	switch status.Code(err) {
+	case codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// - applies a breaker per full method name to unary handlers, calls rejected by an open
+// circuit fail with RESOURCE_EXHAUSTED without running the handler, nil isFailure means ServerErrors
+func UnaryServerInterceptor(breakers *circuitbreaker.KeyedBreaker, isFailure Classifier) grpc.UnaryServerInterceptor {
+	isFailure = orServerErrors(isFailure)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := guard(ctx, breakers.Get(info.FullMethod), isFailure, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+// - is UnaryServerInterceptor for streaming handlers, the whole stream counts as one call
+func StreamServerInterceptor(breakers *circuitbreaker.KeyedBreaker, isFailure Classifier) grpc.StreamServerInterceptor {
+	isFailure = orServerErrors(isFailure)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return guard(ss.Context(), breakers.Get(info.FullMethod), isFailure, func(context.Context) error {
+			return handler(srv, ss)
+		})
+	}
+}
+
+// guard runs call through the breaker, calls rejected by an open circuit fail with RESOURCE_EXHAUSTED
+func guard(ctx context.Context, cb *circuitbreaker.CircuitBreaker, isFailure Classifier, call func(ctx context.Context) error) error {
+	err, rejected := run(ctx, cb, isFailure, call)
+	if rejected {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
+}
+
+// run runs call through the breaker, errors isFailure rejects are returned
+// to the caller but recorded as successes, rejected reports that call was not run
+func run(ctx context.Context, cb *circuitbreaker.CircuitBreaker, isFailure Classifier, call func(ctx context.Context) error) (err error, rejected bool) {
+	var (
+		called  bool
+		callErr error
+	)
+	err = cb.ExecuteContext(ctx, func(ctx context.Context) error {
+		called = true
+		callErr = call(ctx)
+		if callErr != nil && isFailure(callErr) {
+			return callErr
+		}
+		return nil
+	})
+	if !called {
+		return err, true
+	}
+	return callErr, false
+}
+
+func orServerErrors(isFailure Classifier) Classifier {
+	if isFailure == nil {
+		return ServerErrors
+	}
+	return isFailure
+}
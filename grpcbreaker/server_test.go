@@ -0,0 +1,97 @@
+package grpcbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	breakers := circuitbreaker.NewKeyedBreaker(func(string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(2),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+		)
+	})
+	interceptor := UnaryServerInterceptor(breakers, nil)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/payments.Payments/Charge"}
+	calls := 0
+	handler := func(_ context.Context, req any) (any, error) {
+		calls++
+		if req == "invalid" {
+			return nil, status.Error(codes.InvalidArgument, "invalid amount")
+		}
+		return nil, status.Error(codes.Unavailable, "ledger unavailable")
+	}
+
+	for range 3 {
+		_, _ = interceptor(context.Background(), "invalid", info, handler)
+	}
+	if state := breakers.Get(info.FullMethod).State(); state != circuitbreaker.StateClosed {
+		t.Errorf("Expected client errors not to open the circuit, got %s", state)
+	}
+
+	for range 2 {
+		_, _ = interceptor(context.Background(), "charge", info, handler)
+	}
+
+	calls = 0
+	_, err := interceptor(context.Background(), "charge", info, handler)
+	if status.Code(err) != codes.ResourceExhausted || calls != 0 {
+		t.Errorf("Expected RESOURCE_EXHAUSTED without calling the handler, got %v and %d calls", err, calls)
+	}
+
+	other := &grpc.UnaryServerInfo{FullMethod: "/payments.Payments/Refund"}
+	if _, err := interceptor(context.Background(), "invalid", other, handler); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected other methods to keep their own breaker, got %v", err)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	breakers := circuitbreaker.NewKeyedBreaker(func(string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(2),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+		)
+	})
+	interceptor := UnaryClientInterceptor(breakers, ClientOptions{
+		Exclude: []string{"/grpc.health.v1.Health/*"},
+	})
+
+	calls := map[string]int{}
+	invoker := func(_ context.Context, method string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		calls[method]++
+		if method == "/shop.v1.Orders/Create" || method == "/grpc.health.v1.Health/Check" {
+			return status.Error(codes.Unavailable, "orders unavailable")
+		}
+		return nil
+	}
+
+	for range 2 {
+		_ = interceptor(context.Background(), "/shop.v1.Orders/Create", nil, nil, nil, invoker)
+	}
+	err := interceptor(context.Background(), "/shop.v1.Orders/Create", nil, nil, nil, invoker)
+	if !errors.Is(err, circuitbreaker.ErrOpenState) || calls["/shop.v1.Orders/Create"] != 2 {
+		t.Errorf("Expected ErrOpenState without invoking, got %v and %d calls", err, calls["/shop.v1.Orders/Create"])
+	}
+
+	if err := interceptor(context.Background(), "/shop.v1.Orders/Get", nil, nil, nil, invoker); err != nil {
+		t.Errorf("Expected other methods of the service to keep working, got %v", err)
+	}
+
+	for range 3 {
+		_ = interceptor(context.Background(), "/grpc.health.v1.Health/Check", nil, nil, nil, invoker)
+	}
+	if calls["/grpc.health.v1.Health/Check"] != 3 {
+		t.Errorf("Expected excluded methods to bypass the breaker, got %d calls", calls["/grpc.health.v1.Health/Check"])
+	}
+}
@@ -0,0 +1,209 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+)
+
+// - defines what happens to counters on a transition
+type CounterPolicy string
+
+const (
+	CounterReset     CounterPolicy = "reset"
+	CounterCarryOver CounterPolicy = "carry-over"
+)
+
+// - defines counters handling around the half-open state
+type HalfOpenCounterPolicy struct {
+	// counters gathered in closed state before the trip, applied when half-open is entered
+	OnEnter CounterPolicy
+	// counters gathered in half-open state when a probe fails, applied when half-open is entered again
+	OnProbeFailure CounterPolicy
+}
+
+// - configures counters handling around the half-open state, both reset by default
+func WithHalfOpenCounterPolicy(policy HalfOpenCounterPolicy) Option {
+	return func(cb *CircuitBreaker) {
+		cb.counterPolicy = policy.normalized()
+	}
+}
+
+// - returns the configured counters handling around the half-open state
+func (cb *CircuitBreaker) HalfOpenCounterPolicy() HalfOpenCounterPolicy {
+	return cb.counterPolicy.normalized()
+}
+
+// normalized replaces empty policies with CounterReset
+func (p HalfOpenCounterPolicy) normalized() HalfOpenCounterPolicy {
+	if p.OnEnter == "" {
+		p.OnEnter = CounterReset
+	}
+	if p.OnProbeFailure == "" {
+		p.OnProbeFailure = CounterReset
+	}
+	return p
+}
+
+// applyCounterPolicy carries counters through the open state, must be called
+// under write lock right after counters were reset by the transition
+func (cb *CircuitBreaker) applyCounterPolicy(from, to string, counts Counts) {
+	keep := func(policy CounterPolicy) Counts {
+		if policy == CounterCarryOver {
+			return counts
+		}
+		return Counts{}
+	}
+
+	cb.carriedIn = Counts{}
+	switch {
+	case from == StateClosed && to == StateOpened:
+		cb.carried = keep(cb.counterPolicy.OnEnter)
+	case from == StateHalfOpen && to == StateOpened:
+		cb.carried = keep(cb.counterPolicy.OnProbeFailure)
+	case from == StateOpened && to == StateHalfOpen:
+		cb.successes = cb.carried.Successes
+		cb.failures = cb.carried.Failures
+		cb.carriedIn = cb.carried
+		cb.carried = Counts{}
+	default:
+		cb.carried = Counts{}
+	}
+}
+
+// - is the outcome of a HalfOpenPolicy decision
+type HalfOpenDecision int
+
+const (
+	// keep probing
+	HalfOpenContinue HalfOpenDecision = iota
+	HalfOpenClose
+	HalfOpenReopen
+)
+
+// - decides how the breaker recovers in half-open state, independently of the trip strategy
+type HalfOpenPolicy interface {
+	// MaxProbes returns how many calls are admitted in half-open state, zero means unlimited
+	MaxProbes() int64
+	// Decide is called after every result recorded in half-open state with the counts of the
+	// probes, without counts carried over by HalfOpenCounterPolicy, reason describes a close
+	// or reopen decision
+	Decide(counts Counts) (decision HalfOpenDecision, reason string)
+}
+
+// - replaces the success threshold and the reopening on the first failed probe with the policy
+func WithHalfOpenPolicy(policy HalfOpenPolicy) Option {
+	return func(cb *CircuitBreaker) {
+		cb.halfOpenPolicy = policy
+	}
+}
+
+// - is WithHalfOpenPolicy(NewSuccessRatePolicy(minRate, probes))
+func WithHalfOpenSuccessRate(minRate float64, probes int64) Option {
+	return WithHalfOpenPolicy(NewSuccessRatePolicy(minRate, probes))
+}
+
+// - admits maxProbes calls (unlimited when zero), closes after successes probes succeeded
+// and reopens on the first failed probe
+type SuccessCountPolicy struct {
+	successes int64
+	maxProbes int64
+}
+
+func NewSuccessCountPolicy(successes, maxProbes int64) *SuccessCountPolicy {
+	return &SuccessCountPolicy{successes: successes, maxProbes: maxProbes}
+}
+
+func (p *SuccessCountPolicy) MaxProbes() int64 {
+	return p.maxProbes
+}
+
+func (p *SuccessCountPolicy) Decide(counts Counts) (HalfOpenDecision, string) {
+	switch {
+	case counts.Failures > 0:
+		return HalfOpenReopen, "probe failed in half-open state"
+	case counts.Successes >= p.successes:
+		return HalfOpenClose, fmt.Sprintf("successes %d >= %d", counts.Successes, p.successes)
+	default:
+		return HalfOpenContinue, ""
+	}
+}
+
+// - admits probes calls, closes the circuit when at least minRate of them succeeded
+// and reopens it otherwise, e.g. NewSuccessRatePolicy(0.8, 10) for "≥80% of 10 probes"
+type SuccessRatePolicy struct {
+	minRate float64
+	probes  int64
+}
+
+func NewSuccessRatePolicy(minRate float64, probes int64) *SuccessRatePolicy {
+	return &SuccessRatePolicy{minRate: minRate, probes: probes}
+}
+
+func (p *SuccessRatePolicy) MaxProbes() int64 {
+	return p.probes
+}
+
+func (p *SuccessRatePolicy) Decide(counts Counts) (HalfOpenDecision, string) {
+	if counts.Total < p.probes {
+		// reopen early when the remaining probes can not reach the rate anymore
+		best := float64(counts.Successes+p.probes-counts.Total) / float64(p.probes)
+		if best < p.minRate {
+			return HalfOpenReopen, fmt.Sprintf("probe success rate can not reach %.0f%% of %d", p.minRate*100, p.probes)
+		}
+		return HalfOpenContinue, ""
+	}
+
+	rate := float64(counts.Successes) / float64(counts.Total)
+	reason := fmt.Sprintf("probe success rate %.0f%% of %d, required %.0f%%", rate*100, counts.Total, p.minRate*100)
+	if rate >= p.minRate {
+		return HalfOpenClose, reason
+	}
+	return HalfOpenReopen, reason
+}
+
+// applyHalfOpenPolicy closes or reopens the circuit as decided by the policy,
+// must be called under write lock in half-open state
+func (cb *CircuitBreaker) applyHalfOpenPolicy() {
+	decision, reason := cb.halfOpenPolicy.Decide(cb.probeCounts())
+	switch decision {
+	case HalfOpenClose:
+		cb.closeHalfOpen(cb.clock.Now(), reason)
+	case HalfOpenReopen:
+		cb.setState(StateOpened, reason)
+	}
+}
+
+// decideProbeRate closes the circuit when the rate-based success threshold passes and
+// reopens it when the threshold still fails after minSamples probes, must be called
+// under write lock in half-open state
+func (cb *CircuitBreaker) decideProbeRate(now time.Time, rate *SuccessRateThreshold) {
+	counts := cb.counts()
+	if cb.check(cb.successSwitch, counts) {
+		cb.closeHalfOpen(now, describeCheck("success", counts, cb.successThreshold))
+		return
+	}
+	if counts.Total >= int64(rate.minSamples) {
+		cb.setState(StateOpened, fmt.Sprintf("probe success rate below %.0f%% of %d", rate.minRate*100, counts.Total))
+	}
+}
+
+// probeCounts returns the counts of the probes, the counters without the counts carried
+// into half-open state, must be called under lock
+func (cb *CircuitBreaker) probeCounts() Counts {
+	successes := cb.successes - cb.carriedIn.Successes
+	failures := cb.failures - cb.carriedIn.Failures
+	return Counts{Successes: successes, Failures: failures, Total: successes + failures}
+}
+
+// admitProbe counts a call admitted in half-open state, false when the policy
+// admits no more probes, must be called under write lock
+func (cb *CircuitBreaker) admitProbe() bool {
+	if cb.halfOpenPolicy == nil {
+		return true
+	}
+	if limit := cb.halfOpenPolicy.MaxProbes(); limit > 0 && cb.probesAdmitted >= limit {
+		return false
+	}
+	cb.probesAdmitted++
+	return true
+}
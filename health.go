@@ -0,0 +1,70 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// - is implemented by components able to report their health
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// - reports an error wrapping ErrOpenState while the circuit is open
+func (cb *CircuitBreaker) Check(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if cb.State() == StateOpened {
+		return fmt.Errorf("circuit breaker %q: %w", cb.Name(), ErrOpenState)
+	}
+	return nil
+}
+
+// - marks the registered breaker as critical for the registry health check
+func (r *Registry) SetCritical(name string, critical bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if critical {
+		r.critical[name] = true
+	} else {
+		delete(r.critical, name)
+	}
+}
+
+// - reports degraded health when any critical breaker is open,
+// the returned error joins errors of all open critical breakers
+func (r *Registry) Check(ctx context.Context) error {
+	var errs []error
+	for _, cb := range r.All() {
+		if !r.isCritical(cb.Name()) {
+			continue
+		}
+		if err := cb.Check(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) isCritical(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.critical[name]
+}
+
+// - serves a readiness probe, responds 503 with the error text when checker fails
+func NewHealthHandler(checker HealthChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := checker.Check(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
@@ -0,0 +1,153 @@
+package circuitbreaker
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+)
+
+const (
+	// linear sub-buckets per power of two, gives ~6% precision
+	histogramSubBuckets = 16
+	histogramBuckets    = 64 * histogramSubBuckets
+)
+
+// - is a log-linear (HDR-style) histogram of call latencies
+type LatencyHistogram struct {
+	mu sync.Mutex
+
+	buckets [histogramBuckets]uint64
+	count   uint64
+	sum     time.Duration
+	min     time.Duration
+	max     time.Duration
+}
+
+// - is a point-in-time view of the histogram
+type LatencySnapshot struct {
+	Count uint64
+	Min   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	P999  time.Duration
+}
+
+// - is a constructor
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{}
+}
+
+// - records one latency, negative values are recorded as zero
+func (h *LatencyHistogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buckets[bucketIndex(uint64(d))]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+}
+
+// - returns the latency below which p percent (0-100) of recorded values fall
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.percentile(p)
+}
+
+// - returns count, min, max, mean and common percentiles
+func (h *LatencyHistogram) Snapshot() LatencySnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return LatencySnapshot{}
+	}
+
+	return LatencySnapshot{
+		Count: h.count,
+		Min:   h.min,
+		Max:   h.max,
+		Mean:  h.sum / time.Duration(h.count),
+		P50:   h.percentile(50),
+		P90:   h.percentile(90),
+		P95:   h.percentile(95),
+		P99:   h.percentile(99),
+		P999:  h.percentile(99.9),
+	}
+}
+
+// - drops all recorded values
+func (h *LatencyHistogram) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buckets = [histogramBuckets]uint64{}
+	h.count = 0
+	h.sum = 0
+	h.min = 0
+	h.max = 0
+}
+
+// percentile must be called under lock
+func (h *LatencyHistogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := uint64(p / 100 * float64(h.count))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > h.count {
+		rank = h.count
+	}
+
+	var seen uint64
+	for i, n := range h.buckets {
+		seen += n
+		if seen >= rank {
+			value := time.Duration(bucketUpperBound(i))
+			if value > h.max {
+				value = h.max
+			}
+			if value < h.min {
+				value = h.min
+			}
+			return value
+		}
+	}
+	return h.max
+}
+
+// bucketIndex maps value to its log-linear bucket
+func bucketIndex(v uint64) int {
+	if v < histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - 5
+	return (shift+1)*histogramSubBuckets + int(v>>shift) - histogramSubBuckets
+}
+
+// bucketUpperBound returns the highest value mapped to the bucket
+func bucketUpperBound(i int) uint64 {
+	if i < histogramSubBuckets {
+		return uint64(i)
+	}
+	shift := i/histogramSubBuckets - 1
+	sub := uint64(i%histogramSubBuckets + histogramSubBuckets)
+	return (sub+1)<<shift - 1
+}
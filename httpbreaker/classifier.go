@@ -0,0 +1,64 @@
+package httpbreaker
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// - decides on a response by its headers and the first bytes of its body
+type BodyClassifier func(resp *http.Response, prefix []byte) bool
+
+// - reads at most limit bytes of the body and passes them to classify,
+// the body is re-wrapped so downstream readers still see the whole content
+func InspectBody(limit int, classify BodyClassifier) Classifier {
+	return func(resp *http.Response) bool {
+		if resp.Body == nil || resp.Body == http.NoBody {
+			return classify(resp, nil)
+		}
+
+		prefix, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+		resp.Body = &prefixedBody{
+			Reader: io.MultiReader(bytes.NewReader(prefix), resp.Body),
+			Closer: resp.Body,
+		}
+		if err != nil {
+			return true
+		}
+
+		return classify(resp, prefix)
+	}
+}
+
+// prefixedBody replays the inspected prefix before the rest of the original body
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// - treats responses whose body prefix contains substr as failures,
+// e.g. BodyContains(512, `"status":"error"`) for soft failures with HTTP 200
+func BodyContains(limit int, substr string) Classifier {
+	return InspectBody(limit, func(_ *http.Response, prefix []byte) bool {
+		return bytes.Contains(prefix, []byte(substr))
+	})
+}
+
+// - treats responses whose header satisfies match as failures
+func HeaderMatches(name string, match func(value string) bool) Classifier {
+	return func(resp *http.Response) bool {
+		return match(resp.Header.Get(name))
+	}
+}
+
+// - treats a response as failure when any classifier does
+func Any(classifiers ...Classifier) Classifier {
+	return func(resp *http.Response) bool {
+		for _, classify := range classifiers {
+			if classify(resp) {
+				return true
+			}
+		}
+		return false
+	}
+}
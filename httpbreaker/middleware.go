@@ -0,0 +1,144 @@
+package httpbreaker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+const (
+	// state of the breaker that handled the request
+	HeaderCircuitState = "X-Circuit-State"
+	// seconds until the open circuit starts probing again
+	HeaderCircuitRetryAfter = "X-Circuit-Retry-After"
+)
+
+// - keys by path, one circuit per handler route of a server
+func KeyByPath(req *http.Request) string {
+	return req.URL.Path
+}
+
+// - decides whether a status code written by the handler counts as a failure
+type StatusClassifier func(status int) bool
+
+// - treats 5xx status codes as failures
+func ServerErrorStatus(status int) bool {
+	return status >= http.StatusInternalServerError
+}
+
+// - is an HTTP server middleware running handlers through breakers, requests
+// rejected by an open circuit get 503 Service Unavailable with Retry-After,
+// by a rate or concurrency limit 429 Too Many Requests, see RejectionStatus
+type Middleware struct {
+	Breakers *circuitbreaker.KeyedBreaker
+	// KeyByPath when nil
+	Key KeyFunc
+	// ServerErrorStatus when nil
+	IsFailure StatusClassifier
+	// adds HeaderCircuitState to every response and HeaderCircuitRetryAfter while
+	// the circuit is open, so upstream callers can coordinate their backoff
+	StateHeaders bool
+}
+
+// - is a constructor
+func NewMiddleware(breakers *circuitbreaker.KeyedBreaker, key KeyFunc) *Middleware {
+	return &Middleware{Breakers: breakers, Key: key}
+}
+
+// - wraps next
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := KeyByPath
+		if m.Key != nil {
+			key = m.Key
+		}
+		isFailure := ServerErrorStatus
+		if m.IsFailure != nil {
+			isFailure = m.IsFailure
+		}
+		name := key(r)
+		cb := m.Breakers.Get(name)
+
+		if m.StateHeaders {
+			setStateHeaders(w.Header(), cb)
+		}
+
+		called := false
+		ctx := circuitbreaker.WithTags(r.Context(), circuitbreaker.Tags{
+			circuitbreaker.TagMethod: r.Method,
+			circuitbreaker.TagKey:    name,
+		})
+		err := cb.ExecuteContext(ctx, func(ctx context.Context) error {
+			called = true
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+			if isFailure(recorder.status) {
+				return errFailureResponse
+			}
+			return nil
+		})
+		if called {
+			return
+		}
+
+		if m.StateHeaders {
+			// the state may have changed while the request was admitted
+			setStateHeaders(w.Header(), cb)
+		}
+		if errors.Is(err, circuitbreaker.ErrOpenState) {
+			w.Header().Set("Retry-After", retryAfterSeconds(cb))
+		}
+		http.Error(w, err.Error(), RejectionStatus(err))
+	})
+}
+
+// - returns the status code of a request the breaker did not admit: 429 Too Many Requests
+// for rate and concurrency limits, 503 Service Unavailable otherwise (open circuit, draining)
+func RejectionStatus(err error) int {
+	switch {
+	case errors.Is(err, circuitbreaker.ErrRateLimited), errors.Is(err, circuitbreaker.ErrConcurrencyLimited):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
+// setStateHeaders describes the breaker state in h
+func setStateHeaders(h http.Header, cb *circuitbreaker.CircuitBreaker) {
+	state := cb.State()
+	h.Set(HeaderCircuitState, state)
+	if state == circuitbreaker.StateOpened {
+		h.Set(HeaderCircuitRetryAfter, retryAfterSeconds(cb))
+	} else {
+		h.Del(HeaderCircuitRetryAfter)
+	}
+}
+
+// retryAfterSeconds returns the remaining open time rounded up to seconds
+func retryAfterSeconds(cb *circuitbreaker.CircuitBreaker) string {
+	return strconv.Itoa(int(math.Ceil(cb.OpenRemaining().Seconds())))
+}
+
+// statusRecorder captures the status code written by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush keeps the wrapped writer usable as http.Flusher by streaming handlers
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,94 @@
+package httpbreaker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - balances requests over upstreams round robin, with Retarget requests assigned to an
+// upstream whose circuit is open go to the next upstream whose circuit admits calls
+type Upstreams struct {
+	Targets  []*url.URL
+	Breakers *circuitbreaker.KeyedBreaker
+	Retarget bool
+
+	next atomic.Uint64
+}
+
+// - is a constructor, breakers are keyed by upstream host and Retarget is enabled
+func NewUpstreams(breakers *circuitbreaker.KeyedBreaker, targets ...*url.URL) *Upstreams {
+	return &Upstreams{Targets: targets, Breakers: breakers, Retarget: true}
+}
+
+// - returns the upstream for the next request, nil when there are no upstreams
+func (u *Upstreams) Pick() *url.URL {
+	if len(u.Targets) == 0 {
+		return nil
+	}
+
+	start := int(u.next.Add(1)-1) % len(u.Targets)
+	if !u.Retarget {
+		return u.Targets[start]
+	}
+
+	for i := range u.Targets {
+		target := u.Targets[(start+i)%len(u.Targets)]
+		if cb, ok := u.Breakers.Lookup(target.Host); !ok || cb.State() != circuitbreaker.StateOpened {
+			return target
+		}
+	}
+	// every circuit is open, the transport rejects the request
+	return u.Targets[start]
+}
+
+// - returns the httputil.ReverseProxy director sending requests to Pick
+func (u *Upstreams) Director() func(req *http.Request) {
+	return func(req *http.Request) {
+		target := u.Pick()
+		if target == nil {
+			return
+		}
+
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		req.URL.Path = strings.TrimSuffix(target.Path, "/") + req.URL.Path
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = strings.TrimSuffix(target.EscapedPath(), "/") + req.URL.RawPath
+		}
+		if target.RawQuery != "" {
+			req.URL.RawQuery = joinQuery(target.RawQuery, req.URL.RawQuery)
+		}
+	}
+}
+
+// - returns a breaker-aware gateway: a reverse proxy over the upstreams with a breaker
+// per upstream host, responding 503 while the circuit of the picked upstream is open
+// and 502 on other transport errors
+func NewReverseProxy(breakers *circuitbreaker.KeyedBreaker, base http.RoundTripper, targets ...*url.URL) *httputil.ReverseProxy {
+	upstreams := NewUpstreams(breakers, targets...)
+
+	return &httputil.ReverseProxy{
+		Director:  upstreams.Director(),
+		Transport: NewTransport(base, breakers, KeyByHost),
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			if errors.Is(err, circuitbreaker.ErrOpenState) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+func joinQuery(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "&" + b
+}
@@ -0,0 +1,156 @@
+// Package httpbreaker integrates circuit breakers with net/http.
+package httpbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/nick1jesky/circuit_breaker/keys"
+)
+
+// - routes a request to its breaker
+type KeyFunc func(req *http.Request) string
+
+// - keys by host, one circuit per upstream host
+func KeyByHost(req *http.Request) string {
+	return req.URL.Host
+}
+
+// - keys by host and path, one circuit per endpoint
+func KeyByHostPath(req *http.Request) string {
+	return req.URL.Host + req.URL.Path
+}
+
+// - keys by method, host and path, e.g. "POST api.example.com/orders"
+func KeyByMethodHostPath(req *http.Request) string {
+	return req.Method + " " + req.URL.Host + req.URL.Path
+}
+
+// - decides whether a response counts as a dependency failure
+type Classifier func(resp *http.Response) bool
+
+// - treats 5xx responses as failures
+func ServerErrors(resp *http.Response) bool {
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// errFailureResponse marks responses classified as failures inside Execute
+var errFailureResponse = errors.New("failure response")
+
+// - is an http.RoundTripper routing each request to its own breaker,
+// so one failing endpoint does not open the circuit for the entire host
+type Transport struct {
+	Base     http.RoundTripper
+	Breakers *circuitbreaker.KeyedBreaker
+	// KeyByHost when nil
+	Key KeyFunc
+	// ServerErrors when nil
+	IsFailure Classifier
+}
+
+// - is a constructor, nil base means http.DefaultTransport
+func NewTransport(base http.RoundTripper, breakers *circuitbreaker.KeyedBreaker, key KeyFunc) *Transport {
+	return &Transport{Base: base, Breakers: breakers, Key: key}
+}
+
+// - RoundTrip, requests rejected by an open circuit fail with an error wrapping ErrOpenState
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.key(req)
+	cb := t.Breakers.Get(key)
+
+	var resp *http.Response
+	sent := false
+	ctx := circuitbreaker.WithTags(req.Context(), circuitbreaker.Tags{
+		circuitbreaker.TagMethod: req.Method,
+		circuitbreaker.TagKey:    key,
+	})
+	err := cb.ExecuteContext(ctx, func(ctx context.Context) error {
+		var err error
+		sent = true
+		resp, err = t.base().RoundTrip(req)
+		if err != nil {
+			return err
+		}
+		if t.isFailure(resp) {
+			return errFailureResponse
+		}
+		return nil
+	})
+	if !sent && req.Body != nil {
+		// a RoundTripper closes the body even when the request is not sent
+		req.Body.Close()
+	}
+
+	switch {
+	case errors.Is(err, errFailureResponse):
+		return resp, nil
+	case errors.Is(err, circuitbreaker.ErrOpenState):
+		return nil, fmt.Errorf("%s: %w", key, err)
+	default:
+		return resp, err
+	}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) key(req *http.Request) string {
+	if t.Key == nil {
+		return KeyByHost(req)
+	}
+	return t.Key(req)
+}
+
+func (t *Transport) isFailure(resp *http.Response) bool {
+	if t.IsFailure == nil {
+		return ServerErrors(resp)
+	}
+	return t.IsFailure(resp)
+}
+
+// - keys by host name without the port, one circuit per upstream host
+func KeyByHostname(req *http.Request) string {
+	return keys.Hostname(requestHost(req))
+}
+
+// - keys by host and port, the default port of the scheme is made explicit,
+// so "example.com" and "example.com:443" share a circuit
+func KeyByHostPort(req *http.Request) string {
+	scheme := req.URL.Scheme
+	if scheme == "" && req.TLS != nil {
+		scheme = "https"
+	}
+	return keys.HostPort(requestHost(req), scheme)
+}
+
+// - returns a KeyFunc keying by host and the first matching path template,
+// IDs in unmatched paths are normalized, see keys.PathTemplate
+func KeyByPathTemplate(templates ...string) KeyFunc {
+	return func(req *http.Request) string {
+		return requestHost(req) + keys.PathTemplate(req.URL.Path, templates...)
+	}
+}
+
+// - keys server requests by the http.ServeMux pattern that matched them,
+// by the normalized path when the request was not routed by a ServeMux
+func KeyByRoutePattern(req *http.Request) string {
+	if req.Pattern != "" {
+		return req.Pattern
+	}
+	return keys.NormalizePath(req.URL.Path)
+}
+
+// requestHost returns the target host of client and server requests
+func requestHost(req *http.Request) string {
+	if req.URL.Host != "" {
+		return req.URL.Host
+	}
+	return req.Host
+}
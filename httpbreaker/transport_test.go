@@ -0,0 +1,250 @@
+package httpbreaker
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+func newKeyed() *circuitbreaker.KeyedBreaker {
+	return circuitbreaker.NewKeyedBreaker(func(key string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(1),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+			circuitbreaker.WithName(key),
+		)
+	})
+}
+
+func TestTransportKeyedByEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	breakers := newKeyed()
+	client := &http.Client{Transport: NewTransport(nil, breakers, KeyByHostPath)}
+
+	resp, err := client.Get(server.URL + "/bad")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected failure response to be returned, got %d", resp.StatusCode)
+	}
+
+	if _, err := client.Get(server.URL + "/bad"); !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Errorf("Expected %v, got %v", circuitbreaker.ErrOpenState, err)
+	}
+
+	resp, err = client.Get(server.URL + "/good")
+	if err != nil {
+		t.Fatalf("Expected other endpoint to stay available, got %v", err)
+	}
+	resp.Body.Close()
+
+	if keys := breakers.Keys(); len(keys) != 2 {
+		t.Errorf("Expected 2 breakers, got %v", keys)
+	}
+}
+
+// closeTracker is a request body recording whether it was closed
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeTracker) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransportClosesBodyOnRejection(t *testing.T) {
+	breakers := newKeyed()
+	transport := NewTransport(nil, breakers, nil)
+
+	body := &closeTracker{Reader: strings.NewReader("payload")}
+	req, err := http.NewRequest(http.MethodPost, "http://payments.internal/charge", body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	breakers.Get(KeyByHost(req)).ForceOpen()
+
+	if _, err := transport.RoundTrip(req); !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Fatalf("Expected %v, got %v", circuitbreaker.ErrOpenState, err)
+	}
+	if !body.closed {
+		t.Error("Expected the request body to be closed when the circuit rejects")
+	}
+}
+
+func TestBodyAwareClassifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"error","message":"backend unavailable"}`))
+	}))
+	defer server.Close()
+
+	transport := NewTransport(nil, newKeyed(), nil)
+	transport.IsFailure = Any(ServerErrors, BodyContains(32, `"status":"error"`))
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Unexpected read error: %v", err)
+	}
+	if string(body) != `{"status":"error","message":"backend unavailable"}` {
+		t.Errorf("Expected whole body after inspection, got %s", body)
+	}
+
+	if _, err := client.Get(server.URL); !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Errorf("Expected soft failure to open the circuit, got %v", err)
+	}
+}
+
+func TestReverseProxyRetargets(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "healthy "+r.URL.Path)
+	}))
+	defer healthy.Close()
+
+	failingURL, _ := url.Parse(failing.URL)
+	healthyURL, _ := url.Parse(healthy.URL + "/api")
+
+	proxy := httptest.NewServer(NewReverseProxy(newKeyed(), nil, failingURL, healthyURL))
+	defer proxy.Close()
+
+	var statuses []int
+	for range 4 {
+		resp, err := http.Get(proxy.URL + "/orders")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		statuses = append(statuses, resp.StatusCode)
+		if resp.StatusCode == http.StatusOK && string(body) != "healthy /api/orders" {
+			t.Errorf("Unexpected body: %q", body)
+		}
+	}
+
+	// the first request opens the circuit of the failing upstream, all later ones are retargeted
+	if statuses[0] != http.StatusBadGateway || statuses[2] != http.StatusOK || statuses[3] != http.StatusOK {
+		t.Errorf("Expected retargeting after the failing upstream opened, got %v", statuses)
+	}
+}
+
+func TestMiddlewareStateHeaders(t *testing.T) {
+	middleware := NewMiddleware(newKeyed(), nil)
+	middleware.StateHeaders = true
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := serve("/ok"); rec.Code != http.StatusOK || rec.Header().Get(HeaderCircuitState) != circuitbreaker.StateClosed {
+		t.Errorf("Expected closed state header, got %d %v", rec.Code, rec.Header())
+	}
+	serve("/fail")
+
+	rec := serve("/fail")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	if rec.Header().Get(HeaderCircuitState) != circuitbreaker.StateOpened || rec.Header().Get(HeaderCircuitRetryAfter) != "60" || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected open state headers, got %v", rec.Header())
+	}
+}
+
+func TestMiddlewareRejections(t *testing.T) {
+	limiter := circuitbreaker.NewRateLimiter(0.001, 1)
+	var cb *circuitbreaker.CircuitBreaker
+	breakers := circuitbreaker.NewKeyedBreaker(func(string) *circuitbreaker.CircuitBreaker {
+		cb = circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(1),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+			circuitbreaker.WithRateLimiter(limiter, circuitbreaker.LimitAfterBreaker),
+			circuitbreaker.WithRejectionSink(10, 1),
+		)
+		return cb
+	})
+	handler := NewMiddleware(breakers, nil).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("Expected the response writer to stay a http.Flusher")
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		return rec
+	}
+
+	serve()
+	if rec := serve(); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected %d with Retry-After from the open circuit, got %d %v", http.StatusServiceUnavailable, rec.Code, rec.Header())
+	}
+	if calls := cb.Rejections(); len(calls) != 1 || calls[0].Tags[circuitbreaker.TagKey] != "/orders" {
+		t.Errorf("Expected the rejected call tagged with the key, got %+v", calls)
+	}
+
+	cb.ForceClose()
+	rec := serve()
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected %d from the rate limiter, got %d %v", http.StatusTooManyRequests, rec.Code, rec.Header())
+	}
+	if body := rec.Body.String(); body != circuitbreaker.ErrRateLimited.Error()+"\n" {
+		t.Errorf("Expected the rate limit error, got %q", body)
+	}
+}
+
+func TestKeyExtractors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://API.example.com/users/42/orders", nil)
+	if key := KeyByHostPort(req); key != "api.example.com:443" {
+		t.Errorf("Expected default port key, got %q", key)
+	}
+	if key := KeyByPathTemplate("/users/{id}/orders")(req); key != "API.example.com/users/{id}/orders" {
+		t.Errorf("Expected template key, got %q", key)
+	}
+
+	mux := http.NewServeMux()
+	var pattern string
+	mux.HandleFunc("GET /users/{id}/orders", func(w http.ResponseWriter, r *http.Request) {
+		pattern = KeyByRoutePattern(r)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42/orders", nil))
+	if pattern != "GET /users/{id}/orders" {
+		t.Errorf("Expected route pattern key, got %q", pattern)
+	}
+}
@@ -0,0 +1,19 @@
+package circuitbreaker
+
+// - records a call whose outcome must not influence the breaker, e.g. the client
+// aborted before the request was sent: the counters and rates are left unchanged,
+// a half-open probe slot taken by the call is given back
+func (cb *CircuitBreaker) RecordIgnore() {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.recordIgnore()
+}
+
+// recordIgnore must be called under write lock
+func (cb *CircuitBreaker) recordIgnore() {
+	cb.ignored++
+	if cb.state == StateHalfOpen && cb.probesAdmitted > 0 {
+		cb.probesAdmitted--
+	}
+}
@@ -0,0 +1,72 @@
+package circuitbreaker
+
+// - delays the open -> half-open transition until the calls admitted before the breaker
+// opened have completed, so their late results can not be mistaken for probe results
+func WithDrainBeforeHalfOpen() Option {
+	return func(cb *CircuitBreaker) {
+		cb.drainBeforeHalfOpen = true
+	}
+}
+
+// - returns the number of protected calls currently running in Execute and ExecuteContext
+// and of the permits returned by Acquire until Success, Failure or Ignore is called
+func (cb *CircuitBreaker) Inflight() int64 {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	return cb.inflight
+}
+
+// beginCall counts an admitted call, returns the generation it started in
+func (cb *CircuitBreaker) beginCall() uint64 {
+	generation, _ := cb.beginCallState()
+	return generation
+}
+
+// beginCallState is beginCall also returning the state the call started in
+func (cb *CircuitBreaker) beginCallState() (uint64, string) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.inflightByGeneration == nil {
+		cb.inflightByGeneration = make(map[uint64]int64)
+	}
+	cb.inflight++
+	cb.inflightByGeneration[cb.generation]++
+	return cb.generation, cb.state
+}
+
+// endCall uncounts a call started in generation, applies the deferred half-open
+// transition when the last call of a previous generation completed
+func (cb *CircuitBreaker) endCall(generation uint64) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.inflight--
+	cb.inflightByGeneration[generation]--
+	if cb.inflightByGeneration[generation] <= 0 {
+		delete(cb.inflightByGeneration, generation)
+	}
+	cb.notifyDrained()
+
+	if cb.halfOpenDeferred && !cb.waitingForDrain() {
+		cb.halfOpenDeferred = false
+		if cb.state == StateOpened {
+			cb.setState(StateHalfOpen, cb.openTimeoutReason()+", in-flight calls drained")
+		}
+	}
+}
+
+// waitingForDrain reports whether the half-open transition must wait for calls
+// started before the current generation, must be called under lock
+func (cb *CircuitBreaker) waitingForDrain() bool {
+	if !cb.drainBeforeHalfOpen {
+		return false
+	}
+	for generation := range cb.inflightByGeneration {
+		if generation < cb.generation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package circuitbreaker
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// - is an append-only audit log of breaker events, one JSONEvent line per event,
+// register Handler with WithEventHandler on every breaker to audit
+type Journal struct {
+	mu     sync.Mutex
+	w      io.Writer
+	err    error
+	closed bool
+
+	// rotation of journals opened with OpenJournal
+	path     string
+	file     *os.File
+	size     int64
+	maxSize  int64
+	maxFiles int
+}
+
+// - returns a journal writing to w
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w}
+}
+
+// - returns a journal appending to the file at path, when the file exceeds maxSize bytes
+// it is rotated to path.1, path.1 to path.2 and so on, keeping maxFiles rotated files,
+// maxSize 0 disables rotation
+func OpenJournal(path string, maxSize int64, maxFiles int) (*Journal, error) {
+	j := &Journal{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// - returns the EventHandler recording the audited events to the journal: transitions,
+// overrides and config changes, other events (e.g. rejections) are dropped
+func (j *Journal) Handler() EventHandler {
+	return func(event Event) {
+		switch event.Type {
+		case EventStateChange, EventOverride, EventConfigChange:
+			_ = j.Record(event)
+		}
+	}
+}
+
+// - appends event to the journal whatever its type, the first write error is kept
+// and returned by Err
+func (j *Journal) Record(event Event) error {
+	line, err := json.Marshal(newJSONEvent(event))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrJournalClosed
+	}
+	if j.file != nil && j.maxSize > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxSize {
+		if err := j.rotate(); err != nil {
+			j.fail(err)
+			return err
+		}
+	}
+
+	n, err := j.w.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		j.fail(err)
+	}
+	return err
+}
+
+// - returns the first error the journal failed with, events may be missing after it
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// - closes the file of a journal opened with OpenJournal, later events are dropped
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	if j.file != nil {
+		return j.file.Close()
+	}
+	return nil
+}
+
+// open opens the journal file for appending, must be called under lock
+func (j *Journal) open() error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	j.file, j.w, j.size = file, file, info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files and starts a new journal file, must be called under lock
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	for i := j.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedJournal(j.path, i), rotatedJournal(j.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if j.maxFiles > 0 {
+		if err := os.Rename(j.path, rotatedJournal(j.path, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Truncate(j.path, 0); err != nil {
+		return err
+	}
+	return j.open()
+}
+
+// fail keeps the first error, must be called under lock
+func (j *Journal) fail(err error) {
+	if j.err == nil {
+		j.err = err
+	}
+}
+
+func rotatedJournal(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// - parses the events of a journal written by Journal or NewJSONEventHandler
+func ReadJournal(r io.Reader) ([]JSONEvent, error) {
+	var events []JSONEvent
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event JSONEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return events, fmt.Errorf("journal line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// - reads the journal at path together with its rotated files, oldest events first
+func ReadJournalFiles(path string, maxFiles int) ([]JSONEvent, error) {
+	var events []JSONEvent
+	for i := maxFiles; i >= 0; i-- {
+		name := path
+		if i > 0 {
+			name = rotatedJournal(path, i)
+		}
+
+		file, err := os.Open(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return events, err
+		}
+		read, err := ReadJournal(file)
+		file.Close()
+		events = append(events, read...)
+		if err != nil {
+			return events, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return events, nil
+}
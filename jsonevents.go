@@ -0,0 +1,49 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// - is the stable schema of a line written by NewJSONEventHandler
+type JSONEvent struct {
+	Time    time.Time `json:"time"`
+	Breaker string    `json:"breaker"`
+	Type    EventType `json:"type"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	Tags    Tags      `json:"tags,omitempty"`
+	Actor   string    `json:"actor,omitempty"`
+}
+
+// - returns an EventHandler writing one JSON line per event to w,
+// for log-based alerting, write errors are dropped
+func NewJSONEventHandler(w io.Writer) EventHandler {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+
+	return func(event Event) {
+		line := newJSONEvent(event)
+
+		mu.Lock()
+		defer mu.Unlock()
+		_ = encoder.Encode(line)
+	}
+}
+
+// newJSONEvent maps event to the stable schema
+func newJSONEvent(event Event) JSONEvent {
+	return JSONEvent{
+		Time:    event.Time.UTC(),
+		Breaker: event.Breaker,
+		Type:    event.Type,
+		From:    event.From,
+		To:      event.To,
+		Reason:  event.Reason,
+		Tags:    event.Tags,
+		Actor:   event.Actor,
+	}
+}
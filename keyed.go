@@ -0,0 +1,69 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// - keeps one breaker per key (host, endpoint, shard), breakers are created lazily
+type KeyedBreaker struct {
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+	factory  func(key string) *CircuitBreaker
+}
+
+// - is a constructor, factory creates the breaker for a new key
+func NewKeyedBreaker(factory func(key string) *CircuitBreaker) *KeyedBreaker {
+	return &KeyedBreaker{
+		breakers: make(map[string]*CircuitBreaker),
+		factory:  factory,
+	}
+}
+
+// - returns the breaker for the key, creating it when needed
+func (k *KeyedBreaker) Get(key string) *CircuitBreaker {
+	k.mu.RLock()
+	cb, ok := k.breakers[key]
+	k.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if cb, ok := k.breakers[key]; ok {
+		return cb
+	}
+	cb = k.factory(key)
+	k.breakers[key] = cb
+	return cb
+}
+
+// - returns the breaker for the key without creating it
+func (k *KeyedBreaker) Lookup(key string) (*CircuitBreaker, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	cb, ok := k.breakers[key]
+	return cb, ok
+}
+
+// - drops the breaker for the key
+func (k *KeyedBreaker) Remove(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.breakers, key)
+}
+
+// - returns known keys sorted
+func (k *KeyedBreaker) Keys() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]string, 0, len(k.breakers))
+	for key := range k.breakers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,121 @@
+// Package keys extracts consistent breaker keys from request targets, so per-target
+// breakers of KeyedBreaker are neither shared by unrelated targets nor split by
+// high-cardinality parts such as IDs in paths.
+package keys
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// - returns host without the port, lower cased, e.g. "api.example.com" for "API.example.com:8443"
+func Hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// - returns host with the port, the default port of scheme is added when host has none,
+// e.g. "api.example.com:443" for "api.example.com" and "https"
+func HostPort(host, scheme string) string {
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		return net.JoinHostPort(strings.ToLower(h), port)
+	}
+
+	port := "80"
+	switch strings.ToLower(scheme) {
+	case "https", "wss", "grpcs":
+		port = "443"
+	}
+	return net.JoinHostPort(strings.ToLower(strings.Trim(host, "[]")), port)
+}
+
+// path segments replaced by NormalizePath
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexSegment     = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+)
+
+// - replaces numeric, UUID and long hex path segments with "{id}",
+// e.g. "/users/{id}/orders" for "/users/42/orders"
+func NormalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if numericSegment.MatchString(s) || uuidSegment.MatchString(s) || hexSegment.MatchString(s) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// - returns the first template matching path, segments in braces match any segment
+// and a trailing "{...}" segment matches the rest of the path, e.g. "/users/{id}" matches
+// "/users/42", NormalizePath(path) when no template matches
+func PathTemplate(path string, templates ...string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, template := range templates {
+		if matchTemplate(segments, strings.Split(strings.Trim(template, "/"), "/")) {
+			return template
+		}
+	}
+	return NormalizePath(path)
+}
+
+// matchTemplate matches path segments against template segments
+func matchTemplate(segments, template []string) bool {
+	for i, t := range template {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "...}") {
+			return i < len(segments)
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			continue
+		}
+		if t != segments[i] {
+			return false
+		}
+	}
+	return len(segments) == len(template)
+}
+
+// - returns the service of a gRPC full method name, e.g. "pkg.Orders" for "/pkg.Orders/Get"
+func GRPCService(fullMethod string) string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return service
+}
+
+// - returns the database name of a DSN in URL form ("postgres://host/orders"),
+// key=value form ("host=db dbname=orders") or MySQL form ("user@tcp(db:3306)/orders"),
+// empty when the DSN names no database
+func SQLDatabase(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			if name := strings.Trim(u.Path, "/"); name != "" {
+				return name
+			}
+			return u.Query().Get("database")
+		}
+	}
+
+	if !strings.Contains(dsn, "/") && strings.Contains(dsn, "=") {
+		for _, field := range strings.Fields(dsn) {
+			key, value, _ := strings.Cut(field, "=")
+			if key == "dbname" || key == "database" {
+				return strings.Trim(value, `'"`)
+			}
+		}
+		return ""
+	}
+
+	if i := strings.LastIndex(dsn, "/"); i >= 0 {
+		name, _, _ := strings.Cut(dsn[i+1:], "?")
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package keys
+
+import "testing"
+
+func TestKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{"hostname", Hostname("API.example.com:8443"), "api.example.com"},
+		{"host port default", HostPort("api.example.com", "https"), "api.example.com:443"},
+		{"host port explicit", HostPort("[::1]:8080", "http"), "[::1]:8080"},
+		{"template", PathTemplate("/users/42/orders", "/users/{id}", "/users/{id}/orders"), "/users/{id}/orders"},
+		{"template rest", PathTemplate("/static/css/app.css", "/static/{path...}"), "/static/{path...}"},
+		{"normalized", PathTemplate("/carts/3f2b8c9e-1a2b-4c3d-8e9f-0a1b2c3d4e5f/items/7"), "/carts/{id}/items/{id}"},
+		{"grpc service", GRPCService("/shop.v1.Orders/Get"), "shop.v1.Orders"},
+		{"postgres url", SQLDatabase("postgres://user:pass@db:5432/orders?sslmode=disable"), "orders"},
+		{"sqlserver query", SQLDatabase("sqlserver://user@db?database=billing"), "billing"},
+		{"key value", SQLDatabase("host=db user=app dbname='inventory'"), "inventory"},
+		{"mysql", SQLDatabase("app:secret@tcp(db:3306)/payments?parseTime=true"), "payments"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, tt.got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"maps"
+)
+
+// - opens the closed circuit when the last value of metric recorded with RecordLoad
+// reaches limit, e.g. WithLoadLimit("cpu", 0.9) or WithLoadLimit("queue_depth", 1000),
+// so the breaker also protects an overloaded process, not only a failing dependency,
+// RecordLoad opens it at once, the load signal is no call result a grace period or the
+// soft-open mode could hold back, a result recorded while overloaded trips like the
+// trip policy, see WithTripGrace and WithSoftOpen
+func WithLoadLimit(metric string, limit float64) Option {
+	return func(cb *CircuitBreaker) {
+		if cb.loadLimits == nil {
+			cb.loadLimits = make(map[string]float64)
+		}
+		cb.loadLimits[metric] = limit
+	}
+}
+
+// - feeds an external load signal such as CPU usage, queue depth or goroutine count,
+// the last value per metric is checked against WithLoadLimit and passed to the
+// trip policy in Stats.Load
+func (cb *CircuitBreaker) RecordLoad(metric string, value float64) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.load == nil {
+		cb.load = make(map[string]float64)
+	}
+	cb.load[metric] = value
+
+	now := cb.clock.Now()
+	if _, forced := cb.forcedState(now); forced || cb.state != StateClosed || cb.inWarmup(now) || cb.dwelling(now) {
+		return
+	}
+	if reason, over := cb.overloaded(); over {
+		cb.setState(StateOpened, reason)
+	}
+}
+
+// - returns the last recorded value of every load metric
+func (cb *CircuitBreaker) Load() map[string]float64 {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return maps.Clone(cb.load)
+}
+
+// overloaded reports the first metric at its limit, must be called under lock
+func (cb *CircuitBreaker) overloaded() (string, bool) {
+	for metric, limit := range cb.loadLimits {
+		if value, ok := cb.load[metric]; ok && value >= limit {
+			return fmt.Sprintf("load %s %g >= %g", metric, value, limit), true
+		}
+	}
+	return "", false
+}
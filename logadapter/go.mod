@@ -0,0 +1,16 @@
+module github.com/nick1jesky/circuit_breaker/logadapter
+
+go 1.24.2
+
+require (
+	github.com/nick1jesky/circuit_breaker v0.0.0
+	github.com/sirupsen/logrus v1.9.4
+	go.uber.org/zap v1.27.1
+)
+
+require (
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
+
+replace github.com/nick1jesky/circuit_breaker => ../
@@ -0,0 +1,80 @@
+// Package bridge implements the slog.Handler shared by the logger adapters.
+package bridge
+
+import (
+	"context"
+	"log/slog"
+)
+
+// - writes a record with attributes flattened to "group.key" pairs
+type Emitter interface {
+	Enabled(level slog.Level) bool
+	Emit(level slog.Level, msg string, attrs []slog.Attr)
+}
+
+// - is a slog.Handler passing records to an Emitter
+type Handler struct {
+	emitter Emitter
+	attrs   []slog.Attr
+	prefix  string
+}
+
+// - is a constructor
+func NewHandler(emitter Emitter) *Handler {
+	return &Handler{emitter: emitter}
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.emitter.Enabled(level)
+}
+
+func (h *Handler) Handle(_ context.Context, record slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+record.NumAttrs())
+	attrs = append(attrs, h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = flatten(attrs, h.prefix, attr)
+		return true
+	})
+
+	h.emitter.Emit(record.Level, record.Message, attrs)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		next.attrs = flatten(next.attrs, h.prefix, attr)
+	}
+	return &next
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	next := *h
+	next.prefix = h.prefix + name + "."
+	return &next
+}
+
+// flatten appends attr with prefixed keys, groups are expanded
+func flatten(attrs []slog.Attr, prefix string, attr slog.Attr) []slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return attrs
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			attrs = flatten(attrs, groupPrefix, member)
+		}
+		return attrs
+	}
+
+	return append(attrs, slog.Attr{Key: prefix + attr.Key, Value: attr.Value})
+}
@@ -0,0 +1,63 @@
+// Package logrusadapter plugs a logrus logger into the circuit breaker logging hooks.
+package logrusadapter
+
+import (
+	"log/slog"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/nick1jesky/circuit_breaker/logadapter/internal/bridge"
+	"github.com/sirupsen/logrus"
+)
+
+// - returns a slog.Handler writing to logger, e.g. for circuitbreaker.Logging(slog.New(NewHandler(logger)), name)
+func NewHandler(logger *logrus.Logger) slog.Handler {
+	return bridge.NewHandler(emitter{logger: logger})
+}
+
+// - returns an EventHandler logging breaker events to logger,
+// state changes at info level and other events at debug level
+func EventHandler(logger *logrus.Logger) circuitbreaker.EventHandler {
+	return func(event circuitbreaker.Event) {
+		level := logrus.DebugLevel
+		if event.Type == circuitbreaker.EventStateChange {
+			level = logrus.InfoLevel
+		}
+
+		logger.WithFields(logrus.Fields{
+			"breaker": event.Breaker,
+			"from":    event.From,
+			"to":      event.To,
+			"reason":  event.Reason,
+		}).WithTime(event.Time).Log(level, "circuit breaker "+string(event.Type))
+	}
+}
+
+type emitter struct {
+	logger *logrus.Logger
+}
+
+func (e emitter) Enabled(level slog.Level) bool {
+	return e.logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func (e emitter) Emit(level slog.Level, msg string, attrs []slog.Attr) {
+	fields := make(logrus.Fields, len(attrs))
+	for _, attr := range attrs {
+		fields[attr.Key] = attr.Value.Any()
+	}
+	e.logger.WithFields(fields).Log(logrusLevel(level), msg)
+}
+
+// logrusLevel maps slog levels to the nearest logrus level
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	default:
+		return logrus.DebugLevel
+	}
+}
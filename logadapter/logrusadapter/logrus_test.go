@@ -0,0 +1,44 @@
+package logrusadapter
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestLogrusAdapter(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	breaker := circuitbreaker.NewCircuitBreaker(
+		circuitbreaker.NewInt64Threshold(1),
+		circuitbreaker.NewInt64Threshold(1),
+		time.Minute,
+		circuitbreaker.WithName("payments"),
+		circuitbreaker.WithEventHandler(EventHandler(logger)),
+	)
+	logged := circuitbreaker.Decorate(breaker, circuitbreaker.Logging(slog.New(NewHandler(logger)), "payments"))
+
+	logged.RecordFailure()
+
+	var event, change *logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		switch entry.Message {
+		case "circuit breaker state-change":
+			event = entry
+		case "circuit breaker state changed":
+			change = entry
+		}
+	}
+
+	if event == nil || event.Data["to"] != circuitbreaker.StateOpened {
+		t.Errorf("Expected state change event, got %+v", hook.AllEntries())
+	}
+	if change == nil || change.Data["breaker"] != "payments" || change.Level != logrus.InfoLevel {
+		t.Errorf("Expected slog record, got %+v", hook.AllEntries())
+	}
+}
@@ -0,0 +1,65 @@
+// Package zapadapter plugs a zap logger into the circuit breaker logging hooks.
+package zapadapter
+
+import (
+	"log/slog"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/nick1jesky/circuit_breaker/logadapter/internal/bridge"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// - returns a slog.Handler writing to logger, e.g. for circuitbreaker.Logging(slog.New(NewHandler(logger)), name)
+func NewHandler(logger *zap.Logger) slog.Handler {
+	return bridge.NewHandler(emitter{logger: logger})
+}
+
+// - returns an EventHandler logging breaker events to logger,
+// state changes at info level and other events at debug level
+func EventHandler(logger *zap.Logger) circuitbreaker.EventHandler {
+	return func(event circuitbreaker.Event) {
+		level := zapcore.DebugLevel
+		if event.Type == circuitbreaker.EventStateChange {
+			level = zapcore.InfoLevel
+		}
+
+		logger.Log(level, "circuit breaker "+string(event.Type),
+			zap.String("breaker", event.Breaker),
+			zap.String("from", event.From),
+			zap.String("to", event.To),
+			zap.String("reason", event.Reason),
+			zap.Time("time", event.Time),
+		)
+	}
+}
+
+type emitter struct {
+	logger *zap.Logger
+}
+
+func (e emitter) Enabled(level slog.Level) bool {
+	return e.logger.Core().Enabled(zapLevel(level))
+}
+
+func (e emitter) Emit(level slog.Level, msg string, attrs []slog.Attr) {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+	e.logger.Log(zapLevel(level), msg, fields...)
+}
+
+// zapLevel maps slog levels to the nearest zap level
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
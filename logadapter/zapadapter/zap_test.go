@@ -0,0 +1,38 @@
+package zapadapter
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZapAdapter(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core)
+
+	breaker := circuitbreaker.NewCircuitBreaker(
+		circuitbreaker.NewInt64Threshold(1),
+		circuitbreaker.NewInt64Threshold(1),
+		time.Minute,
+		circuitbreaker.WithName("payments"),
+		circuitbreaker.WithEventHandler(EventHandler(logger)),
+	)
+	logged := circuitbreaker.Decorate(breaker, circuitbreaker.Logging(slog.New(NewHandler(logger)).WithGroup("cb"), "payments"))
+
+	logged.RecordFailure()
+
+	events := logs.FilterMessage("circuit breaker state-change").All()
+	if len(events) != 1 || events[0].ContextMap()["to"] != circuitbreaker.StateOpened {
+		t.Errorf("Expected state change event, got %+v", logs.All())
+	}
+
+	changes := logs.FilterMessage("circuit breaker state changed").All()
+	if len(changes) != 1 || changes[0].ContextMap()["cb.breaker"] != "payments" {
+		t.Errorf("Expected slog record with grouped attributes, got %+v", logs.All())
+	}
+}
@@ -0,0 +1,92 @@
+package circuitbreaker
+
+import "time"
+
+// - tells whether a point in time falls into a window
+type Schedule interface {
+	Active(t time.Time) bool
+}
+
+// - is an explicit [Start, End) time range
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// - check
+func (r TimeRange) Active(t time.Time) bool {
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// - is a recurring daily window, cron-like alternative to TimeRange
+type DailyWindow struct {
+	// offset from midnight when the window starts
+	Start time.Duration
+	// length of the window, may cross midnight
+	Duration time.Duration
+	// days the window starts on, every day when empty
+	Weekdays []time.Weekday
+	// time.Local when nil
+	Location *time.Location
+}
+
+// - check
+func (w DailyWindow) Active(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+
+	// a window started yesterday may still be active after midnight
+	for _, day := range []time.Time{t, t.AddDate(0, 0, -1)} {
+		if !w.startsOn(day.Weekday()) {
+			continue
+		}
+
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		start := midnight.Add(w.Start)
+		if !t.Before(start) && t.Before(start.Add(w.Duration)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startsOn reports whether the window starts on the weekday
+func (w DailyWindow) startsOn(weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, wd := range w.Weekdays {
+		if wd == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// - forces the breaker to State (StateOpened or StateClosed) while Schedule is active,
+// results recorded during the window are ignored
+type MaintenanceWindow struct {
+	Schedule Schedule
+	State    string
+}
+
+// - configures planned maintenance windows, the first active window wins
+func WithMaintenanceWindows(windows ...MaintenanceWindow) Option {
+	return func(cb *CircuitBreaker) {
+		cb.maintenance = append(cb.maintenance, windows...)
+	}
+}
+
+// maintenanceState returns the state forced by an active maintenance window
+func (cb *CircuitBreaker) maintenanceState(now time.Time) (string, bool) {
+	for _, w := range cb.maintenance {
+		if w.Schedule != nil && w.Schedule.Active(now) {
+			return w.State, true
+		}
+	}
+	return "", false
+}
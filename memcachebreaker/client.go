@@ -0,0 +1,183 @@
+// Package memcachebreaker guards gomemcache clients with a circuit breaker per server.
+package memcachebreaker
+
+import (
+	"errors"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is the part of *memcache.Client guarded by Client
+type Memcache interface {
+	Get(key string) (*memcache.Item, error)
+	GetMulti(keys []string) (map[string]*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Add(item *memcache.Item) error
+	Replace(item *memcache.Item) error
+	CompareAndSwap(item *memcache.Item) error
+	Delete(key string) error
+	Increment(key string, delta uint64) (uint64, error)
+	Decrement(key string, delta uint64) (uint64, error)
+	Touch(key string, seconds int32) error
+}
+
+// - reports whether err counts as a failure of the server
+type Classifier func(err error) bool
+
+// - is the default Classifier, cache misses and conflicts are answers of a healthy server
+func ServerErrors(err error) bool {
+	switch {
+	case errors.Is(err, memcache.ErrCacheMiss),
+		errors.Is(err, memcache.ErrCASConflict),
+		errors.Is(err, memcache.ErrNotStored),
+		errors.Is(err, memcache.ErrMalformedKey):
+		return false
+	}
+	return true
+}
+
+// - runs memcache calls through the breaker of the server the key maps to, so a dead
+// node fails fast with ErrOpenState while keys of the other nodes are served
+type Client struct {
+	client   Memcache
+	selector memcache.ServerSelector
+	Breakers *circuitbreaker.KeyedBreaker
+	// defaults to ServerErrors
+	IsFailure Classifier
+}
+
+// - is a constructor, selector must be the one client was built with, see memcache.NewFromSelector
+func NewClient(client Memcache, selector memcache.ServerSelector, breakers *circuitbreaker.KeyedBreaker) *Client {
+	return &Client{client: client, selector: selector, Breakers: breakers, IsFailure: ServerErrors}
+}
+
+// - returns the breaker of the server key maps to
+func (c *Client) Breaker(key string) (*circuitbreaker.CircuitBreaker, error) {
+	addr, err := c.selector.PickServer(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.Breakers.Get(addr.String()), nil
+}
+
+// - is memcache Get
+func (c *Client) Get(key string) (item *memcache.Item, err error) {
+	err = c.guard(key, func() error {
+		item, err = c.client.Get(key)
+		return err
+	})
+	return item, err
+}
+
+// - is memcache GetMulti, keys of servers with an open breaker are left out of the
+// result like cache misses instead of failing the whole call
+func (c *Client) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	byServer := make(map[string][]string)
+	for _, key := range keys {
+		addr, err := c.selector.PickServer(key)
+		if err != nil {
+			return nil, err
+		}
+		byServer[addr.String()] = append(byServer[addr.String()], key)
+	}
+
+	items := make(map[string]*memcache.Item, len(keys))
+	var errs []error
+	for _, serverKeys := range byServer {
+		var found map[string]*memcache.Item
+		err := c.guard(serverKeys[0], func() (err error) {
+			found, err = c.client.GetMulti(serverKeys)
+			return err
+		})
+		if errors.Is(err, circuitbreaker.ErrOpenState) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for key, item := range found {
+			items[key] = item
+		}
+	}
+	return items, errors.Join(errs...)
+}
+
+// - is memcache Set
+func (c *Client) Set(item *memcache.Item) error {
+	return c.guard(item.Key, func() error { return c.client.Set(item) })
+}
+
+// - is memcache Add
+func (c *Client) Add(item *memcache.Item) error {
+	return c.guard(item.Key, func() error { return c.client.Add(item) })
+}
+
+// - is memcache Replace
+func (c *Client) Replace(item *memcache.Item) error {
+	return c.guard(item.Key, func() error { return c.client.Replace(item) })
+}
+
+// - is memcache CompareAndSwap
+func (c *Client) CompareAndSwap(item *memcache.Item) error {
+	return c.guard(item.Key, func() error { return c.client.CompareAndSwap(item) })
+}
+
+// - is memcache Delete
+func (c *Client) Delete(key string) error {
+	return c.guard(key, func() error { return c.client.Delete(key) })
+}
+
+// - is memcache Increment
+func (c *Client) Increment(key string, delta uint64) (value uint64, err error) {
+	err = c.guard(key, func() error {
+		value, err = c.client.Increment(key, delta)
+		return err
+	})
+	return value, err
+}
+
+// - is memcache Decrement
+func (c *Client) Decrement(key string, delta uint64) (value uint64, err error) {
+	err = c.guard(key, func() error {
+		value, err = c.client.Decrement(key, delta)
+		return err
+	})
+	return value, err
+}
+
+// - is memcache Touch
+func (c *Client) Touch(key string, seconds int32) error {
+	return c.guard(key, func() error { return c.client.Touch(key, seconds) })
+}
+
+// guard runs call through the breaker of the key server, errors that are not failures
+// are returned without being recorded
+func (c *Client) guard(key string, call func() error) error {
+	cb, err := c.Breaker(key)
+	if err != nil {
+		return err
+	}
+
+	isFailure := c.IsFailure
+	if isFailure == nil {
+		isFailure = ServerErrors
+	}
+
+	var (
+		called  bool
+		callErr error
+	)
+	err = cb.Execute(func() error {
+		called = true
+		callErr = call()
+		if callErr != nil && isFailure(callErr) {
+			return callErr
+		}
+		return nil
+	})
+	if !called {
+		return err
+	}
+	return callErr
+}
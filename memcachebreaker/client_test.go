@@ -0,0 +1,111 @@
+package memcachebreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// fakeMemcache stores items in memory, keys of the dead server fail
+type fakeMemcache struct {
+	Memcache
+	selector memcache.ServerSelector
+	dead     string
+	items    map[string]*memcache.Item
+}
+
+func (f *fakeMemcache) check(key string) error {
+	addr, _ := f.selector.PickServer(key)
+	if addr.String() == f.dead {
+		return errors.New("i/o timeout")
+	}
+	return nil
+}
+
+func (f *fakeMemcache) Get(key string) (*memcache.Item, error) {
+	if err := f.check(key); err != nil {
+		return nil, err
+	}
+	item, ok := f.items[key]
+	if !ok {
+		return nil, memcache.ErrCacheMiss
+	}
+	return item, nil
+}
+
+func (f *fakeMemcache) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	items := make(map[string]*memcache.Item)
+	for _, key := range keys {
+		item, err := f.Get(key)
+		if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, err
+		}
+		if item != nil {
+			items[key] = item
+		}
+	}
+	return items, nil
+}
+
+func newClient(t *testing.T) (*Client, *fakeMemcache) {
+	selector := new(memcache.ServerList)
+	if err := selector.SetServers("127.0.0.1:11211", "127.0.0.1:11212"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	fake := &fakeMemcache{selector: selector, items: make(map[string]*memcache.Item)}
+	breakers := circuitbreaker.NewKeyedBreaker(func(key string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(1),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+			circuitbreaker.WithName(key),
+		)
+	})
+	return NewClient(fake, selector, breakers), fake
+}
+
+// keysOn returns keys mapped to two different servers
+func keysOn(t *testing.T, selector memcache.ServerSelector) (string, string, string) {
+	first, _ := selector.PickServer("key0")
+	for i := 1; i < 100; i++ {
+		key := "key" + string(rune('0'+i%10)) + string(rune('a'+i/10))
+		if addr, _ := selector.PickServer(key); addr.String() != first.String() {
+			return "key0", key, first.String()
+		}
+	}
+	t.Fatal("Expected keys on two servers")
+	return "", "", ""
+}
+
+func TestDeadNodeFailsFast(t *testing.T) {
+	client, fake := newClient(t)
+	deadKey, liveKey, dead := keysOn(t, fake.selector)
+	fake.dead = dead
+	fake.items[liveKey] = &memcache.Item{Key: liveKey, Value: []byte("v")}
+
+	if _, err := client.Get(deadKey); err == nil || errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Fatalf("Expected server error, got %v", err)
+	}
+	if _, err := client.Get(deadKey); !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Errorf("Expected %v, got %v", circuitbreaker.ErrOpenState, err)
+	}
+
+	items, err := client.GetMulti([]string{deadKey, liveKey})
+	if err != nil || len(items) != 1 || items[liveKey] == nil {
+		t.Errorf("Expected keys of the live server only, got %v, %v", items, err)
+	}
+}
+
+func TestCacheMissIsNotFailure(t *testing.T) {
+	client, fake := newClient(t)
+	key, _, _ := keysOn(t, fake.selector)
+
+	for range 3 {
+		if _, err := client.Get(key); !errors.Is(err, memcache.ErrCacheMiss) {
+			t.Fatalf("Expected %v, got %v", memcache.ErrCacheMiss, err)
+		}
+	}
+}
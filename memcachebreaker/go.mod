@@ -0,0 +1,10 @@
+module github.com/nick1jesky/circuit_breaker/memcachebreaker
+
+go 1.24.2
+
+require (
+	github.com/bradfitz/gomemcache v0.0.0-20230905024940-24af94b03874
+	github.com/nick1jesky/circuit_breaker v0.0.0
+)
+
+replace github.com/nick1jesky/circuit_breaker => ../
@@ -0,0 +1,73 @@
+package circuitbreaker
+
+import (
+	"maps"
+	"time"
+)
+
+// - is a snapshot of breaker metrics
+type Metrics struct {
+	State  string
+	Counts Counts
+	// calls admitted and rejected by Allow over the breaker lifetime
+	Admitted int64
+	Rejected int64
+	// calls admitted whatever the state, see WithBypass
+	Bypassed int64
+	// calls rejected since the last state transition, i.e. during the current open period
+	RejectedInState int64
+	// calls recorded with RecordIgnore, e.g. aborted by the caller
+	Ignored int64
+	// protected calls currently running in Execute
+	Inflight int64
+	// the closed breaker sheds calls, see WithSoftOpen
+	Degraded bool
+	// State is a simulated open state, see SimulateOpen
+	Simulated bool
+	Latency   LatencySnapshot
+	// latency per TagOperation of the calls, see WithTags
+	Operations map[string]LatencySnapshot
+}
+
+// - returns current metrics of the breaker
+func (cb *CircuitBreaker) Metrics() Metrics {
+	state := cb.State()
+
+	cb.mu.RLock()
+	metrics := Metrics{
+		State:           state,
+		Counts:          cb.counts(),
+		Admitted:        cb.admitted,
+		Rejected:        cb.rejected,
+		Bypassed:        cb.bypassed,
+		RejectedInState: cb.rejectedInState,
+		Ignored:         cb.ignored,
+		Inflight:        cb.inflight,
+		Degraded:        cb.soft != nil && cb.soft.degraded && cb.state == StateClosed,
+		Simulated:       cb.drill != nil,
+	}
+	if metrics.Simulated {
+		metrics.State = StateOpened
+	}
+	operations := maps.Clone(cb.operations)
+	cb.mu.RUnlock()
+
+	metrics.Latency = cb.latency.Snapshot()
+	if len(operations) > 0 {
+		metrics.Operations = make(map[string]LatencySnapshot, len(operations))
+		for operation, histogram := range operations {
+			metrics.Operations[operation] = histogram.Snapshot()
+		}
+	}
+	return metrics
+}
+
+// - records latency of a protected call
+func (cb *CircuitBreaker) RecordLatency(d time.Duration) {
+	cb.latency.Record(d)
+}
+
+// - returns the latency histogram of protected calls
+func (cb *CircuitBreaker) Latency() *LatencyHistogram {
+	return cb.latency
+}
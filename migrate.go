@@ -0,0 +1,66 @@
+package circuitbreaker
+
+import (
+	"slices"
+	"time"
+)
+
+// - is an optional extension of window-based thresholds whose history can be carried
+// over to the threshold replacing them in UpdateValues, see WithThresholdMigration
+type HistoryThreshold interface {
+	// History returns the outcomes within the window, oldest first
+	History() []Outcome
+	// ImportHistory adds outcomes of the replaced threshold, outcomes outside
+	// of the own window are dropped
+	ImportHistory(outcomes []Outcome)
+}
+
+// - makes UpdateValues migrate the history of a replaced window-based threshold into
+// its replacement when both implement HistoryThreshold, so a reconfiguration does not
+// make the breaker forget the failures it has just seen
+func WithThresholdMigration() Option {
+	return func(cb *CircuitBreaker) {
+		cb.migrateThresholds = true
+	}
+}
+
+// migrateHistory copies the history of from into to, must be called under write lock
+func (cb *CircuitBreaker) migrateHistory(from, to CustomThreshold) {
+	if !cb.migrateThresholds || from == to {
+		return
+	}
+	source, ok := from.(HistoryThreshold)
+	if !ok {
+		return
+	}
+	if target, ok := to.(HistoryThreshold); ok {
+		target.ImportHistory(source.History())
+	}
+}
+
+// - returns the failures within the window
+func (sw *SlidingWindowThreshold) History() []Outcome {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.prune()
+	history := make([]Outcome, len(sw.failureTimes))
+	for i, ft := range sw.failureTimes {
+		history[i] = Outcome{Time: ft}
+	}
+	return history
+}
+
+// - adds the failures of outcomes, successes are not tracked by the window
+func (sw *SlidingWindowThreshold) ImportHistory(outcomes []Outcome) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	for _, o := range outcomes {
+		if !o.Success {
+			sw.failureTimes = append(sw.failureTimes, o.Time)
+		}
+	}
+	slices.SortFunc(sw.failureTimes, time.Time.Compare)
+	sw.prune()
+}
@@ -0,0 +1,10 @@
+module github.com/nick1jesky/circuit_breaker/mongobreaker
+
+go 1.24.2
+
+require (
+	github.com/nick1jesky/circuit_breaker v0.0.0
+	go.mongodb.org/mongo-driver v1.17.6
+)
+
+replace github.com/nick1jesky/circuit_breaker => ../
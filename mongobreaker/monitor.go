@@ -0,0 +1,115 @@
+// Package mongobreaker feeds MongoDB driver monitoring events into circuit breakers.
+package mongobreaker
+
+import (
+	"context"
+	"strings"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// - reports whether a failed command counts as a failure of the cluster
+type Classifier func(e *event.CommandFailedEvent) bool
+
+// - is the default Classifier, every failed command counts
+func AllFailures(*event.CommandFailedEvent) bool {
+	return true
+}
+
+// - returns a Classifier ignoring failures containing any of substrings, e.g. "E11000"
+// for duplicate keys, which are caused by the caller rather than by the cluster
+func IgnoreFailures(substrings ...string) Classifier {
+	return func(e *event.CommandFailedEvent) bool {
+		for _, s := range substrings {
+			if strings.Contains(e.Failure, s) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// - feeds command results and server heartbeats of one cluster into its breaker,
+// the breaker is looked up in Breakers by the cluster name
+type Monitor struct {
+	Breakers *circuitbreaker.KeyedBreaker
+	Cluster  string
+	// defaults to AllFailures
+	IsFailure Classifier
+}
+
+// - is a constructor
+func NewMonitor(breakers *circuitbreaker.KeyedBreaker, cluster string) *Monitor {
+	return &Monitor{Breakers: breakers, Cluster: cluster, IsFailure: AllFailures}
+}
+
+// - returns the breaker of the cluster
+func (m *Monitor) Breaker() *circuitbreaker.CircuitBreaker {
+	return m.Breakers.Get(m.Cluster)
+}
+
+// - returns a monitor for options.Client().SetMonitor recording command results,
+// next (may be nil) receives every event afterwards
+func (m *Monitor) CommandMonitor(next *event.CommandMonitor) *event.CommandMonitor {
+	if next == nil {
+		next = &event.CommandMonitor{}
+	}
+	return &event.CommandMonitor{
+		Started: next.Started,
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			m.Breaker().RecordSuccess()
+			if next.Succeeded != nil {
+				next.Succeeded(ctx, e)
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			if m.isFailure(e) {
+				m.Breaker().RecordFailure()
+			}
+			if next.Failed != nil {
+				next.Failed(ctx, e)
+			}
+		},
+	}
+}
+
+// - returns a monitor for options.Client().SetServerMonitor recording heartbeats,
+// next (may be nil) receives every event afterwards
+func (m *Monitor) ServerMonitor(next *event.ServerMonitor) *event.ServerMonitor {
+	monitor := &event.ServerMonitor{}
+	if next != nil {
+		*monitor = *next
+	}
+	monitor.ServerHeartbeatSucceeded = func(e *event.ServerHeartbeatSucceededEvent) {
+		m.Breaker().RecordSuccess()
+		if next != nil && next.ServerHeartbeatSucceeded != nil {
+			next.ServerHeartbeatSucceeded(e)
+		}
+	}
+	monitor.ServerHeartbeatFailed = func(e *event.ServerHeartbeatFailedEvent) {
+		m.Breaker().RecordFailure()
+		if next != nil && next.ServerHeartbeatFailed != nil {
+			next.ServerHeartbeatFailed(e)
+		}
+	}
+	return monitor
+}
+
+// isFailure applies the classifier
+func (m *Monitor) isFailure(e *event.CommandFailedEvent) bool {
+	if m.IsFailure == nil {
+		return AllFailures(e)
+	}
+	return m.IsFailure(e)
+}
+
+// - runs fn when the cluster breaker admits it and returns ErrOpenState otherwise,
+// the outcome is recorded by the monitors rather than by Execute
+func Execute[T any](ctx context.Context, m *Monitor, fn func(ctx context.Context) (T, error)) (T, error) {
+	if !m.Breaker().Allow() {
+		var zero T
+		return zero, circuitbreaker.ErrOpenState
+	}
+	return fn(ctx)
+}
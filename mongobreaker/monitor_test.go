@@ -0,0 +1,70 @@
+package mongobreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func newMonitor() *Monitor {
+	breakers := circuitbreaker.NewKeyedBreaker(func(key string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(2),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+			circuitbreaker.WithName(key),
+		)
+	})
+	return NewMonitor(breakers, "orders-cluster")
+}
+
+func TestCommandFailuresOpenCluster(t *testing.T) {
+	monitor := newMonitor()
+	monitor.IsFailure = IgnoreFailures("E11000")
+
+	var forwarded int
+	commands := monitor.CommandMonitor(&event.CommandMonitor{
+		Failed: func(context.Context, *event.CommandFailedEvent) { forwarded++ },
+	})
+	ctx := context.Background()
+
+	commands.Failed(ctx, &event.CommandFailedEvent{Failure: "(DuplicateKey) E11000 duplicate key error"})
+	commands.Failed(ctx, &event.CommandFailedEvent{Failure: "connection reset by peer"})
+	if state := monitor.Breaker().State(); state != circuitbreaker.StateClosed {
+		t.Fatalf("Expected duplicate key not to count, got %s", state)
+	}
+	commands.Failed(ctx, &event.CommandFailedEvent{Failure: "connection reset by peer"})
+	if forwarded != 3 {
+		t.Errorf("Expected events forwarded to the next monitor, got %d", forwarded)
+	}
+
+	called := false
+	_, err := Execute(ctx, monitor, func(context.Context) (int, error) {
+		called = true
+		return 0, nil
+	})
+	if !errors.Is(err, circuitbreaker.ErrOpenState) || called {
+		t.Errorf("Expected operation to be blocked, got %v", err)
+	}
+}
+
+func TestHeartbeatFailures(t *testing.T) {
+	monitor := newMonitor()
+	servers := monitor.ServerMonitor(nil)
+
+	servers.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{Failure: errors.New("timeout")})
+	servers.ServerHeartbeatSucceeded(&event.ServerHeartbeatSucceededEvent{})
+	servers.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{Failure: errors.New("timeout")})
+	if state := monitor.Breaker().State(); state != circuitbreaker.StateClosed {
+		t.Fatalf("Expected successful heartbeat to reset failures, got %s", state)
+	}
+
+	servers.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{Failure: errors.New("timeout")})
+	if state := monitor.Breaker().State(); state != circuitbreaker.StateOpened {
+		t.Errorf("Expected state %s, got %s", circuitbreaker.StateOpened, state)
+	}
+}
@@ -0,0 +1,152 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// number of buckets a BurnWindow is divided into
+const burnWindowBuckets = 60
+
+// - is a failure rate over a sliding window, e.g. 5% over an hour
+type BurnWindow struct {
+	Window time.Duration
+	Rate   float64
+	// the window is not evaluated until it holds MinCalls results
+	MinCalls int64
+}
+
+// - combines the windows of MultiWindowTrip
+type WindowMode int
+
+const (
+	// every window must exceed its rate, fewer trips on short spikes
+	AllWindows WindowMode = iota
+	// any window exceeding its rate trips, faster detection
+	AnyWindow
+)
+
+// - is a TripPolicy over several failure rate windows, mirroring multi-burn-rate
+// SLO alerting: with AllWindows a long window (5% over 1h) confirms a short one
+// (20% over 5m) so a brief spike does not flap the circuit, while the short window
+// keeps the detection of a sustained outage fast, the windows are reset when the
+// circuit closes
+type MultiWindowTrip struct {
+	mode WindowMode
+
+	mu      sync.Mutex
+	windows []*burnCounter
+	// time of the last result, see DebugWindow
+	last time.Time
+}
+
+type burnCounter struct {
+	BurnWindow
+	width   time.Duration
+	buckets [burnWindowBuckets]burnBucket
+}
+
+type burnBucket struct {
+	index    int64
+	calls    int64
+	failures int64
+}
+
+// - is a constructor
+func NewMultiWindowTrip(mode WindowMode, windows ...BurnWindow) *MultiWindowTrip {
+	t := &MultiWindowTrip{mode: mode}
+	for _, w := range windows {
+		t.windows = append(t.windows, &burnCounter{
+			BurnWindow: w,
+			width:      max(w.Window/burnWindowBuckets, time.Nanosecond),
+		})
+	}
+	return t
+}
+
+// - records the result of stats and checks the windows
+func (t *MultiWindowTrip) ShouldTrip(stats Stats) (bool, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.windows) == 0 {
+		return false, ""
+	}
+
+	t.last = stats.Time
+	for _, w := range t.windows {
+		w.record(stats.Time, stats.Success)
+	}
+
+	var exceeded []string
+	for _, w := range t.windows {
+		rate, ok := w.rate(stats.Time)
+		if ok && rate >= w.Rate {
+			exceeded = append(exceeded, fmt.Sprintf("%.1f%% over %s", rate*100, w.Window))
+		} else if t.mode == AllWindows {
+			return false, ""
+		}
+	}
+	if len(exceeded) == 0 {
+		return false, ""
+	}
+	return true, "failure rate " + strings.Join(exceeded, " and ")
+}
+
+// - drops the recorded results
+func (t *MultiWindowTrip) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, w := range t.windows {
+		w.buckets = [burnWindowBuckets]burnBucket{}
+	}
+}
+
+// - returns the failure rate of every window at now, windows below MinCalls report zero
+func (t *MultiWindowTrip) Rates(now time.Time) []float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rates := make([]float64, len(t.windows))
+	for i, w := range t.windows {
+		rates[i], _ = w.rate(now)
+	}
+	return rates
+}
+
+// record adds a result to the bucket of now
+func (w *burnCounter) record(now time.Time, success bool) {
+	index := now.UnixNano() / int64(w.width)
+	bucket := &w.buckets[index%burnWindowBuckets]
+	if bucket.index != index {
+		*bucket = burnBucket{index: index}
+	}
+	bucket.calls++
+	if !success {
+		bucket.failures++
+	}
+}
+
+// rate returns the failure rate of the buckets within the window, false below MinCalls
+func (w *burnCounter) rate(now time.Time) (float64, bool) {
+	calls, failures := w.counts(now)
+	if calls == 0 || calls < w.MinCalls {
+		return 0, false
+	}
+	return float64(failures) / float64(calls), true
+}
+
+// counts sums the buckets within the window
+func (w *burnCounter) counts(now time.Time) (calls, failures int64) {
+	current := now.UnixNano() / int64(w.width)
+	for _, bucket := range w.buckets {
+		if bucket.calls > 0 && current-bucket.index < burnWindowBuckets {
+			calls += bucket.calls
+			failures += bucket.failures
+		}
+	}
+	return calls, failures
+}
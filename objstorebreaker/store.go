@@ -0,0 +1,151 @@
+// Package objstorebreaker wraps object storage clients (S3 and compatible) with circuit breakers.
+package objstorebreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is recorded as the failure of calls whose first byte arrived later than
+// Store.SlowFirstByte, the caller still gets the result of the call
+var ErrSlowCall = errors.New("object store call exceeded first byte latency")
+
+// - describes a stored object
+type ObjectInfo struct {
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// - is the subset of object store operations wrapped by Store, adapters for
+// a concrete SDK implement it
+type ObjectStore interface {
+	// returns once the response headers arrived, the body is streamed by the reader
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error)
+	Put(ctx context.Context, bucket, key string, body io.Reader, size int64) error
+	Head(ctx context.Context, bucket, key string) (ObjectInfo, error)
+}
+
+// - maps a bucket to the key its breaker is registered with
+type KeyFunc func(bucket string) string
+
+// - keys breakers by bucket
+func KeyByBucket(bucket string) string {
+	return bucket
+}
+
+// - returns a KeyFunc sharing one breaker for every bucket of the endpoint
+func KeyByEndpoint(endpoint string) KeyFunc {
+	return func(string) string { return endpoint }
+}
+
+// - reports whether err counts as a failure of the store, e.g. not found does not
+type Classifier func(err error) bool
+
+// - guards object store calls with keyed breakers, slow calls are classified by the
+// first byte latency only: the time until Get returns the body reader and, for Put,
+// the time from the end of the upload until the response, so large transfers do not
+// count as slow calls
+type Store struct {
+	store    ObjectStore
+	breakers *circuitbreaker.KeyedBreaker
+	key      KeyFunc
+
+	// nil counts every error
+	IsFailure Classifier
+	// calls with a larger first byte latency are recorded as failures, zero disables the check
+	SlowFirstByte time.Duration
+}
+
+// - is a constructor, nil key means KeyByBucket
+func NewStore(store ObjectStore, breakers *circuitbreaker.KeyedBreaker, key KeyFunc) *Store {
+	if key == nil {
+		key = KeyByBucket
+	}
+	return &Store{store: store, breakers: breakers, key: key}
+}
+
+// - returns the breaker of the bucket
+func (s *Store) Breaker(bucket string) *circuitbreaker.CircuitBreaker {
+	return s.breakers.Get(s.key(bucket))
+}
+
+// - is ObjectStore Get, the body is not part of the guarded call
+func (s *Store) Get(ctx context.Context, bucket, key string) (body io.ReadCloser, info ObjectInfo, err error) {
+	err = s.guard(ctx, bucket, func(ctx context.Context) (time.Duration, error) {
+		start := time.Now()
+		body, info, err = s.store.Get(ctx, bucket, key)
+		return time.Since(start), err
+	})
+	return body, info, err
+}
+
+// - is ObjectStore Put
+func (s *Store) Put(ctx context.Context, bucket, key string, body io.Reader, size int64) error {
+	return s.guard(ctx, bucket, func(ctx context.Context) (time.Duration, error) {
+		start := time.Now()
+		upload := &uploadReader{reader: body}
+		err := s.store.Put(ctx, bucket, key, upload, size)
+		if sent := upload.sentAt.Load(); sent != nil {
+			start = *sent
+		}
+		return time.Since(start), err
+	})
+}
+
+// - is ObjectStore Head
+func (s *Store) Head(ctx context.Context, bucket, key string) (info ObjectInfo, err error) {
+	err = s.guard(ctx, bucket, func(ctx context.Context) (time.Duration, error) {
+		start := time.Now()
+		info, err = s.store.Head(ctx, bucket, key)
+		return time.Since(start), err
+	})
+	return info, err
+}
+
+// guard runs call through the bucket breaker, call returns its first byte latency,
+// errors that are not failures are returned without being recorded
+func (s *Store) guard(ctx context.Context, bucket string, call func(ctx context.Context) (time.Duration, error)) error {
+	var (
+		called  bool
+		callErr error
+	)
+	err := s.Breaker(bucket).ExecuteContext(ctx, func(ctx context.Context) error {
+		called = true
+		var firstByte time.Duration
+		firstByte, callErr = call(ctx)
+
+		if callErr != nil && (s.IsFailure == nil || s.IsFailure(callErr)) {
+			return callErr
+		}
+		if callErr == nil && s.SlowFirstByte > 0 && firstByte > s.SlowFirstByte {
+			return fmt.Errorf("%w: %s", ErrSlowCall, firstByte)
+		}
+		return nil
+	})
+	if !called {
+		return err
+	}
+	return callErr
+}
+
+// uploadReader remembers when the upload body was read to the end
+type uploadReader struct {
+	reader io.Reader
+	sentAt atomic.Pointer[time.Time]
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		now := time.Now()
+		r.sentAt.CompareAndSwap(nil, &now)
+	}
+	return n, err
+}
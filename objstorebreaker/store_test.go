@@ -0,0 +1,102 @@
+package objstorebreaker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+var errNotFound = errors.New("not found")
+
+// slowStore takes headerDelay to answer and reads uploads at a slow pace
+type slowStore struct {
+	headerDelay time.Duration
+	objects     map[string][]byte
+}
+
+func (s *slowStore) Get(_ context.Context, bucket, key string) (io.ReadCloser, ObjectInfo, error) {
+	time.Sleep(s.headerDelay)
+	data, ok := s.objects[bucket+"/"+key]
+	if !ok {
+		return nil, ObjectInfo{}, errNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), ObjectInfo{Size: int64(len(data))}, nil
+}
+
+func (s *slowStore) Put(_ context.Context, bucket, key string, body io.Reader, _ int64) error {
+	var buf bytes.Buffer
+	chunk := make([]byte, 4)
+	for {
+		// the transfer is slow, the answer after the upload is fast
+		time.Sleep(5 * time.Millisecond)
+		n, err := body.Read(chunk)
+		buf.Write(chunk[:n])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	s.objects[bucket+"/"+key] = buf.Bytes()
+	time.Sleep(s.headerDelay)
+	return nil
+}
+
+func (s *slowStore) Head(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	_, info, err := s.Get(ctx, bucket, key)
+	return info, err
+}
+
+func newStore(backend ObjectStore) *Store {
+	breakers := circuitbreaker.NewKeyedBreaker(func(key string) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(1),
+			circuitbreaker.NewInt64Threshold(1),
+			time.Minute,
+			circuitbreaker.WithName(key),
+		)
+	})
+	store := NewStore(backend, breakers, nil)
+	store.IsFailure = func(err error) bool { return !errors.Is(err, errNotFound) }
+	store.SlowFirstByte = 20 * time.Millisecond
+	return store
+}
+
+func TestTransferTimeIsNotSlow(t *testing.T) {
+	backend := &slowStore{objects: make(map[string][]byte)}
+	store := newStore(backend)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "media", "video", strings.NewReader("a large object body"), 19); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := store.Head(ctx, "media", "missing"); !errors.Is(err, errNotFound) {
+		t.Fatalf("Expected %v, got %v", errNotFound, err)
+	}
+	if state := store.Breaker("media").State(); state != circuitbreaker.StateClosed {
+		t.Errorf("Expected long upload and not found to be successes, got %s", state)
+	}
+}
+
+func TestSlowFirstByteOpensBucket(t *testing.T) {
+	backend := &slowStore{headerDelay: 30 * time.Millisecond, objects: map[string][]byte{"logs/a": []byte("a")}}
+	store := newStore(backend)
+	ctx := context.Background()
+
+	body, _, err := store.Get(ctx, "logs", "a")
+	if err != nil {
+		t.Fatalf("Expected slow call to return its result, got %v", err)
+	}
+	body.Close()
+
+	if _, _, err := store.Get(ctx, "logs", "a"); !errors.Is(err, circuitbreaker.ErrOpenState) {
+		t.Errorf("Expected %v, got %v", circuitbreaker.ErrOpenState, err)
+	}
+	if state := store.Breaker("media").State(); state != circuitbreaker.StateClosed {
+		t.Errorf("Expected other bucket to stay closed, got %s", state)
+	}
+}
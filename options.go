@@ -0,0 +1,51 @@
+package circuitbreaker
+
+// - configures optional behaviour of the circuit breaker
+type Option func(*CircuitBreaker)
+
+// - drives the open -> half-open transition by a timer instead of lazily inside Allow/State,
+// so events are emitted when the transition logically happens
+func WithTransitionTimer() Option {
+	return func(cb *CircuitBreaker) {
+		cb.transitionTimer = true
+	}
+}
+
+// - registers handler for breaker events
+func WithEventHandler(handler EventHandler) Option {
+	return func(cb *CircuitBreaker) {
+		cb.eventHandlers = append(cb.eventHandlers, handler)
+	}
+}
+
+// - sets the breaker name used by the registry, events and admin API
+func WithName(name string) Option {
+	return func(cb *CircuitBreaker) {
+		cb.name = name
+	}
+}
+
+// - stops counting context.Canceled and context.DeadlineExceeded caused by the caller
+// as dependency failures in Execute and ExecuteContext, such calls are not recorded
+func IgnoreContextErrors(ignore bool) Option {
+	return func(cb *CircuitBreaker) {
+		cb.ignoreContextErrors = ignore
+	}
+}
+
+// - sets how many calls must be recorded before a Float64Threshold ratio is evaluated,
+// so a single failure can not open the breaker with a 100% failure rate
+func WithMinimumCalls(n int64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.minimumCalls = n
+	}
+}
+
+// - makes Execute and ExecuteContext wrap returned errors with the breaker name
+// and the state the call ran in, e.g. `breaker "payments" (half-open): timeout`,
+// the original error stays available to errors.Is and errors.As
+func WithErrorWrapping() Option {
+	return func(cb *CircuitBreaker) {
+		cb.wrapErrors = true
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/nick1jesky/circuit_breaker/otelbreaker
+
+go 1.24.2
+
+require (
+	github.com/nick1jesky/circuit_breaker v0.0.0
+	go.opentelemetry.io/otel/trace v1.41.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.opentelemetry.io/otel v1.41.0 // indirect
+)
+
+replace github.com/nick1jesky/circuit_breaker => ../
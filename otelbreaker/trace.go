@@ -0,0 +1,36 @@
+// Package otelbreaker links circuit breaker calls to OpenTelemetry traces.
+package otelbreaker
+
+import (
+	"context"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// - is the tag holding the span ID of the call
+const TagSpanID = "span_id"
+
+// - returns ctx tagged with the trace and span IDs of the active span, so the events
+// and the transition a call causes point to its trace, ctx is returned unchanged
+// without a valid span
+func WithTraceTags(ctx context.Context) context.Context {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ctx
+	}
+
+	return circuitbreaker.WithTags(ctx, circuitbreaker.Tags{
+		circuitbreaker.TagTraceID: spanContext.TraceID().String(),
+		TagSpanID:                 spanContext.SpanID().String(),
+	})
+}
+
+// - is circuitbreaker.ExecuteContext with the call tagged by WithTraceTags
+func ExecuteContext[T any](
+	ctx context.Context,
+	cb *circuitbreaker.CircuitBreaker,
+	fn func(ctx context.Context) (T, error),
+) (T, error) {
+	return circuitbreaker.ExecuteContext(WithTraceTags(ctx), cb, fn)
+}
@@ -0,0 +1,44 @@
+package otelbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceTags(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	var events []circuitbreaker.Event
+	cb := circuitbreaker.NewCircuitBreaker(
+		circuitbreaker.NewInt64Threshold(1),
+		circuitbreaker.NewInt64Threshold(1),
+		time.Minute,
+		circuitbreaker.WithEventHandler(func(e circuitbreaker.Event) { events = append(events, e) }),
+	)
+
+	_, err := ExecuteContext(ctx, cb, func(context.Context) (int, error) { return 0, errors.New("downstream error") })
+	if err == nil {
+		t.Fatal("Expected downstream error")
+	}
+
+	if len(events) != 1 || events[0].Tags[circuitbreaker.TagTraceID] != traceID.String() {
+		t.Errorf("Expected transition event with trace ID, got %+v", events)
+	}
+	if tr := cb.LastTransition(); tr.Tags[TagSpanID] != spanID.String() {
+		t.Errorf("Expected transition with span ID, got %+v", tr)
+	}
+
+	if tagged := WithTraceTags(context.Background()); circuitbreaker.TagsFrom(tagged) != nil {
+		t.Error("Expected no tags without a span")
+	}
+}
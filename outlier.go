@@ -0,0 +1,259 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// - configures OutlierDetector, zero values disable the respective detection
+type OutlierConfig struct {
+	// consecutive failures of a host ejecting it, like consecutive_5xx of Envoy
+	ConsecutiveFailures int64
+	// hosts whose success rate in the interval is below the mean minus
+	// SuccessRateStdevFactor standard deviations of all hosts are ejected
+	SuccessRateStdevFactor float64
+	// success rates are evaluated only when at least SuccessRateMinHosts hosts
+	// had SuccessRateRequestVolume calls in the interval
+	SuccessRateMinHosts      int
+	SuccessRateRequestVolume int64
+	// the period of Run
+	Interval time.Duration
+	// a host is ejected for BaseEjectionTime times the number of its ejections,
+	// up to MaxEjectionTime when it is set
+	BaseEjectionTime time.Duration
+	MaxEjectionTime  time.Duration
+	// share of hosts ejected at the same time in percent, at least one host
+	// can always be ejected, 0 means no limit
+	MaxEjectionPercent float64
+}
+
+// - is an Envoy-style outlier detection over the breakers of load-balanced hosts:
+// a host exceeding the consecutive failure or success rate deviation threshold is
+// ejected by opening its breaker for the computed ejection time, it is reinserted
+// when the half-open probes close the breaker, load balancers skipping open
+// circuits such as httpbreaker.Upstreams skip ejected hosts
+type OutlierDetector struct {
+	breakers *KeyedBreaker
+	config   OutlierConfig
+
+	mu    sync.Mutex
+	hosts map[string]*outlierHost
+}
+
+type outlierHost struct {
+	consecutive int64
+	calls       int64
+	failures    int64
+	ejections   int
+	ejected     bool
+}
+
+// - is a constructor, breakers are keyed by host
+func NewOutlierDetector(breakers *KeyedBreaker, config OutlierConfig) *OutlierDetector {
+	return &OutlierDetector{
+		breakers: breakers,
+		config:   config,
+		hosts:    make(map[string]*outlierHost),
+	}
+}
+
+// - runs fn through the breaker of host and records the result for detection
+func (d *OutlierDetector) Execute(ctx context.Context, host string, fn func(ctx context.Context) error) error {
+	called := false
+	err := d.breakers.Get(host).ExecuteContext(ctx, func(ctx context.Context) error {
+		called = true
+		return fn(ctx)
+	})
+	if called && ctx.Err() == nil {
+		d.Record(host, err == nil)
+	}
+	return err
+}
+
+// - records the result of a call to host that went through its breaker, for callers
+// not using Execute
+func (d *OutlierDetector) Record(host string, success bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := d.host(host)
+	h.calls++
+	if success {
+		h.consecutive = 0
+		return
+	}
+	h.failures++
+	h.consecutive++
+
+	if d.config.ConsecutiveFailures > 0 && h.consecutive >= d.config.ConsecutiveFailures {
+		d.eject(host, h, fmt.Sprintf("outlier: %d consecutive failures", h.consecutive))
+	}
+}
+
+// - evaluates the success rates of the interval and starts a new one, Run calls it
+// every Interval
+func (d *OutlierDetector) Sweep() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, host := range d.outliers() {
+		h := d.hosts[host]
+		rate := float64(h.calls-h.failures) / float64(h.calls)
+		d.eject(host, h, fmt.Sprintf("outlier: success rate %.1f%%", rate*100))
+	}
+
+	for host, h := range d.hosts {
+		// hosts staying in rotation for an interval earn back a shorter ejection
+		if !d.isEjected(host, h) && h.ejections > 0 && h.failures == 0 {
+			h.ejections--
+		}
+		h.calls, h.failures = 0, 0
+	}
+}
+
+// - calls Sweep every Interval until ctx is done, run it in its own goroutine
+func (d *OutlierDetector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Sweep()
+		}
+	}
+}
+
+// - returns the hosts currently ejected, sorted
+func (d *OutlierDetector) Ejected() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var hosts []string
+	for host, h := range d.hosts {
+		if d.isEjected(host, h) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// host returns the detection state of host, must be called under lock
+func (d *OutlierDetector) host(host string) *outlierHost {
+	h, ok := d.hosts[host]
+	if !ok {
+		h = &outlierHost{}
+		d.hosts[host] = h
+	}
+	return h
+}
+
+// outliers returns the hosts whose success rate deviates from the mean, must be called under lock
+func (d *OutlierDetector) outliers() []string {
+	if d.config.SuccessRateStdevFactor <= 0 {
+		return nil
+	}
+
+	rates := make(map[string]float64)
+	for host, h := range d.hosts {
+		if h.calls > 0 && h.calls >= d.config.SuccessRateRequestVolume && !d.isEjected(host, h) {
+			rates[host] = float64(h.calls-h.failures) / float64(h.calls)
+		}
+	}
+	if len(rates) < d.config.SuccessRateMinHosts {
+		return nil
+	}
+	return deviating(rates, d.config.SuccessRateStdevFactor)
+}
+
+// deviating returns the keys whose success rate is below the mean minus factor
+// standard deviations, sorted
+func deviating(rates map[string]float64, factor float64) []string {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	var mean float64
+	for _, rate := range rates {
+		mean += rate
+	}
+	mean /= float64(len(rates))
+
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	limit := mean - factor*math.Sqrt(variance/float64(len(rates)))
+
+	var outliers []string
+	for key, rate := range rates {
+		if rate < limit {
+			outliers = append(outliers, key)
+		}
+	}
+	sort.Strings(outliers)
+	return outliers
+}
+
+// eject opens the breaker of host unless too many hosts are ejected, must be called under lock
+func (d *OutlierDetector) eject(host string, h *outlierHost, reason string) {
+	if d.isEjected(host, h) || !d.canEject() {
+		return
+	}
+
+	timeout := d.config.BaseEjectionTime * time.Duration(h.ejections+1)
+	if d.config.MaxEjectionTime > 0 {
+		timeout = min(timeout, d.config.MaxEjectionTime)
+	}
+	if d.breakers.Get(host).eject(timeout, reason) {
+		h.ejections++
+		h.ejected = true
+		h.consecutive = 0
+	}
+}
+
+// canEject applies MaxEjectionPercent, must be called under lock
+func (d *OutlierDetector) canEject() bool {
+	if d.config.MaxEjectionPercent <= 0 {
+		return true
+	}
+
+	ejected := 0
+	for host, h := range d.hosts {
+		if d.isEjected(host, h) {
+			ejected++
+		}
+	}
+	return ejected == 0 || float64(ejected+1)*100 <= d.config.MaxEjectionPercent*float64(len(d.hosts))
+}
+
+// isEjected reports whether host is out of rotation, a host is reinserted once
+// its breaker closes, must be called under lock
+func (d *OutlierDetector) isEjected(host string, h *outlierHost) bool {
+	if h.ejected && d.breakers.Get(host).State() == StateClosed {
+		h.ejected = false
+	}
+	return h.ejected
+}
+
+// eject opens the circuit for timeout, the timeout replaces the open timeout
+// until the breaker closes so a failed probe re-ejects for the same time, zero
+// keeps the open timeout, false when the circuit is already open or the state is forced
+func (cb *CircuitBreaker) eject(timeout time.Duration, reason string) bool {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if _, forced := cb.forcedState(cb.clock.Now()); forced || cb.state == StateOpened {
+		return false
+	}
+	cb.ejectTimeout = timeout
+	cb.setState(StateOpened, reason)
+	return true
+}
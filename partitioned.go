@@ -0,0 +1,108 @@
+package circuitbreaker
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// - is the state of one shard of a Partitioned breaker
+type ShardState struct {
+	Shard  int    `json:"shard"`
+	State  string `json:"state"`
+	Counts Counts `json:"counts"`
+}
+
+// - is the aggregate state of a Partitioned breaker
+type PartitionedState struct {
+	// HealthUp when no shard is open, HealthDown when every shard is open
+	Status   HealthStatus `json:"status"`
+	Closed   int          `json:"closed"`
+	HalfOpen int          `json:"half_open"`
+	Open     int          `json:"open"`
+	// sum of the counters of all shards
+	Counts Counts       `json:"counts"`
+	Shards []ShardState `json:"shards"`
+}
+
+// - keeps one breaker per shard of a sharded dependency (database, cache cluster),
+// keys are hashed to shards, so failing shards fail fast while the others are served
+type Partitioned struct {
+	shards []*CircuitBreaker
+	hash   func(key string) uint64
+}
+
+// - is a constructor, factory creates the breaker of each shard
+func NewPartitioned(shards int, factory func(shard int) *CircuitBreaker) *Partitioned {
+	p := &Partitioned{
+		shards: make([]*CircuitBreaker, max(shards, 1)),
+		hash:   fnvHash,
+	}
+	for i := range p.shards {
+		p.shards[i] = factory(i)
+	}
+	return p
+}
+
+// - replaces the FNV-1a hash mapping keys to shards, e.g. with the hash the
+// dependency itself shards by, must be called before use
+func (p *Partitioned) SetHash(hash func(key string) uint64) {
+	p.hash = hash
+}
+
+// - returns the shard of the key
+func (p *Partitioned) Shard(key string) int {
+	return int(p.hash(key) % uint64(len(p.shards)))
+}
+
+// - returns the breaker of the key's shard
+func (p *Partitioned) Breaker(key string) *CircuitBreaker {
+	return p.shards[p.Shard(key)]
+}
+
+// - returns the breaker of the shard
+func (p *Partitioned) ShardBreaker(shard int) *CircuitBreaker {
+	return p.shards[shard]
+}
+
+// - runs fn through the breaker of the key's shard, see CircuitBreaker.ExecuteContext
+func (p *Partitioned) Execute(ctx context.Context, key string, fn func(ctx context.Context) error) error {
+	return p.Breaker(key).ExecuteContext(ctx, fn)
+}
+
+// - returns the aggregate and per-shard state
+func (p *Partitioned) State() PartitionedState {
+	state := PartitionedState{Shards: make([]ShardState, 0, len(p.shards))}
+	for i, cb := range p.shards {
+		shard := ShardState{Shard: i, State: cb.State(), Counts: cb.Counts()}
+		state.Shards = append(state.Shards, shard)
+
+		switch shard.State {
+		case StateOpened:
+			state.Open++
+		case StateHalfOpen:
+			state.HalfOpen++
+		default:
+			state.Closed++
+		}
+		state.Counts.Successes += shard.Counts.Successes
+		state.Counts.Failures += shard.Counts.Failures
+		state.Counts.Total += shard.Counts.Total
+	}
+
+	switch {
+	case state.Open == len(p.shards):
+		state.Status = HealthDown
+	case state.Open > 0:
+		state.Status = HealthDegraded
+	default:
+		state.Status = HealthUp
+	}
+	return state
+}
+
+// fnvHash is the default key hash of Partitioned
+func fnvHash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
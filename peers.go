@@ -0,0 +1,56 @@
+package circuitbreaker
+
+import "fmt"
+
+// - compares the success rates of the breakers of peers of the same service, e.g. the
+// replicas behind a load balancer, and opens the circuits of peers deviating from the
+// group mean, catching a single bad replica whose own failure rate stays below the threshold
+type PeerComparator struct {
+	breakers *KeyedBreaker
+	// peers below the group mean by Factor standard deviations are opened
+	Factor float64
+	// peers with fewer calls since their last transition are not compared
+	MinCalls int64
+	// the comparison needs at least MinPeers peers with MinCalls calls
+	MinPeers int
+}
+
+// - is a constructor, factor 2 and at least 3 peers with 10 calls by default
+func NewPeerComparator(breakers *KeyedBreaker) *PeerComparator {
+	return &PeerComparator{
+		breakers: breakers,
+		Factor:   2,
+		MinCalls: 10,
+		MinPeers: 3,
+	}
+}
+
+// - compares the closed circuits of peers and opens the deviating ones for their
+// open timeout, returns the opened peers sorted
+func (p *PeerComparator) Compare(peers ...string) []string {
+	rates := make(map[string]float64, len(peers))
+	for _, peer := range peers {
+		cb, ok := p.breakers.Lookup(peer)
+		if !ok || cb.State() != StateClosed {
+			continue
+		}
+		counts := cb.Counts()
+		if counts.Total == 0 || counts.Total < p.MinCalls {
+			continue
+		}
+		rates[peer] = float64(counts.Successes) / float64(counts.Total)
+	}
+	if len(rates) < p.MinPeers {
+		return nil
+	}
+
+	var opened []string
+	for _, peer := range deviating(rates, p.Factor) {
+		cb, _ := p.breakers.Lookup(peer)
+		reason := fmt.Sprintf("success rate %.1f%% deviates from %d peers", rates[peer]*100, len(rates)-1)
+		if cb.eject(0, reason) {
+			opened = append(opened, peer)
+		}
+	}
+	return opened
+}
@@ -0,0 +1,115 @@
+package circuitbreaker
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// - is an admission of a call by Acquire, its result is reported exactly once with
+// Success, Failure or Ignore, from any goroutine, results of calls admitted before
+// the last state transition are not recorded, so a late result can not be mistaken
+// for a result of the new state (e.g. a half-open probe)
+type Permit struct {
+	cb *CircuitBreaker
+	// the state transition counter at admission
+	Generation uint64
+	// the state the call was admitted in
+	State    string
+	Admitted time.Time
+	// a result reported after the deadline counts as a failure, zero means no deadline,
+	// see WithPermitTimeout
+	Deadline time.Time
+
+	done atomic.Bool
+}
+
+// - sets the deadline of permits returned by Acquire
+func WithPermitTimeout(d time.Duration) Option {
+	return func(cb *CircuitBreaker) {
+		cb.permitTimeout = d
+	}
+}
+
+// - is the two-step form of Execute: admits a call like Allow and returns the permit
+// the result is reported with, returns ErrOpenState (or ErrRateLimited, ErrDraining)
+// when the call is not admitted
+func (cb *CircuitBreaker) Acquire() (*Permit, error) {
+	if err := cb.admit(1); err != nil {
+		cb.sinkRejection(nil, err)
+		return nil, cb.wrapError(err, "")
+	}
+
+	generation, state := cb.beginCallState()
+	permit := &Permit{
+		cb:         cb,
+		Generation: generation,
+		State:      state,
+		Admitted:   cb.clock.Now(),
+	}
+	if cb.permitTimeout > 0 {
+		permit.Deadline = permit.Admitted.Add(cb.permitTimeout)
+	}
+	return permit, nil
+}
+
+// - records the call as successful, or as failed when the deadline passed
+func (p *Permit) Success() {
+	p.finish(true, true)
+}
+
+// - records the call as failed
+func (p *Permit) Failure() {
+	p.finish(false, true)
+}
+
+// - releases the permit without recording a result, see RecordIgnore
+func (p *Permit) Ignore() {
+	p.finish(false, false)
+}
+
+// - reports whether the deadline passed
+func (p *Permit) Expired() bool {
+	return !p.Deadline.IsZero() && p.cb.clock.Now().After(p.Deadline)
+}
+
+// finish records the result once and releases the permit
+func (p *Permit) finish(success, record bool) {
+	if !p.done.CompareAndSwap(false, true) {
+		return
+	}
+	defer p.cb.endCall(p.Generation)
+
+	if !record {
+		p.cb.ignorePermit(p.Generation)
+		return
+	}
+	if success && p.Expired() {
+		success = false
+	}
+	p.cb.recordPermit(p.Generation, success)
+}
+
+// ignorePermit records an ignored call unless the breaker switched state since generation
+func (cb *CircuitBreaker) ignorePermit(generation uint64) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.generation == generation {
+		cb.recordIgnore()
+	}
+}
+
+// recordPermit records the result unless the breaker switched state since generation
+func (cb *CircuitBreaker) recordPermit(generation uint64, success bool) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.generation != generation {
+		return
+	}
+	if success {
+		cb.recordSuccess(cb.clock.Now())
+	} else {
+		cb.recordFailure(cb.clock.Now())
+	}
+}
@@ -0,0 +1,117 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// - is the stable JSON representation of a breaker, written by MarshalJSON
+// and accepted by UnmarshalJSON and Restore
+type BreakerState struct {
+	Name           string          `json:"name,omitempty"`
+	State          string          `json:"state"`
+	Since          time.Time       `json:"since"`
+	Counts         Counts          `json:"counts"`
+	Config         ConfigSummary   `json:"config"`
+	LastTransition *TransitionView `json:"last_transition,omitempty"`
+}
+
+// - summarizes the breaker configuration, thresholds without a ThresholdSpec are omitted
+type ConfigSummary struct {
+	Failure     *ThresholdSpec `json:"failure,omitempty"`
+	Success     *ThresholdSpec `json:"success,omitempty"`
+	OpenTimeout Duration       `json:"open_timeout"`
+}
+
+// - returns the current state of the breaker, a forced or maintenance state is not
+// part of it, the snapshot holds the state the circuit returns to once the override ends
+func (cb *CircuitBreaker) Snapshot() BreakerState {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	cb.checkOpenTimeout()
+	snapshot := BreakerState{
+		Name:   cb.name,
+		State:  cb.state,
+		Since:  cb.lastStateChange,
+		Counts: cb.counts(),
+		Config: ConfigSummary{OpenTimeout: Duration(cb.openTimeout())},
+	}
+	if spec, err := SpecOf(cb.failureThreshold); err == nil {
+		snapshot.Config.Failure = &spec
+	}
+	if spec, err := SpecOf(cb.successThreshold); err == nil {
+		snapshot.Config.Success = &spec
+	}
+	if tr := cb.lastTransition; !tr.At.IsZero() {
+		snapshot.LastTransition = &TransitionView{From: tr.From, To: tr.To, At: tr.At, Reason: tr.Reason, Actor: tr.Actor}
+	}
+	return snapshot
+}
+
+// - MarshalJSON writes Snapshot
+func (cb *CircuitBreaker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cb.Snapshot())
+}
+
+// - UnmarshalJSON restores the breaker from the Snapshot shape, see Restore
+func (cb *CircuitBreaker) UnmarshalJSON(data []byte) error {
+	var state BreakerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	return cb.Restore(state)
+}
+
+// - restores state, counters and the last transition of a snapshot, e.g. after a restart,
+// name and config are kept as configured, returns an error wrapping ErrInvalidState
+// for an unknown state
+func (cb *CircuitBreaker) Restore(state BreakerState) error {
+	switch state.State {
+	case StateClosed, StateOpened, StateHalfOpen:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidState, state.State)
+	}
+
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	now := cb.clock.Now()
+	since := state.Since
+	if since.IsZero() || since.After(now) {
+		since = now
+	}
+
+	cb.state = state.State
+	cb.lastStateChange = since
+	cb.countersStart = since
+	cb.generation++
+	cb.successes = state.Counts.Successes
+	cb.failures = state.Counts.Failures
+	cb.consecutiveSuccesses = 0
+	cb.consecutiveFailures = 0
+	cb.probesAdmitted = 0
+	cb.carried = Counts{}
+	cb.carriedIn = Counts{}
+	// a restored open circuit starts a new streak
+	cb.openStreak = 0
+	if cb.state == StateOpened {
+		cb.openStreak = 1
+		cb.streakStart = since
+	}
+
+	cb.lastTransition = TransitionInfo{}
+	if tr := state.LastTransition; tr != nil {
+		cb.lastTransition = TransitionInfo{From: tr.From, To: tr.To, At: tr.At, Reason: tr.Reason, Counts: state.Counts, Actor: tr.Actor}
+	}
+
+	if cb.timer != nil {
+		cb.timer.Stop()
+		cb.timer = nil
+	}
+	if cb.transitionTimer && cb.state == StateOpened {
+		cb.scheduleHalfOpen()
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultPauseInterval = 50 * time.Millisecond
+
+// - is a background job processed by the pool
+type Job func(ctx context.Context) error
+
+// - pairs the circuit breaker with a bounded worker pool,
+// workers stop consuming the queue while the circuit is open and resume
+// when the breaker admits calls again (half-open probes and closed state)
+type Pool struct {
+	cb            *CircuitBreaker
+	workers       int
+	queue         chan Job
+	pauseInterval time.Duration
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// - is a constructor
+func NewPool(cb *CircuitBreaker, workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Pool{
+		cb:            cb,
+		workers:       workers,
+		queue:         make(chan Job, queueSize),
+		pauseInterval: defaultPauseInterval,
+		stopped:       make(chan struct{}),
+	}
+}
+
+// - sets how often paused workers check the breaker, must be called before Start
+func (p *Pool) SetPauseInterval(interval time.Duration) {
+	p.pauseInterval = interval
+}
+
+// - starts the workers
+func (p *Pool) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	for range p.workers {
+		p.wg.Add(1)
+		go p.work(ctx)
+	}
+}
+
+// - stops the workers and waits for running jobs, queued jobs and jobs waiting for
+// admission are left unprocessed
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	select {
+	case <-p.stopped:
+	default:
+		close(p.stopped)
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// - enqueues the job, blocks while the queue is full
+func (p *Pool) Submit(ctx context.Context, job Job) error {
+	select {
+	case <-p.stopped:
+		return ErrPoolStopped
+	default:
+	}
+
+	select {
+	case p.queue <- job:
+		return nil
+	case <-p.stopped:
+		return ErrPoolStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// - returns number of queued jobs
+func (p *Pool) Pending() int {
+	return len(p.queue)
+}
+
+// work consumes the queue while the breaker admits calls, a job is admitted after it is
+// dequeued and kept by the worker until the breaker admits it, idle workers only watch
+// the state, so they take no probe slots and cause no rejections
+func (p *Pool) work(ctx context.Context) {
+	defer p.wg.Done()
+
+	var job Job
+	for {
+		if p.cb.State() == StateOpened {
+			if !p.pause(ctx) {
+				return
+			}
+			continue
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case job = <-p.queue:
+			}
+		}
+
+		if !p.cb.Allow() {
+			if !p.pause(ctx) {
+				return
+			}
+			continue
+		}
+
+		if err := job(ctx); err != nil {
+			p.cb.RecordFailure()
+		} else {
+			p.cb.RecordSuccess()
+		}
+		job = nil
+	}
+}
+
+// pause waits for the pause interval, false when ctx is done
+func (p *Pool) pause(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(p.pauseInterval):
+		return true
+	}
+}
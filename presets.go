@@ -0,0 +1,94 @@
+package circuitbreaker
+
+import "time"
+
+// - is a named starting configuration, tune the fields or append options before New
+type Preset struct {
+	Name             string
+	FailureThreshold CustomThreshold
+	SuccessThreshold CustomThreshold
+	OpenTimeout      time.Duration
+	Options          []Option
+}
+
+// - creates the breaker configured by the preset, opts are applied after the preset options
+func (p Preset) New(opts ...Option) *CircuitBreaker {
+	all := append([]Option{WithName(p.Name)}, p.Options...)
+	all = append(all, opts...)
+	return NewCircuitBreaker(p.FailureThreshold, p.SuccessThreshold, p.OpenTimeout, all...)
+}
+
+// - opens at 50% failures of at least 20 calls counted over the current minute, probes for 30s
+// and closes after 3 successes, database outages tend to last and reconnect storms hurt
+func PresetDatabase() Preset {
+	return Preset{
+		Name:             "database",
+		FailureThreshold: NewFloat64Threshold(0.5),
+		SuccessThreshold: NewInt64Threshold(3),
+		OpenTimeout:      30 * time.Second,
+		Options: []Option{
+			WithMinimumCalls(20),
+			WithCounterRotation(time.Minute),
+			IgnoreContextErrors(true),
+		},
+	}
+}
+
+// - opens at 50% failures of at least 10 calls counted over the current minute, probes after 10s
+// and closes after 5 successes
+func PresetHTTPAPI() Preset {
+	return Preset{
+		Name:             "http-api",
+		FailureThreshold: NewFloat64Threshold(0.5),
+		SuccessThreshold: NewInt64Threshold(5),
+		OpenTimeout:      10 * time.Second,
+		Options: []Option{
+			WithMinimumCalls(10),
+			WithCounterRotation(time.Minute),
+			IgnoreContextErrors(true),
+		},
+	}
+}
+
+// - opens after 5 consecutive failures, probes after 5s and closes on the first success,
+// a cache miss is cheap, so the cache is skipped quickly and retried soon
+func PresetCache() Preset {
+	return Preset{
+		Name:             "cache",
+		FailureThreshold: NewInt64Threshold(5),
+		SuccessThreshold: NewInt64Threshold(1),
+		OpenTimeout:      5 * time.Second,
+		Options: []Option{
+			IgnoreContextErrors(true),
+		},
+	}
+}
+
+// - opens after 3 consecutive failures and stays open for a minute,
+// closing after 5 successes, for dependencies that must be protected at any cost
+func PresetAggressive() Preset {
+	return Preset{
+		Name:             "aggressive",
+		FailureThreshold: NewInt64Threshold(3),
+		SuccessThreshold: NewInt64Threshold(5),
+		OpenTimeout:      time.Minute,
+	}
+}
+
+// - opens only at 75% failures of at least 50 calls counted over the current minute after
+// a one minute warmup, probes after 10s and closes after 2 successes, for callers that
+// prefer errors to shedding
+func PresetConservative() Preset {
+	return Preset{
+		Name:             "conservative",
+		FailureThreshold: NewFloat64Threshold(0.75),
+		SuccessThreshold: NewInt64Threshold(2),
+		OpenTimeout:      10 * time.Second,
+		Options: []Option{
+			WithMinimumCalls(50),
+			WithWarmup(time.Minute, 50),
+			WithCounterRotation(time.Minute),
+			IgnoreContextErrors(true),
+		},
+	}
+}
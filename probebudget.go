@@ -0,0 +1,31 @@
+package circuitbreaker
+
+import "time"
+
+// - lets up to n calls per interval through while the circuit is open as live probes,
+// a successful probe moves the breaker to half-open before the open timeout elapses,
+// failed probes keep it open
+func WithProbeBudget(n int, interval time.Duration) Option {
+	return func(cb *CircuitBreaker) {
+		cb.probeBudget = n
+		cb.probeInterval = interval
+	}
+}
+
+// takeProbe spends one probe of the current interval, must be called under write lock
+func (cb *CircuitBreaker) takeProbe(now time.Time) bool {
+	if cb.probeBudget <= 0 {
+		return false
+	}
+
+	if d, skewed := elapsed(now, cb.probeWindowStart); skewed || d >= cb.probeInterval {
+		cb.probeWindowStart = now
+		cb.probesUsed = 0
+	}
+	if cb.probesUsed >= cb.probeBudget {
+		return false
+	}
+
+	cb.probesUsed++
+	return true
+}
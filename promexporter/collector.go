@@ -0,0 +1,191 @@
+// Package promexporter exports circuit breaker metrics to Prometheus.
+package promexporter
+
+import (
+	"sort"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// - configures the exported metrics
+type Options struct {
+	// metric name prefix, "circuit_breaker" when empty
+	Namespace string
+	// static labels added to every metric, e.g. service and region
+	ConstLabels prometheus.Labels
+	// dynamic labels of a breaker computed from its name, e.g. the tenant or route it protects,
+	// every breaker must get the same label names
+	BreakerLabels func(breaker string) prometheus.Labels
+	// per-call tags (see circuitbreaker.WithTags) exported as labels of the call metrics,
+	// missing tags are exported as empty labels
+	TagLabels []string
+}
+
+// - is a prometheus.Collector exporting the breakers of a registry and,
+// through Observer, the calls made through them
+type Collector struct {
+	registry *circuitbreaker.Registry
+	opts     Options
+
+	breakerLabels []string
+
+	state    *prometheus.Desc
+	rejected *prometheus.Desc
+	inflight *prometheus.Desc
+	failures *prometheus.Desc
+
+	calls       *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+	transitions *prometheus.CounterVec
+}
+
+// - is a constructor, breakerLabelNames are the names returned by Options.BreakerLabels
+func NewCollector(registry *circuitbreaker.Registry, opts Options, breakerLabelNames ...string) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = "circuit_breaker"
+	}
+
+	labels := append([]string{"breaker"}, breakerLabelNames...)
+	callLabels := append(append(append([]string(nil), labels...), "outcome"), opts.TagLabels...)
+	durationLabels := append(append([]string(nil), labels...), opts.TagLabels...)
+
+	desc := func(name, help string, extra ...string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, "", name),
+			help,
+			append(append([]string(nil), labels...), extra...),
+			opts.ConstLabels,
+		)
+	}
+
+	return &Collector{
+		registry:      registry,
+		opts:          opts,
+		breakerLabels: breakerLabelNames,
+		state:         desc("state", "1 for the current state of the breaker.", "state"),
+		rejected:      desc("rejected_total", "Calls rejected by the breaker."),
+		inflight:      desc("inflight", "Protected calls in flight."),
+		failures:      desc("failures", "Failures counted since the last transition."),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "calls_total",
+			Help:        "Calls made through the breaker by outcome.",
+			ConstLabels: opts.ConstLabels,
+		}, callLabels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "call_duration_seconds",
+			Help:        "Duration of admitted calls.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     prometheus.DefBuckets,
+		}, durationLabels),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "transitions_total",
+			Help:        "State transitions of the breaker, with the trace of the causing call as exemplar.",
+			ConstLabels: opts.ConstLabels,
+		}, append(append([]string(nil), labels...), "from", "to")),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	ch <- c.rejected
+	ch <- c.inflight
+	ch <- c.failures
+	c.calls.Describe(ch)
+	c.duration.Describe(ch)
+	c.transitions.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.registry.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		metrics := snapshot[name]
+		labels := c.labelValues(name)
+
+		for _, state := range []string{circuitbreaker.StateClosed, circuitbreaker.StateOpened, circuitbreaker.StateHalfOpen} {
+			value := 0.0
+			if metrics.State == state {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, value, append(labels, state)...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(metrics.Rejected), labels...)
+		ch <- prometheus.MustNewConstMetric(c.inflight, prometheus.GaugeValue, float64(metrics.Inflight), labels...)
+		ch <- prometheus.MustNewConstMetric(c.failures, prometheus.GaugeValue, float64(metrics.Counts.Failures), labels...)
+	}
+
+	c.calls.Collect(ch)
+	c.duration.Collect(ch)
+	c.transitions.Collect(ch)
+}
+
+// - returns the EventHandler feeding the transition metric, pass it to circuitbreaker.WithEventHandler,
+// the trace ID tag of the call causing a transition (see circuitbreaker.TagTraceID) becomes its exemplar
+func (c *Collector) EventHandler() circuitbreaker.EventHandler {
+	return func(event circuitbreaker.Event) {
+		if event.Type != circuitbreaker.EventStateChange {
+			return
+		}
+
+		labels := append(c.labelValues(event.Breaker), event.From, event.To)
+		counter := c.transitions.WithLabelValues(labels...)
+
+		traceID := event.Tags[circuitbreaker.TagTraceID]
+		if adder, ok := counter.(prometheus.ExemplarAdder); ok && traceID != "" {
+			adder.AddWithExemplar(1, prometheus.Labels{circuitbreaker.TagTraceID: traceID})
+			return
+		}
+		counter.Inc()
+	}
+}
+
+// - returns the CallObserver feeding the call metrics, pass it to circuitbreaker.WithCallObserver
+func (c *Collector) Observer() circuitbreaker.CallObserver {
+	return func(call circuitbreaker.CallInfo) {
+		labels := c.labelValues(call.Breaker)
+		tags := make([]string, 0, len(c.opts.TagLabels))
+		for _, key := range c.opts.TagLabels {
+			tags = append(tags, call.Tags[key])
+		}
+
+		outcome := "success"
+		switch {
+		case call.Rejected:
+			outcome = "rejected"
+		case call.Err != nil:
+			outcome = "failure"
+		}
+
+		c.calls.WithLabelValues(append(append(append([]string(nil), labels...), outcome), tags...)...).Inc()
+		if !call.Rejected {
+			c.duration.WithLabelValues(append(append([]string(nil), labels...), tags...)...).Observe(call.Latency.Seconds())
+		}
+	}
+}
+
+// labelValues returns the breaker name followed by the dynamic breaker labels
+func (c *Collector) labelValues(breaker string) []string {
+	values := make([]string, 0, 1+len(c.breakerLabels))
+	values = append(values, breaker)
+	if len(c.breakerLabels) == 0 || c.opts.BreakerLabels == nil {
+		for range c.breakerLabels {
+			values = append(values, "")
+		}
+		return values
+	}
+
+	labels := c.opts.BreakerLabels(breaker)
+	for _, name := range c.breakerLabels {
+		values = append(values, labels[name])
+	}
+	return values
+}
@@ -0,0 +1,94 @@
+package promexporter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	registry := circuitbreaker.NewRegistry()
+	collector := NewCollector(registry, Options{
+		ConstLabels: prometheus.Labels{"service": "checkout"},
+		BreakerLabels: func(breaker string) prometheus.Labels {
+			return prometheus.Labels{"region": strings.TrimPrefix(breaker, "payments-")}
+		},
+		TagLabels: []string{"tenant"},
+	}, "region")
+
+	cb := circuitbreaker.NewCircuitBreaker(
+		circuitbreaker.NewInt64Threshold(1),
+		circuitbreaker.NewInt64Threshold(1),
+		time.Minute,
+		circuitbreaker.WithName("payments-eu"),
+		circuitbreaker.WithCallObserver(collector.Observer()),
+	)
+	if err := registry.Register(cb); err != nil {
+		t.Fatalf("Unexpected register error: %v", err)
+	}
+
+	ctx := circuitbreaker.WithTags(context.Background(), circuitbreaker.Tags{"tenant": "acme"})
+	_ = cb.ExecuteContext(ctx, func(context.Context) error { return errors.New("downstream error") })
+	_ = cb.ExecuteContext(ctx, func(context.Context) error { return nil })
+
+	expected := `
+# HELP circuit_breaker_calls_total Calls made through the breaker by outcome.
+# TYPE circuit_breaker_calls_total counter
+circuit_breaker_calls_total{breaker="payments-eu",outcome="failure",region="eu",service="checkout",tenant="acme"} 1
+circuit_breaker_calls_total{breaker="payments-eu",outcome="rejected",region="eu",service="checkout",tenant="acme"} 1
+# HELP circuit_breaker_state 1 for the current state of the breaker.
+# TYPE circuit_breaker_state gauge
+circuit_breaker_state{breaker="payments-eu",region="eu",service="checkout",state="closed"} 0
+circuit_breaker_state{breaker="payments-eu",region="eu",service="checkout",state="half-open"} 0
+circuit_breaker_state{breaker="payments-eu",region="eu",service="checkout",state="open"} 1
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"circuit_breaker_calls_total", "circuit_breaker_state")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransitionExemplar(t *testing.T) {
+	collector := NewCollector(circuitbreaker.NewRegistry(), Options{})
+
+	cb := circuitbreaker.NewCircuitBreaker(
+		circuitbreaker.NewInt64Threshold(1),
+		circuitbreaker.NewInt64Threshold(1),
+		time.Minute,
+		circuitbreaker.WithName("payments"),
+		circuitbreaker.WithEventHandler(collector.EventHandler()),
+	)
+
+	ctx := circuitbreaker.WithTags(context.Background(), circuitbreaker.Tags{circuitbreaker.TagTraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	_ = cb.ExecuteContext(ctx, func(context.Context) error { return errors.New("downstream error") })
+
+	families, err := prometheus.Gatherers{gatherer(collector)}.Gather()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "circuit_breaker_transitions_total" {
+			continue
+		}
+		exemplar := family.GetMetric()[0].GetCounter().GetExemplar()
+		if exemplar == nil || exemplar.GetLabel()[0].GetValue() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected trace exemplar, got %v", exemplar)
+		}
+		return
+	}
+	t.Error("Expected transitions metric")
+}
+
+func gatherer(collector prometheus.Collector) prometheus.Gatherer {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+	return registry
+}
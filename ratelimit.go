@@ -0,0 +1,98 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// - is a token bucket rate limiter
+type RateLimiter struct {
+	mu sync.Mutex
+
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  Clock
+}
+
+// - is a constructor, the bucket refills ratePerSecond tokens per second up to burst and starts full
+func NewRateLimiter(ratePerSecond float64, burst int) *RateLimiter {
+	clock := Clock(realClock{})
+	return &RateLimiter{
+		rate:   ratePerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+		clock:  clock,
+	}
+}
+
+// - replaces the wall clock used for refilling, must be called before use
+func (l *RateLimiter) SetClock(clock Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clock = clock
+	l.last = clock.Now()
+}
+
+// - takes a token, returns false when the bucket is empty
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if d, skewed := elapsed(now, l.last); !skewed {
+		l.tokens += d.Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// - defines whether the rate limiter is consulted before or after the breaker
+type LimitOrder int
+
+const (
+	// rate limited calls never reach the breaker and are not counted as rejected
+	LimitBeforeBreaker LimitOrder = iota
+	// calls rejected by the breaker do not consume tokens
+	LimitAfterBreaker
+)
+
+// - makes Allow and Execute consult the rate limiter, Execute returns ErrRateLimited
+// when the bucket is empty
+func WithRateLimiter(limiter *RateLimiter, order LimitOrder) Option {
+	return func(cb *CircuitBreaker) {
+		cb.limiter = limiter
+		cb.limitOrder = order
+	}
+}
+
+// - is the decorator version of WithRateLimiter for composed breakers
+func RateLimit(limiter *RateLimiter, order LimitOrder) Decorator {
+	return func(inner Breaker) Breaker {
+		return &rateLimitedBreaker{Breaker: inner, limiter: limiter, order: order}
+	}
+}
+
+type rateLimitedBreaker struct {
+	Breaker
+	limiter *RateLimiter
+	order   LimitOrder
+}
+
+func (r *rateLimitedBreaker) Allow() bool {
+	if r.order == LimitBeforeBreaker {
+		return r.limiter.Allow() && r.Breaker.Allow()
+	}
+	return r.Breaker.Allow() && r.limiter.Allow()
+}
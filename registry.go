@@ -0,0 +1,68 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// - keeps named circuit breakers of a service
+type Registry struct {
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+	critical map[string]bool
+}
+
+// - is a constructor
+func NewRegistry() *Registry {
+	return &Registry{
+		breakers: make(map[string]*CircuitBreaker),
+		critical: make(map[string]bool),
+	}
+}
+
+// - adds the breaker, it must have a unique name (see WithName)
+func (r *Registry) Register(cb *CircuitBreaker) error {
+	if cb.Name() == "" {
+		return ErrEmptyName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.breakers[cb.Name()]; ok {
+		return ErrAlreadyRegistered
+	}
+	r.breakers[cb.Name()] = cb
+	return nil
+}
+
+// - removes the breaker
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.breakers, name)
+	delete(r.critical, name)
+}
+
+// - returns the breaker by name
+func (r *Registry) Get(name string) (*CircuitBreaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cb, ok := r.breakers[name]
+	return cb, ok
+}
+
+// - returns all breakers sorted by name
+func (r *Registry) All() []*CircuitBreaker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, cb := range r.breakers {
+		all = append(all, cb)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name() < all[j].Name()
+	})
+	return all
+}
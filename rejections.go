@@ -0,0 +1,76 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// - is a call rejected by Execute while the circuit was open
+type RejectedCall struct {
+	Time time.Time `json:"time"`
+	// tags of the call, e.g. TagMethod and TagKey set by httpbreaker
+	Tags Tags `json:"tags,omitempty"`
+}
+
+// - keeps every n-th call rejected by Execute while open in a ring buffer of the given
+// capacity, see Rejections and the admin API, every <= 1 keeps each rejected call
+func WithRejectionSink(capacity int, every int64) Option {
+	return func(cb *CircuitBreaker) {
+		if capacity > 0 {
+			cb.rejections = &rejectionSink{calls: make([]RejectedCall, capacity), every: max(every, 1)}
+		}
+	}
+}
+
+// - returns the sampled calls rejected while open, oldest first, nil without WithRejectionSink
+func (cb *CircuitBreaker) Rejections() []RejectedCall {
+	if cb.rejections == nil {
+		return nil
+	}
+	return cb.rejections.snapshot()
+}
+
+// rejectionSink is a bounded buffer of sampled rejected calls
+type rejectionSink struct {
+	mu    sync.Mutex
+	calls []RejectedCall
+	seen  int64
+	next  int
+	full  bool
+	every int64
+}
+
+// add stores the call when it is sampled, overwriting the oldest one when the buffer is full
+func (s *rejectionSink) add(call RejectedCall) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen++
+	if (s.seen-1)%s.every != 0 {
+		return
+	}
+
+	s.calls[s.next] = call
+	s.next = (s.next + 1) % len(s.calls)
+	s.full = s.full || s.next == 0
+}
+
+func (s *rejectionSink) snapshot() []RejectedCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.full {
+		return append([]RejectedCall(nil), s.calls[:s.next]...)
+	}
+	return append(append([]RejectedCall(nil), s.calls[s.next:]...), s.calls[:s.next]...)
+}
+
+// sinkRejection stores the call rejected with err when it was rejected by the open circuit
+func (cb *CircuitBreaker) sinkRejection(ctx context.Context, err error) {
+	if cb.rejections == nil || !errors.Is(err, ErrOpenState) {
+		return
+	}
+	cb.rejections.add(RejectedCall{Time: cb.clock.Now(), Tags: TagsFrom(ctx)})
+}
@@ -0,0 +1,98 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// - reads a JSON file mapping breaker names to their Config, e.g.
+//
+//	{"payments": {"failure": {"type": "float64", "params": {"value": 0.5}},
+//	              "success": {"type": "int64", "params": {"value": 3}},
+//	              "open_timeout": "30s"}}
+func LoadConfigFile(path string) (map[string]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs map[string]Config
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return configs, nil
+}
+
+// - creates a registry with a breaker per config named by its key, opts are applied
+// after the configured options of every breaker, the returned error joins invalid configs
+func NewRegistryFromConfig(configs map[string]Config, opts ...Option) (*Registry, error) {
+	registry := NewRegistry()
+	var errs []error
+	for name, config := range configs {
+		cb, err := config.New(append(opts, WithName(name))...)
+		if err == nil {
+			err = registry.Register(cb)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// - updates thresholds and open timeouts of the registered breakers with UpdateValues,
+// configs of unregistered names are skipped, the returned error joins invalid configs
+func (r *Registry) Apply(configs map[string]Config) error {
+	var errs []error
+	for name, config := range configs {
+		cb, ok := r.Get(name)
+		if !ok {
+			continue
+		}
+
+		failure, success, err := config.Thresholds()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		cb.UpdateValues(failure, success, time.Duration(config.OpenTimeout))
+	}
+	return errors.Join(errs...)
+}
+
+// - re-reads the config file and applies it to the registry whenever one of the signals
+// is received, until ctx is done, errors are passed to onError when it is not nil
+func ReloadOnSignal(ctx context.Context, registry *Registry, path string, onError func(error), signals ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			err := reload(registry, path)
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// reload reads path and applies it to the registry
+func reload(registry *Registry, path string) error {
+	configs, err := LoadConfigFile(path)
+	if err != nil {
+		return err
+	}
+	return registry.Apply(configs)
+}
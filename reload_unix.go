@@ -0,0 +1,13 @@
+//go:build unix
+
+package circuitbreaker
+
+import (
+	"context"
+	"syscall"
+)
+
+// - is ReloadOnSignal for SIGHUP, following the common daemon convention
+func ReloadOnSIGHUP(ctx context.Context, registry *Registry, path string, onError func(error)) {
+	ReloadOnSignal(ctx, registry, path, onError, syscall.SIGHUP)
+}
@@ -0,0 +1,32 @@
+package circuitbreaker
+
+import (
+	"context"
+	"time"
+)
+
+// - returns metrics of all registered breakers by name
+func (r *Registry) Snapshot() map[string]Metrics {
+	all := r.All()
+	snapshot := make(map[string]Metrics, len(all))
+	for _, cb := range all {
+		snapshot[cb.Name()] = cb.Metrics()
+	}
+	return snapshot
+}
+
+// - passes Snapshot to fn every interval until ctx is done, run it in its own goroutine
+// to push breaker gauges without touching the request path
+func (r *Registry) ReportEvery(ctx context.Context, interval time.Duration, fn func(map[string]Metrics)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(r.Snapshot())
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// number of buckets the retry budget window is divided into
+const retryBudgetBuckets = 10
+
+// - limits retries to a ratio of the total traffic within a sliding window, shared by
+// the callers of a dependency, and suppresses retries while its breaker is not closed,
+// so retries can not amplify an outage
+type RetryBudget struct {
+	cb         *CircuitBreaker
+	clock      Clock
+	ratio      float64
+	minRetries int64
+	width      time.Duration
+
+	mu      sync.Mutex
+	buckets [retryBudgetBuckets]retryBucket
+}
+
+type retryBucket struct {
+	index    int64
+	requests int64
+	retries  int64
+}
+
+// - is a constructor, ratio is the share of retries in the requests of the window
+// (e.g. 0.1), minRetries are allowed regardless of the ratio so low traffic can retry,
+// cb (may be nil) suppresses retries while it is open or half-open
+func NewRetryBudget(cb *CircuitBreaker, ratio float64, minRetries int64, window time.Duration) *RetryBudget {
+	var clock Clock = realClock{}
+	if cb != nil {
+		clock = cb.clock
+	}
+	return &RetryBudget{
+		cb:         cb,
+		clock:      clock,
+		ratio:      ratio,
+		minRetries: minRetries,
+		width:      max(window/retryBudgetBuckets, time.Nanosecond),
+	}
+}
+
+// - counts a first attempt
+func (b *RetryBudget) RecordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bucket(b.clock.Now()).requests++
+}
+
+// - takes a retry from the budget, returns ErrOpenState while the breaker is not
+// closed and ErrRetryBudgetExhausted when the window holds too many retries
+func (b *RetryBudget) AllowRetry() error {
+	if b.cb != nil && b.cb.State() != StateClosed {
+		return ErrOpenState
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	requests, retries := b.totals(now)
+	if retries >= int64(b.ratio*float64(requests))+b.minRetries {
+		return ErrRetryBudgetExhausted
+	}
+	b.bucket(now).retries++
+	return nil
+}
+
+// - runs fn up to attempts times while it fails and the budget allows retries, waiting
+// backoff(retry) before each retry (retry starts at 1), returns the last error of fn
+func (b *RetryBudget) Do(ctx context.Context, attempts int, backoff func(retry int) time.Duration, fn func(ctx context.Context) error) error {
+	b.RecordRequest()
+	err := fn(ctx)
+	for retry := 1; err != nil && retry < attempts; retry++ {
+		if b.AllowRetry() != nil {
+			return err
+		}
+		if backoff != nil {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff(retry)):
+			}
+		}
+		err = fn(ctx)
+	}
+	return err
+}
+
+// bucket returns the bucket of now, must be called under lock
+func (b *RetryBudget) bucket(now time.Time) *retryBucket {
+	index := now.UnixNano() / int64(b.width)
+	bucket := &b.buckets[index%retryBudgetBuckets]
+	if bucket.index != index {
+		*bucket = retryBucket{index: index}
+	}
+	return bucket
+}
+
+// totals sums the buckets within the window, must be called under lock
+func (b *RetryBudget) totals(now time.Time) (requests, retries int64) {
+	current := now.UnixNano() / int64(b.width)
+	for _, bucket := range b.buckets {
+		if current-bucket.index < retryBudgetBuckets {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return requests, retries
+}
@@ -0,0 +1,29 @@
+package circuitbreaker
+
+import "time"
+
+// - resets the successes and failures accumulated in closed state every interval,
+// so rates reflect recent traffic instead of the whole uptime and counters can not
+// grow without bound, consecutive counts are kept, zero (the default) disables rotation
+func WithCounterRotation(interval time.Duration) Option {
+	return func(cb *CircuitBreaker) {
+		cb.rotationInterval = interval
+	}
+}
+
+// rotateCounters starts a new counting interval when the current one is over,
+// must be called under write lock
+func (cb *CircuitBreaker) rotateCounters(now time.Time) {
+	if cb.rotationInterval <= 0 || cb.state != StateClosed {
+		return
+	}
+
+	d, skewed := elapsed(now, cb.countersStart)
+	if !skewed && d < cb.rotationInterval {
+		return
+	}
+
+	cb.countersStart = now
+	cb.successes = 0
+	cb.failures = 0
+}
@@ -0,0 +1,111 @@
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// load metrics recorded by WatchRuntime, see WithLoadLimit
+const (
+	LoadGoroutines = "goroutines"
+	LoadHeapBytes  = "heap_bytes"
+)
+
+// runtime/metrics sample of the bytes held by live and not yet swept heap objects
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// - is a threshold sampling the runtime instead of checking the recorded value, it passes
+// when the goroutine count or the heap size exceeds its limit, so a breaker around an
+// expensive endpoint trips while the process is overloaded, zero limits are not checked,
+// the runtime is sampled at most once per SampleInterval
+type RuntimeThreshold struct {
+	MaxGoroutines int
+	MaxHeapBytes  uint64
+	// 100ms when zero
+	SampleInterval time.Duration
+
+	mu         sync.Mutex
+	clock      Clock
+	sampled    time.Time
+	goroutines int
+	heap       uint64
+}
+
+// - is a constructor
+func NewRuntimeThreshold(maxGoroutines int, maxHeapBytes uint64) *RuntimeThreshold {
+	return &RuntimeThreshold{MaxGoroutines: maxGoroutines, MaxHeapBytes: maxHeapBytes, clock: realClock{}}
+}
+
+// - replaces the wall clock used for the sample interval, must be called before use
+func (t *RuntimeThreshold) SetClock(clock Clock) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clock = clock
+}
+
+// - ignores value and checks the sampled runtime against the limits
+func (t *RuntimeThreshold) Check(any) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	interval := t.SampleInterval
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	if t.clock == nil {
+		t.clock = realClock{}
+	}
+	if now := t.clock.Now(); t.sampled.IsZero() || now.Sub(t.sampled) >= interval {
+		t.goroutines, t.heap = sampleRuntime()
+		t.sampled = now
+	}
+
+	return (t.MaxGoroutines > 0 && t.goroutines > t.MaxGoroutines) ||
+		(t.MaxHeapBytes > 0 && t.heap > t.MaxHeapBytes)
+}
+
+func (t *RuntimeThreshold) GetThreshold() any {
+	return [2]any{t.MaxGoroutines, t.MaxHeapBytes}
+}
+
+func (t *RuntimeThreshold) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return fmt.Sprintf("RuntimeThreshold: goroutines %d of %d, heap %d of %d bytes",
+		t.goroutines, t.MaxGoroutines, t.heap, t.MaxHeapBytes)
+}
+
+// - records the goroutine count and heap size as LoadGoroutines and LoadHeapBytes with
+// RecordLoad every interval until ctx is done, pair it with WithLoadLimit to open the
+// circuit without waiting for a call result, run it in its own goroutine
+func WatchRuntime(ctx context.Context, cb *CircuitBreaker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			goroutines, heap := sampleRuntime()
+			cb.RecordLoad(LoadGoroutines, float64(goroutines))
+			cb.RecordLoad(LoadHeapBytes, float64(heap))
+		}
+	}
+}
+
+// sampleRuntime returns the goroutine count and the heap size without stopping the world
+func sampleRuntime() (int, uint64) {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+
+	var heap uint64
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		heap = sample[0].Value.Uint64()
+	}
+	return runtime.NumGoroutine(), heap
+}
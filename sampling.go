@@ -0,0 +1,48 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// - emits EventRejected for every n-th rejected call, transitions are always emitted,
+// n = 1 emits every rejection and n <= 0 (the default) disables rejection events,
+// which keeps event overhead bounded during an outage storm
+func WithRejectionEventSampling(n int64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.rejectionSampling = n
+	}
+}
+
+// queueRejection queues a sampled EventRejected, must be called under write lock after
+// the rejection counters were updated
+func (cb *CircuitBreaker) queueRejection() {
+	if cb.rejectionSampling <= 0 || cb.rejected%cb.rejectionSampling != 0 {
+		return
+	}
+
+	cb.queueEvent(Event{
+		Type:   EventRejected,
+		From:   cb.state,
+		To:     cb.state,
+		Time:   cb.clock.Now(),
+		Reason: fmt.Sprintf("%d calls rejected, sampled 1 in %d", cb.rejected, cb.rejectionSampling),
+	})
+}
+
+// - makes only the given fraction of calls in closed state contribute to the threshold
+// statistics, reducing bookkeeping for extremely high-QPS breakers, results in half-open
+// state are always recorded, fraction >= 1 (the default) records every call
+func WithClosedStateSampling(fraction float64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.sampleFraction = fraction
+		cb.sampleRand = rand.Float64
+	}
+}
+
+// skipSample decides whether a result in closed state is left out of the statistics, the
+// warmup calls, the counter rotation and the recent samples, must be called under write lock
+// before any of them is updated
+func (cb *CircuitBreaker) skipSample() bool {
+	return cb.sampleRand != nil && cb.sampleFraction < 1 && cb.sampleRand() >= cb.sampleFraction
+}
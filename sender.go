@@ -0,0 +1,139 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// - holds payloads a Sender could not deliver, implementations must be safe for concurrent use
+type SpillBuffer[T any] interface {
+	// returns ErrBufferFull when the payload can not be stored
+	Push(payload T) error
+	// returns the oldest payload, false when the buffer is empty
+	Pop() (T, bool)
+	Len() int
+}
+
+// - is an in-memory FIFO SpillBuffer of bounded capacity
+type MemoryBuffer[T any] struct {
+	mu       sync.Mutex
+	items    []T
+	capacity int
+}
+
+// - is a constructor, capacity <= 0 means unbounded
+func NewMemoryBuffer[T any](capacity int) *MemoryBuffer[T] {
+	return &MemoryBuffer[T]{capacity: capacity}
+}
+
+// - appends payload, returns ErrBufferFull when the buffer is at capacity
+func (b *MemoryBuffer[T]) Push(payload T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.capacity > 0 && len(b.items) >= b.capacity {
+		return ErrBufferFull
+	}
+	b.items = append(b.items, payload)
+	return nil
+}
+
+// - removes and returns the oldest payload
+func (b *MemoryBuffer[T]) Pop() (T, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var zero T
+	if len(b.items) == 0 {
+		return zero, false
+	}
+	payload := b.items[0]
+	b.items[0] = zero
+	b.items = b.items[1:]
+	return payload, true
+}
+
+// - returns the number of buffered payloads
+func (b *MemoryBuffer[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.items)
+}
+
+// - delivers fire-and-forget payloads (email, webhooks) through the breaker: payloads
+// rejected by an open breaker or failed to send spill to the buffer and are flushed
+// after recovery, by the next successful Send or by Flush
+type Sender[T any] struct {
+	cb     *CircuitBreaker
+	send   func(ctx context.Context, payload T) error
+	buffer SpillBuffer[T]
+
+	flushing atomic.Bool
+}
+
+// - is a constructor
+func NewSender[T any](cb *CircuitBreaker, send func(ctx context.Context, payload T) error, buffer SpillBuffer[T]) *Sender[T] {
+	return &Sender[T]{cb: cb, send: send, buffer: buffer}
+}
+
+// - sends payload through the breaker, a payload that was not delivered is buffered
+// and nil is returned, the error is returned only when the buffer rejects the payload
+func (s *Sender[T]) Send(ctx context.Context, payload T) error {
+	err := s.cb.ExecuteContext(ctx, func(ctx context.Context) error {
+		return s.send(ctx, payload)
+	})
+	if err != nil {
+		return s.spill(payload, err)
+	}
+
+	if s.buffer.Len() > 0 {
+		// the dependency accepts payloads again, deliver the backlog without
+		// holding up the caller
+		go func() { _, _ = s.Flush(context.WithoutCancel(ctx)) }()
+	}
+	return nil
+}
+
+// - sends buffered payloads in order until the buffer is empty or a payload is not
+// delivered, which is returned to the buffer with the error, returns the number of delivered payloads
+func (s *Sender[T]) Flush(ctx context.Context) (int, error) {
+	if !s.flushing.CompareAndSwap(false, true) {
+		return 0, nil
+	}
+	defer s.flushing.Store(false)
+
+	sent := 0
+	for ctx.Err() == nil {
+		payload, ok := s.buffer.Pop()
+		if !ok {
+			return sent, nil
+		}
+		err := s.cb.ExecuteContext(ctx, func(ctx context.Context) error {
+			return s.send(ctx, payload)
+		})
+		if err != nil {
+			if spillErr := s.spill(payload, err); spillErr != nil {
+				return sent, spillErr
+			}
+			return sent, err
+		}
+		sent++
+	}
+	return sent, ctx.Err()
+}
+
+// - returns the number of payloads waiting for delivery
+func (s *Sender[T]) Pending() int {
+	return s.buffer.Len()
+}
+
+// spill buffers payload that failed with cause
+func (s *Sender[T]) spill(payload T, cause error) error {
+	if err := s.buffer.Push(payload); err != nil {
+		return errors.Join(err, cause)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package circuitbreaker
+
+import (
+	"context"
+	"sync"
+)
+
+// - runs calls through the breaker, in half-open state only one probe runs at a time
+// and concurrent callers wait for it: they share its value when it succeeds and get
+// ErrOpenState when it fails, which avoids duplicate work right after recovery
+type SharedProbe[T any] struct {
+	cb *CircuitBreaker
+
+	mu    sync.Mutex
+	probe *probeCall[T]
+}
+
+type probeCall[T any] struct {
+	done   chan struct{}
+	result T
+	err    error
+}
+
+// - is a constructor
+func NewSharedProbe[T any](cb *CircuitBreaker) *SharedProbe[T] {
+	return &SharedProbe[T]{cb: cb}
+}
+
+// - is ExecuteContext sharing the half-open probe result, waiting callers honor ctx
+func (p *SharedProbe[T]) Execute(ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+
+	p.mu.Lock()
+	if call := p.probe; call != nil {
+		p.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+		if call.err != nil {
+			return zero, ErrOpenState
+		}
+		return call.result, nil
+	}
+
+	if p.cb.State() != StateHalfOpen {
+		p.mu.Unlock()
+		return ExecuteContext(ctx, p.cb, fn)
+	}
+
+	call := &probeCall[T]{done: make(chan struct{})}
+	p.probe = call
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.probe = nil
+		p.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.result, call.err = ExecuteContext(ctx, p.cb, fn)
+	return call.result, call.err
+}
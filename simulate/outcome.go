@@ -0,0 +1,102 @@
+package simulate
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRecord = errors.New("invalid outcome record")
+
+// - is a recorded call result
+type Outcome struct {
+	Time    time.Time `json:"time"`
+	Success bool      `json:"success"`
+}
+
+// - reads outcomes from CSV rows "timestamp,result", the timestamp is RFC 3339
+// or unix milliseconds, the result is success/ok/true/1 or failure/error/false/0,
+// an optional header row is skipped
+func ReadCSV(r io.Reader) ([]Outcome, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var outcomes []Outcome
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%w: line %d: expected timestamp and result", ErrInvalidRecord, line)
+		}
+		if line == 1 && isHeader(record[0]) {
+			continue
+		}
+
+		ts, err := parseTime(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRecord, line, err)
+		}
+		success, err := parseResult(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidRecord, line, err)
+		}
+
+		outcomes = append(outcomes, Outcome{Time: ts, Success: success})
+	}
+
+	sortOutcomes(outcomes)
+	return outcomes, nil
+}
+
+// - reads outcomes from a JSON array of {"time": "...", "success": true}
+func ReadJSON(r io.Reader) ([]Outcome, error) {
+	var outcomes []Outcome
+	if err := json.NewDecoder(r).Decode(&outcomes); err != nil {
+		return nil, err
+	}
+
+	sortOutcomes(outcomes)
+	return outcomes, nil
+}
+
+func isHeader(field string) bool {
+	field = strings.ToLower(strings.TrimSpace(field))
+	return field == "timestamp" || field == "time"
+}
+
+func parseTime(field string) (time.Time, error) {
+	field = strings.TrimSpace(field)
+	if ms, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339Nano, field)
+}
+
+func parseResult(field string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(field)) {
+	case "success", "ok", "true", "1":
+		return true, nil
+	case "failure", "error", "false", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown result %q", field)
+	}
+}
+
+func sortOutcomes(outcomes []Outcome) {
+	sort.SliceStable(outcomes, func(i, j int) bool {
+		return outcomes[i].Time.Before(outcomes[j].Time)
+	})
+}
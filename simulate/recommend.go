@@ -0,0 +1,232 @@
+package simulate
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is a time range, End is exclusive
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// - reports whether t is within the interval
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// - is a failure rate breaker configuration evaluated by Recommend, the rate is
+// computed over fixed windows (see WithCounterRotation)
+type Candidate struct {
+	FailureRate  float64
+	Window       time.Duration
+	MinimumCalls int64
+	OpenTimeout  time.Duration
+}
+
+// - builds the breaker of the candidate
+func (c Candidate) Factory() Factory {
+	return func(_ circuitbreaker.Clock, opts ...circuitbreaker.Option) *circuitbreaker.CircuitBreaker {
+		opts = append(slices.Clip(opts),
+			circuitbreaker.WithCounterRotation(c.Window),
+			circuitbreaker.WithMinimumCalls(c.MinimumCalls),
+		)
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewFloat64Threshold(c.FailureRate),
+			circuitbreaker.NewInt64Threshold(1),
+			c.OpenTimeout,
+			opts...,
+		)
+	}
+}
+
+// - lists the parameter values Recommend combines, every combination is replayed
+type SearchSpace struct {
+	FailureRates []float64
+	Windows      []time.Duration
+	MinimumCalls []int64
+	OpenTimeouts []time.Duration
+}
+
+// - returns every combination of the search space
+func (s SearchSpace) Candidates() []Candidate {
+	var candidates []Candidate
+	for _, rate := range s.FailureRates {
+		for _, window := range s.Windows {
+			for _, minimum := range s.MinimumCalls {
+				for _, timeout := range s.OpenTimeouts {
+					candidates = append(candidates, Candidate{
+						FailureRate:  rate,
+						Window:       window,
+						MinimumCalls: minimum,
+						OpenTimeout:  timeout,
+					})
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// - is the replay result of a candidate scored against known outages
+type CandidateResult struct {
+	Candidate Candidate
+	// times the circuit opened outside of any outage
+	FalseTrips int
+	// outages during which the circuit never opened
+	MissedOutages int
+	// mean time from the start of a detected outage until the circuit opened
+	MeanDetection time.Duration
+	// time spent open outside of outages
+	FalseOpenTime time.Duration
+	Report        Report
+}
+
+// - is the output of Recommend, Results are ordered from the best candidate
+type Recommendation struct {
+	Outages []Interval
+	Best    CandidateResult
+	Results []CandidateResult
+}
+
+// - replays outcomes through every candidate of space and ranks them by false trips
+// plus missed outages, then by detection time and false open time, outages are the
+// known incidents, see DetectOutages when they are not recorded
+func Recommend(outcomes []Outcome, outages []Interval, space SearchSpace) Recommendation {
+	recommendation := Recommendation{Outages: outages}
+
+	for _, candidate := range space.Candidates() {
+		report := Run(outcomes, candidate.Factory())
+		recommendation.Results = append(recommendation.Results, score(candidate, report, outages))
+	}
+
+	sort.SliceStable(recommendation.Results, func(i, j int) bool {
+		a, b := recommendation.Results[i], recommendation.Results[j]
+		if errA, errB := a.FalseTrips+a.MissedOutages, b.FalseTrips+b.MissedOutages; errA != errB {
+			return errA < errB
+		}
+		if a.MeanDetection != b.MeanDetection {
+			return a.MeanDetection < b.MeanDetection
+		}
+		return a.FalseOpenTime < b.FalseOpenTime
+	})
+	if len(recommendation.Results) > 0 {
+		recommendation.Best = recommendation.Results[0]
+	}
+
+	return recommendation
+}
+
+// - returns the periods of at least minCalls outcomes per window whose failure rate
+// reached rate, adjacent windows are merged
+func DetectOutages(outcomes []Outcome, window time.Duration, rate float64, minCalls int) []Interval {
+	if len(outcomes) == 0 || window <= 0 {
+		return nil
+	}
+
+	var outages []Interval
+	start := outcomes[0].Time
+	for i := 0; i < len(outcomes); {
+		end := start.Add(window)
+		calls, failures := 0, 0
+		for ; i < len(outcomes) && outcomes[i].Time.Before(end); i++ {
+			calls++
+			if !outcomes[i].Success {
+				failures++
+			}
+		}
+
+		if calls >= minCalls && calls > 0 && float64(failures)/float64(calls) >= rate {
+			if n := len(outages); n > 0 && outages[n-1].End.Equal(start) {
+				outages[n-1].End = end
+			} else {
+				outages = append(outages, Interval{Start: start, End: end})
+			}
+		}
+		start = end
+	}
+
+	return outages
+}
+
+// score compares the open periods of the report with the outages
+func score(candidate Candidate, report Report, outages []Interval) CandidateResult {
+	result := CandidateResult{Candidate: candidate, Report: report}
+
+	var end time.Time
+	if n := len(report.Transitions); n > 0 {
+		end = report.Transitions[n-1].Time
+	}
+	for _, o := range outages {
+		if o.End.After(end) {
+			end = o.End
+		}
+	}
+	periods := openPeriods(report.Transitions, end)
+
+	for _, p := range periods {
+		outage, ok := outageAt(outages, p.Start)
+		if !ok {
+			result.FalseTrips++
+			result.FalseOpenTime += p.End.Sub(p.Start)
+			continue
+		}
+		if p.End.After(outage.End) {
+			result.FalseOpenTime += p.End.Sub(outage.End)
+		}
+	}
+
+	var detection time.Duration
+	detected := 0
+	for _, o := range outages {
+		first, ok := firstOpenWithin(periods, o)
+		if !ok {
+			result.MissedOutages++
+			continue
+		}
+		detection += first.Sub(o.Start)
+		detected++
+	}
+	if detected > 0 {
+		result.MeanDetection = detection / time.Duration(detected)
+	}
+
+	return result
+}
+
+// openPeriods returns the open periods of transitions, a period still open ends at end
+func openPeriods(transitions []Transition, end time.Time) []Interval {
+	var periods []Interval
+	for _, tr := range transitions {
+		if tr.To == circuitbreaker.StateOpened {
+			periods = append(periods, Interval{Start: tr.Time, End: end})
+		} else if tr.From == circuitbreaker.StateOpened && len(periods) > 0 {
+			periods[len(periods)-1].End = tr.Time
+		}
+	}
+	return periods
+}
+
+// outageAt returns the outage containing t
+func outageAt(outages []Interval, t time.Time) (Interval, bool) {
+	for _, o := range outages {
+		if o.Contains(t) {
+			return o, true
+		}
+	}
+	return Interval{}, false
+}
+
+// firstOpenWithin returns when the circuit first opened during the outage
+func firstOpenWithin(periods []Interval, outage Interval) (time.Time, bool) {
+	for _, p := range periods {
+		if outage.Contains(p.Start) {
+			return p.Start, true
+		}
+	}
+	return time.Time{}, false
+}
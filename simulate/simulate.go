@@ -0,0 +1,96 @@
+// Package simulate replays recorded call outcomes through a breaker configuration
+// in virtual time and reports when the breaker would have opened and closed,
+// for offline threshold tuning.
+package simulate
+
+import (
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/nick1jesky/circuit_breaker/cbtest"
+)
+
+// - builds the breaker under test, the options must be passed to the constructor
+// and the clock to any clock-aware thresholds (e.g. SlidingWindowThreshold.SetClock)
+type Factory func(clock circuitbreaker.Clock, opts ...circuitbreaker.Option) *circuitbreaker.CircuitBreaker
+
+// - is a state change observed during replay
+type Transition struct {
+	Time time.Time
+	From string
+	To   string
+}
+
+// - is a result of the replay
+type Report struct {
+	Calls       int
+	Allowed     int
+	Rejected    int
+	Transitions []Transition
+	// total time spent in the open state up to the last outcome
+	OpenTime time.Duration
+}
+
+// - replays outcomes in virtual time, the open -> half-open transition is timer driven
+// so transitions are reported when they logically happen
+func Run(outcomes []Outcome, factory Factory) Report {
+	var report Report
+	if len(outcomes) == 0 {
+		return report
+	}
+
+	clock := cbtest.NewFakeClock(outcomes[0].Time)
+	cb := factory(
+		clock,
+		circuitbreaker.WithClock(clock),
+		circuitbreaker.WithTransitionTimer(),
+		circuitbreaker.WithEventHandler(func(e circuitbreaker.Event) {
+			if e.Type == circuitbreaker.EventStateChange {
+				report.Transitions = append(report.Transitions, Transition{Time: e.Time, From: e.From, To: e.To})
+			}
+		}),
+	)
+
+	for _, o := range outcomes {
+		clock.AdvanceTo(o.Time)
+
+		report.Calls++
+		if !cb.Allow() {
+			report.Rejected++
+			continue
+		}
+
+		report.Allowed++
+		if o.Success {
+			cb.RecordSuccess()
+		} else {
+			cb.RecordFailure()
+		}
+	}
+
+	report.OpenTime = openTime(report.Transitions, outcomes[len(outcomes)-1].Time)
+	return report
+}
+
+// openTime sums durations of open periods up to end
+func openTime(transitions []Transition, end time.Time) time.Duration {
+	var (
+		total    time.Duration
+		openedAt time.Time
+		open     bool
+	)
+
+	for _, tr := range transitions {
+		if tr.To == circuitbreaker.StateOpened && !open {
+			openedAt, open = tr.Time, true
+		} else if tr.From == circuitbreaker.StateOpened && open {
+			total += tr.Time.Sub(openedAt)
+			open = false
+		}
+	}
+	if open {
+		total += end.Sub(openedAt)
+	}
+
+	return total
+}
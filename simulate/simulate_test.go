@@ -0,0 +1,111 @@
+package simulate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+	"github.com/nick1jesky/circuit_breaker/cbtest"
+)
+
+func TestRunCSV(t *testing.T) {
+	data := `timestamp,result
+1700000000000,failure
+1700000001000,failure
+1700000002000,success
+1700000007000,success
+`
+	outcomes, err := ReadCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(outcomes) != 4 {
+		t.Fatalf("Expected 4 outcomes, got %d", len(outcomes))
+	}
+
+	report := Run(outcomes, func(clock circuitbreaker.Clock, opts ...circuitbreaker.Option) *circuitbreaker.CircuitBreaker {
+		return circuitbreaker.NewCircuitBreaker(
+			circuitbreaker.NewInt64Threshold(2),
+			circuitbreaker.NewInt64Threshold(1),
+			5*time.Second,
+			opts...,
+		)
+	})
+
+	if report.Calls != 4 || report.Rejected != 1 {
+		t.Errorf("Expected 4 calls and 1 rejected, got %d and %d", report.Calls, report.Rejected)
+	}
+
+	start := time.UnixMilli(1700000000000)
+	expected := []Transition{
+		{Time: start.Add(time.Second), From: circuitbreaker.StateClosed, To: circuitbreaker.StateOpened},
+		{Time: start.Add(6 * time.Second), From: circuitbreaker.StateOpened, To: circuitbreaker.StateHalfOpen},
+		{Time: start.Add(7 * time.Second), From: circuitbreaker.StateHalfOpen, To: circuitbreaker.StateClosed},
+	}
+	if len(report.Transitions) != len(expected) {
+		t.Fatalf("Expected %d transitions, got %+v", len(expected), report.Transitions)
+	}
+	for i, tr := range report.Transitions {
+		if !tr.Time.Equal(expected[i].Time) || tr.From != expected[i].From || tr.To != expected[i].To {
+			t.Errorf("Transition %d: expected %+v, got %+v", i, expected[i], tr)
+		}
+	}
+
+	if report.OpenTime != 5*time.Second {
+		t.Errorf("Expected 5s open time, got %s", report.OpenTime)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	data := `[{"time":"2024-01-01T00:00:01Z","success":false},{"time":"2024-01-01T00:00:00Z","success":true}]`
+
+	outcomes, err := ReadJSON(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(outcomes) != 2 || !outcomes[0].Success || outcomes[1].Success {
+		t.Errorf("Expected outcomes sorted by time, got %+v", outcomes)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var outcomes []Outcome
+	for i := range 600 {
+		at := start.Add(time.Duration(i) * time.Second)
+		blip := i >= 60 && i < 63
+		outage := i >= 240 && i < 360
+		outcomes = append(outcomes, Outcome{Time: at, Success: !blip && !outage})
+	}
+
+	outages := DetectOutages(outcomes, time.Minute, 0.5, 10)
+	if len(outages) != 1 || !outages[0].Start.Equal(start.Add(4*time.Minute)) || !outages[0].End.Equal(start.Add(6*time.Minute)) {
+		t.Fatalf("Expected one outage from 4m to 6m, got %+v", outages)
+	}
+
+	recommendation := Recommend(outcomes, outages, SearchSpace{
+		FailureRates: []float64{0.2, 0.8},
+		Windows:      []time.Duration{10 * time.Second, time.Minute},
+		MinimumCalls: []int64{2, 10},
+		OpenTimeouts: []time.Duration{30 * time.Second},
+	})
+
+	if len(recommendation.Results) != 8 {
+		t.Fatalf("Expected 8 results, got %d", len(recommendation.Results))
+	}
+	best := recommendation.Best
+	if best.FalseTrips != 0 || best.MissedOutages != 0 {
+		t.Errorf("Expected best candidate without false trips and missed outages, got %+v", best)
+	}
+	worst := recommendation.Results[len(recommendation.Results)-1]
+	if worst.FalseTrips == 0 {
+		t.Errorf("Expected sensitive candidates to trip on the blip, got %+v", worst)
+	}
+
+	opts := make([]circuitbreaker.Option, 0, 2)
+	best.Candidate.Factory()(cbtest.NewFakeClock(start), opts...)
+	if opts[:1][0] != nil {
+		t.Error("Expected the options of the caller not to be written")
+	}
+}
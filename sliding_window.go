@@ -9,20 +9,22 @@ type SlidingWindowThreshold struct {
 	windowSize  time.Duration
 	maxFailures int
 	name        string
+	clock       Clock
 
 	mu           sync.RWMutex
 	failureTimes []time.Time
 }
 
 func NewSlidingWindowThreshold(
-  windowSize time.Duration, 
-  maxFailures int, 
-  name string,
+	windowSize time.Duration,
+	maxFailures int,
+	name string,
 ) *SlidingWindowThreshold {
 	return &SlidingWindowThreshold{
 		windowSize:   windowSize,
 		maxFailures:  maxFailures,
 		name:         name,
+		clock:        realClock{},
 		failureTimes: make([]time.Time, 0),
 	}
 }
@@ -31,18 +33,28 @@ func (sw *SlidingWindowThreshold) Check(value any) bool {
 	sw.mu.Lock()
 	defer sw.mu.Unlock()
 
-	now := time.Now()
+	sw.prune()
+
+	return len(sw.failureTimes) >= sw.maxFailures
+}
+
+// prune drops failures outside of the window, must be called under write lock
+func (sw *SlidingWindowThreshold) prune() {
+	now := sw.clock.Now()
 	windowStart := now.Add(-sw.windowSize)
 
-	validFailures := make([]time.Time, 0)
+	validFailures := make([]time.Time, 0, len(sw.failureTimes))
 	for _, ft := range sw.failureTimes {
+		// failures recorded before the clock jumped backwards are clamped to now,
+		// so they expire after one window instead of staying until the clock catches up
+		if ft.After(now) {
+			ft = now
+		}
 		if ft.After(windowStart) {
 			validFailures = append(validFailures, ft)
 		}
 	}
 	sw.failureTimes = validFailures
-
-	return len(sw.failureTimes) >= sw.maxFailures
 }
 
 func (sw *SlidingWindowThreshold) GetThreshold() any {
@@ -56,11 +68,63 @@ func (sw *SlidingWindowThreshold) String() string {
 func (sw *SlidingWindowThreshold) RecordFailure() {
 	sw.mu.Lock()
 	defer sw.mu.Unlock()
-	sw.failureTimes = append(sw.failureTimes, time.Now())
+	sw.failureTimes = append(sw.failureTimes, sw.clock.Now())
+}
+
+// - records a failure fed by the breaker at the time of the window clock, see WithOutcomeFeed
+func (sw *SlidingWindowThreshold) RecordOutcome(outcome Outcome) {
+	if !outcome.Success {
+		sw.RecordFailure()
+	}
 }
 
+// - returns number of failures within the window
 func (sw *SlidingWindowThreshold) GetCurrentFailures() int {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.prune()
+	return len(sw.failureTimes)
+}
+
+// - returns failures per second within the window
+func (sw *SlidingWindowThreshold) FailureRate() float64 {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.prune()
+	if sw.windowSize <= 0 {
+		return 0
+	}
+	return float64(len(sw.failureTimes)) / sw.windowSize.Seconds()
+}
+
+// - drops the failures of the window
+func (sw *SlidingWindowThreshold) Reset() {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.failureTimes = sw.failureTimes[:0]
+}
+
+// - returns the window size
+func (sw *SlidingWindowThreshold) WindowSize() time.Duration {
 	sw.mu.RLock()
 	defer sw.mu.RUnlock()
-	return len(sw.failureTimes)
+	return sw.windowSize
+}
+
+// - changes the window size at runtime, failures outside of the new window are dropped
+func (sw *SlidingWindowThreshold) SetWindowSize(windowSize time.Duration) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	sw.windowSize = windowSize
+	sw.prune()
+}
+
+// - replaces the wall clock used for the window, must be called before use
+func (sw *SlidingWindowThreshold) SetClock(clock Clock) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.clock = clock
 }
@@ -0,0 +1,83 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// - adds a degraded mode to the closed state: when threshold, softer than the failure
+// threshold, is crossed the breaker sheds the shed fraction of calls (0.5 sheds half)
+// with ErrOpenState, opening fully only when the failure threshold is hit, the mode
+// ends when a result no longer crosses threshold, changes are reported as EventDegraded
+// and EventDegradedEnd, threshold is checked like the failure threshold
+func WithSoftOpen(threshold CustomThreshold, shed float64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.soft = &softOpen{
+			threshold: threshold,
+			sw:        ChooseSwitch(threshold),
+			shed:      shed,
+			rand:      rand.Float64,
+		}
+	}
+}
+
+type softOpen struct {
+	threshold CustomThreshold
+	sw        Switch
+	shed      float64
+	rand      func() float64
+
+	degraded bool
+}
+
+// - reports whether the closed breaker sheds calls, see WithSoftOpen
+func (cb *CircuitBreaker) Degraded() bool {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.soft != nil && cb.soft.degraded && cb.state == StateClosed
+}
+
+// evaluateSoftOpen switches the degraded mode after a result recorded in closed state,
+// must be called under write lock
+func (cb *CircuitBreaker) evaluateSoftOpen(now time.Time, stats Stats) {
+	if cb.soft == nil {
+		return
+	}
+
+	value, ok := cb.thresholdValue(cb.soft.threshold, stats.ConsecutiveFailures, stats.Failures, stats.Counts)
+	crossed := ok && cb.check(cb.soft.sw, value)
+	switch {
+	case crossed && !cb.soft.degraded:
+		cb.soft.degraded = true
+		cb.queueEvent(Event{
+			Type:   EventDegraded,
+			From:   cb.state,
+			To:     cb.state,
+			Time:   now,
+			Reason: fmt.Sprintf("%s, shedding %.0f%% of calls", describeCheck("failure", value, cb.soft.threshold), cb.soft.shed*100),
+		})
+	case !crossed && cb.soft.degraded:
+		cb.endDegraded(now, "failures below the soft threshold")
+	}
+}
+
+// endDegraded leaves the degraded mode, must be called under write lock
+func (cb *CircuitBreaker) endDegraded(now time.Time, reason string) {
+	if cb.soft == nil || !cb.soft.degraded {
+		return
+	}
+	cb.soft.degraded = false
+	cb.queueEvent(Event{
+		Type:   EventDegradedEnd,
+		From:   cb.state,
+		To:     cb.state,
+		Time:   now,
+		Reason: reason,
+	})
+}
+
+// shed reports whether a call is shed in degraded mode, must be called under write lock
+func (cb *CircuitBreaker) shed() bool {
+	return cb.soft != nil && cb.soft.degraded && cb.state == StateClosed && cb.soft.rand() < cb.soft.shed
+}
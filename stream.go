@@ -0,0 +1,128 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// - guards long-lived connections (WebSocket, SSE, streaming RPC): failed connection
+// attempts and abnormal closures are recorded as failures, successful connects as
+// successes, and Reconnect holds reconnect loops back while the breaker is open
+type StreamGuard struct {
+	cb *CircuitBreaker
+
+	// reports whether the error a connection was closed with is abnormal,
+	// defaults to AbnormalClosure
+	IsAbnormal func(err error) bool
+	// connections closed earlier than MinLifetime count as failures even when
+	// closed normally, zero disables the check
+	MinLifetime time.Duration
+	// interval Reconnect checks the breaker again when the open timeout has
+	// elapsed but the breaker is still open, e.g. while draining
+	PollInterval time.Duration
+}
+
+// - is a constructor
+func NewStreamGuard(cb *CircuitBreaker) *StreamGuard {
+	return &StreamGuard{
+		cb:           cb,
+		IsAbnormal:   AbnormalClosure,
+		PollInterval: 100 * time.Millisecond,
+	}
+}
+
+// - is the default StreamGuard.IsAbnormal, a closure without error, with io.EOF
+// or by context cancellation is normal
+func AbnormalClosure(err error) bool {
+	return err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled)
+}
+
+// - is an established connection watched by the StreamGuard
+type Stream struct {
+	guard  *StreamGuard
+	tags   Tags
+	opened time.Time
+	once   sync.Once
+}
+
+// - establishes a connection with dial if the breaker allows it, a dial error is
+// recorded as a failure, Tags in ctx (see WithTags) label the connection
+func (g *StreamGuard) Connect(ctx context.Context, dial func(ctx context.Context) error) (*Stream, error) {
+	cb := g.cb
+	if err := cb.admit(1); err != nil {
+		cb.sinkRejection(ctx, err)
+		cb.observeCall(ctx, CallInfo{Err: err, Rejected: true})
+		return nil, cb.wrapError(err, "")
+	}
+
+	start := cb.clock.Now()
+	err := dial(ctx)
+	latency := cb.clock.Now().Sub(start)
+	cb.observeCall(ctx, CallInfo{Latency: latency, Err: err})
+
+	if cb.isCallerContextError(ctx, err) {
+		return nil, cb.wrapError(err, "")
+	}
+
+	tags := TagsFrom(ctx)
+	cb.recordLatencyTagged(latency, tags)
+	cb.recordTagged(err == nil, err, tags)
+	if err != nil {
+		return nil, cb.wrapError(err, "")
+	}
+
+	return &Stream{guard: g, tags: tags, opened: cb.clock.Now()}, nil
+}
+
+// - blocks until the breaker lets a reconnect attempt through or ctx is done,
+// a reconnect loop calls it before Connect instead of retrying into an open breaker
+func (g *StreamGuard) Reconnect(ctx context.Context) error {
+	for g.cb.State() == StateOpened {
+		if err := g.wait(ctx, g.cb.OpenRemaining()); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// wait sleeps on the breaker clock for d, at least PollInterval
+func (g *StreamGuard) wait(ctx context.Context, d time.Duration) error {
+	d = max(d, g.PollInterval)
+
+	done := make(chan struct{})
+	timer := g.cb.clock.AfterFunc(d, func() { close(done) })
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// - records the end of the connection, err is the reason it was closed with,
+// only the first call is recorded
+func (s *Stream) Close(err error) {
+	s.once.Do(func() {
+		cb := s.guard.cb
+		lifetime, _ := elapsed(cb.clock.Now(), s.opened)
+		isAbnormal := s.guard.IsAbnormal
+		if isAbnormal == nil {
+			isAbnormal = AbnormalClosure
+		}
+		abnormal := isAbnormal(err) || lifetime < s.guard.MinLifetime
+		if abnormal {
+			cb.recordTagged(false, err, s.tags)
+		}
+	})
+}
+
+// - returns how long the connection has been open
+func (s *Stream) Lifetime() time.Duration {
+	d, _ := elapsed(s.guard.cb.clock.Now(), s.opened)
+	return d
+}
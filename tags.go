@@ -0,0 +1,104 @@
+package circuitbreaker
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// - is per-call metadata passed to ExecuteContext through the context,
+// it is attached to the events caused by the call and labels per-operation metrics
+type Tags map[string]string
+
+// - is the tag naming the operation, calls with it get their own latency histogram in Metrics.Operations
+const TagOperation = "operation"
+
+// - are the tags set by httpbreaker for the request method and the breaker key
+const (
+	TagMethod = "method"
+	TagKey    = "key"
+)
+
+// - is the tag holding the trace ID of the call, set by otelbreaker,
+// exporters use it as exemplar of the transition it caused
+const TagTraceID = "trace_id"
+
+type tagsKey struct{}
+
+// - returns ctx carrying tags merged over the tags already in ctx
+func WithTags(ctx context.Context, tags Tags) context.Context {
+	merged := maps.Clone(TagsFrom(ctx))
+	if merged == nil {
+		merged = make(Tags, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// - returns the tags carried by ctx, nil if there are none
+func TagsFrom(ctx context.Context) Tags {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(tagsKey{}).(Tags)
+	return tags
+}
+
+// recordTagged records the outcome of a call, events caused by it carry tags,
+// the error of a failure is kept in the recent errors
+func (cb *CircuitBreaker) recordTagged(success bool, err error, tags Tags) {
+	cb.recordTaggedAt(time.Time{}, success, err, tags)
+}
+
+// recordTaggedAt is recordTagged for an outcome observed at now, zero now means the current time
+func (cb *CircuitBreaker) recordTaggedAt(now time.Time, success bool, err error, tags Tags) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	queued, generation := len(cb.pending), cb.generation
+	if now.IsZero() {
+		now = cb.clock.Now()
+	}
+	if success {
+		cb.recordSuccess(now)
+	} else {
+		cb.keepError(now, err)
+		cb.recordFailure(now)
+	}
+
+	if tags == nil {
+		return
+	}
+	if cb.generation != generation {
+		cb.lastTransition.Tags = tags
+		if n := len(cb.history); n > 0 {
+			cb.history[n-1].Tags = tags
+		}
+	}
+	for i := queued; i < len(cb.pending); i++ {
+		cb.pending[i].Tags = tags
+	}
+}
+
+// recordLatencyTagged records the latency of a call, also per operation when it is tagged with one
+func (cb *CircuitBreaker) recordLatencyTagged(d time.Duration, tags Tags) {
+	cb.RecordLatency(d)
+
+	operation, ok := tags[TagOperation]
+	if !ok {
+		return
+	}
+
+	cb.mu.Lock()
+	if cb.operations == nil {
+		cb.operations = make(map[string]*LatencyHistogram)
+	}
+	histogram, ok := cb.operations[operation]
+	if !ok {
+		histogram = NewLatencyHistogram()
+		cb.operations[operation] = histogram
+	}
+	cb.mu.Unlock()
+
+	histogram.Record(d)
+}
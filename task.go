@@ -0,0 +1,75 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// - is returned by Task.Run when the task was not started because the breaker rejected
+// it and no reschedule function is set, worker frameworks requeue the task after Delay
+// instead of counting a failed attempt
+type RescheduleError struct {
+	Delay time.Duration
+	Err   error
+}
+
+// - implements error
+func (e *RescheduleError) Error() string {
+	return fmt.Sprintf("task rescheduled in %s: %v", e.Delay, e.Err)
+}
+
+// - returns the rejection error
+func (e *RescheduleError) Unwrap() error {
+	return e.Err
+}
+
+// - wraps an activity or job handler of a queue worker: the breaker is checked before
+// the task starts and a rejected task is rescheduled rather than failed
+type Task[T any] struct {
+	cb         *CircuitBreaker
+	run        func(ctx context.Context, payload T) error
+	reschedule func(ctx context.Context, payload T, delay time.Duration) error
+
+	// delay of rejections that do not depend on the open timeout, e.g. rate limiting,
+	// and the lower bound of every delay
+	MinDelay time.Duration
+}
+
+// - is a constructor, reschedule (may be nil) puts the payload back to the queue,
+// without it Run returns *RescheduleError
+func NewTask[T any](
+	cb *CircuitBreaker,
+	run func(ctx context.Context, payload T) error,
+	reschedule func(ctx context.Context, payload T, delay time.Duration) error,
+) *Task[T] {
+	return &Task[T]{cb: cb, run: run, reschedule: reschedule, MinDelay: time.Second}
+}
+
+// - runs the task through the breaker, a rejected task is rescheduled after the
+// remaining open timeout and nil is returned, errors of the task are returned as is
+func (t *Task[T]) Run(ctx context.Context, payload T) error {
+	started := false
+	err := t.cb.ExecuteContext(ctx, func(ctx context.Context) error {
+		started = true
+		return t.run(ctx, payload)
+	})
+	if started || !isRejection(err) {
+		return err
+	}
+
+	delay := max(t.cb.OpenRemaining(), t.MinDelay)
+	if t.reschedule == nil {
+		return &RescheduleError{Delay: delay, Err: err}
+	}
+	return t.reschedule(ctx, payload, delay)
+}
+
+// isRejection reports whether err means the call was not admitted
+func isRejection(err error) bool {
+	return errors.Is(err, ErrOpenState) ||
+		errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrConcurrencyLimited) ||
+		errors.Is(err, ErrDraining)
+}
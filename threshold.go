@@ -1,11 +1,22 @@
 package circuitbreaker
 
+import (
+	"fmt"
+	"math"
+)
+
 // - is an interface for all types of threshold values
 type CustomThreshold interface {
 	Check(value any) bool
 	GetThreshold() any
 }
 
+// - is an optional extension of CustomThreshold reporting values it can not check,
+// built-in thresholds return an error wrapping ErrUnsupporterType
+type ErrorThreshold interface {
+	CheckErr(value any) (bool, error)
+}
+
 // - defines interface for controll circuit breaker state switching
 type Switch interface {
 	Check(value any) bool
@@ -21,6 +32,11 @@ func (s CustomSwitch) Check(value any) bool {
 	return s.threshold.Check(value)
 }
 
+// - check reporting unsupported values when the threshold implements ErrorThreshold
+func (s CustomSwitch) CheckErr(value any) (bool, error) {
+	return checkThreshold(s.threshold, value)
+}
+
 // - choses realisation of Switch
 func ChooseSwitch(threshold CustomThreshold) Switch {
 	return CustomSwitch{threshold: threshold}
@@ -37,20 +53,31 @@ func NewInt64Threshold(threshold int64) *Int64Threshold {
 }
 
 func (t *Int64Threshold) Check(value any) bool {
-	switch v := value.(type) {
-	case int64:
-		return v >= t.threshold
-	case int:
-		return int64(v) >= t.threshold
-	default:
-		return false
+	ok, _ := t.CheckErr(value)
+	return ok
+}
+
+func (t *Int64Threshold) CheckErr(value any) (bool, error) {
+	if v, ok := asInt64(value); ok {
+		return v >= t.threshold, nil
+	}
+	if v, ok := asUint64(value); ok {
+		return v > math.MaxInt64 || int64(v) >= t.threshold, nil
 	}
+	if v, ok := asFloat64(value); ok {
+		return v >= float64(t.threshold), nil
+	}
+	return false, unsupportedType(value)
 }
 
 func (t *Int64Threshold) GetThreshold() any {
 	return t.threshold
 }
 
+func (t *Int64Threshold) String() string {
+	return fmt.Sprintf("Int64Threshold: %d", t.threshold)
+}
+
 type Float64Threshold struct {
 	threshold float64
 }
@@ -60,20 +87,114 @@ func NewFloat64Threshold(threshold float64) *Float64Threshold {
 }
 
 func (t *Float64Threshold) Check(value any) bool {
+	ok, _ := t.CheckErr(value)
+	return ok
+}
+
+func (t *Float64Threshold) CheckErr(value any) (bool, error) {
+	if v, ok := asFloat64(value); ok {
+		return v >= t.threshold, nil
+	}
+	if v, ok := asInt64(value); ok {
+		return float64(v) >= t.threshold, nil
+	}
+	if v, ok := asUint64(value); ok {
+		return float64(v) >= t.threshold, nil
+	}
+	return false, unsupportedType(value)
+}
+
+func (t *Float64Threshold) GetThreshold() any {
+	return t.threshold
+}
+
+func (t *Float64Threshold) String() string {
+	return fmt.Sprintf("Float64Threshold: %g", t.threshold)
+}
+
+// - closes the circuit when at least minRate of at least minSamples calls succeeded,
+// e.g. NewSuccessRateThreshold(0.9, 20) for "≥90% of at least 20 probes", as the success
+// threshold a failed probe does not reopen the circuit before minSamples probes completed
+type SuccessRateThreshold struct {
+	minRate    float64
+	minSamples int
+}
+
+func NewSuccessRateThreshold(minRate float64, minSamples int) *SuccessRateThreshold {
+	return &SuccessRateThreshold{minRate: minRate, minSamples: minSamples}
+}
+
+func (t *SuccessRateThreshold) Check(value any) bool {
+	ok, _ := t.CheckErr(value)
+	return ok
+}
+
+func (t *SuccessRateThreshold) CheckErr(value any) (bool, error) {
+	counts, ok := value.(Counts)
+	if !ok {
+		return false, unsupportedType(value)
+	}
+	if counts.Total == 0 || counts.Total < int64(t.minSamples) {
+		return false, nil
+	}
+	return float64(counts.Successes)/float64(counts.Total) >= t.minRate, nil
+}
+
+func (t *SuccessRateThreshold) GetThreshold() any {
+	return t.minRate
+}
+
+func (t *SuccessRateThreshold) String() string {
+	return fmt.Sprintf("SuccessRateThreshold: %.0f%% of at least %d calls", t.minRate*100, t.minSamples)
+}
+
+func asInt64(value any) (int64, bool) {
 	switch v := value.(type) {
-	case float64:
-		return v >= t.threshold
-	case float32:
-		return float64(v) >= t.threshold
 	case int:
-		return float64(v) >= t.threshold
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
 	case int64:
-		return float64(v) >= t.threshold
+		return v, true
 	default:
-		return false
+		return 0, false
 	}
 }
 
-func (t *Float64Threshold) GetThreshold() any {
-	return t.threshold
+func asUint64(value any) (uint64, bool) {
+	switch v := value.(type) {
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case uintptr:
+		return uint64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func asFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func unsupportedType(value any) error {
+	return fmt.Errorf("%w: %T", ErrUnsupporterType, value)
 }
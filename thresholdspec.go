@@ -0,0 +1,210 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// - declares a threshold in a JSON or YAML config file, e.g.
+//
+//	{"type": "sliding-window", "params": {"window": "30s", "max_failures": 5}}
+//
+// built-in types:
+//
+//	int64           params: value
+//	float64         params: value
+//	success-rate    params: min_rate, min_samples
+//	sliding-window  params: window (duration string), max_failures, name
+//	any             children: thresholds combined by AnyThreshold
+type ThresholdSpec struct {
+	Type     string          `json:"type" yaml:"type"`
+	Params   map[string]any  `json:"params,omitempty" yaml:"params,omitempty"`
+	Children []ThresholdSpec `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// - creates the threshold declared by spec
+type ThresholdFactory func(spec ThresholdSpec) (CustomThreshold, error)
+
+var thresholdTypes = struct {
+	sync.RWMutex
+	factories map[string]ThresholdFactory
+}{
+	factories: map[string]ThresholdFactory{
+		"int64":          newInt64FromSpec,
+		"float64":        newFloat64FromSpec,
+		"success-rate":   newSuccessRateFromSpec,
+		"sliding-window": newSlidingWindowFromSpec,
+	},
+}
+
+func init() {
+	// registered here, newAnyFromSpec refers back to thresholdTypes through NewThreshold
+	thresholdTypes.factories["any"] = newAnyFromSpec
+}
+
+// - registers a custom threshold type, built-in types can be replaced
+func RegisterThreshold(name string, factory ThresholdFactory) {
+	thresholdTypes.Lock()
+	defer thresholdTypes.Unlock()
+
+	thresholdTypes.factories[name] = factory
+}
+
+// - returns the names of registered threshold types sorted
+func ThresholdTypes() []string {
+	thresholdTypes.RLock()
+	defer thresholdTypes.RUnlock()
+
+	names := make([]string, 0, len(thresholdTypes.factories))
+	for name := range thresholdTypes.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// - instantiates the threshold declared by spec, returns an error wrapping
+// ErrUnknownThreshold or ErrInvalidThreshold when spec can not be used
+func NewThreshold(spec ThresholdSpec) (CustomThreshold, error) {
+	thresholdTypes.RLock()
+	factory, ok := thresholdTypes.factories[spec.Type]
+	thresholdTypes.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownThreshold, spec.Type)
+	}
+	return factory(spec)
+}
+
+// - returns the spec of a built-in threshold, so it can be written to a config file
+func SpecOf(threshold CustomThreshold) (ThresholdSpec, error) {
+	switch t := threshold.(type) {
+	case *Int64Threshold:
+		return ThresholdSpec{Type: "int64", Params: map[string]any{"value": t.threshold}}, nil
+	case *Float64Threshold:
+		return ThresholdSpec{Type: "float64", Params: map[string]any{"value": t.threshold}}, nil
+	case *SuccessRateThreshold:
+		return ThresholdSpec{Type: "success-rate", Params: map[string]any{
+			"min_rate":    t.minRate,
+			"min_samples": t.minSamples,
+		}}, nil
+	case *SlidingWindowThreshold:
+		t.mu.RLock()
+		defer t.mu.RUnlock()
+		return ThresholdSpec{Type: "sliding-window", Params: map[string]any{
+			"window":       t.windowSize.String(),
+			"max_failures": t.maxFailures,
+			"name":         t.name,
+		}}, nil
+	case *AnyThreshold:
+		spec := ThresholdSpec{Type: "any"}
+		for _, child := range t.thresholds {
+			childSpec, err := SpecOf(child)
+			if err != nil {
+				return ThresholdSpec{}, err
+			}
+			spec.Children = append(spec.Children, childSpec)
+		}
+		return spec, nil
+	default:
+		return ThresholdSpec{}, fmt.Errorf("%w: %T", ErrUnknownThreshold, threshold)
+	}
+}
+
+func newInt64FromSpec(spec ThresholdSpec) (CustomThreshold, error) {
+	value, err := spec.int64Param("value")
+	if err != nil {
+		return nil, err
+	}
+	return NewInt64Threshold(value), nil
+}
+
+func newFloat64FromSpec(spec ThresholdSpec) (CustomThreshold, error) {
+	value, err := spec.float64Param("value")
+	if err != nil {
+		return nil, err
+	}
+	return NewFloat64Threshold(value), nil
+}
+
+func newSuccessRateFromSpec(spec ThresholdSpec) (CustomThreshold, error) {
+	minRate, err := spec.float64Param("min_rate")
+	if err != nil {
+		return nil, err
+	}
+	minSamples, err := spec.int64Param("min_samples")
+	if err != nil {
+		return nil, err
+	}
+	return NewSuccessRateThreshold(minRate, int(minSamples)), nil
+}
+
+func newSlidingWindowFromSpec(spec ThresholdSpec) (CustomThreshold, error) {
+	window, err := spec.durationParam("window")
+	if err != nil {
+		return nil, err
+	}
+	maxFailures, err := spec.int64Param("max_failures")
+	if err != nil {
+		return nil, err
+	}
+	name, _ := spec.Params["name"].(string)
+	return NewSlidingWindowThreshold(window, int(maxFailures), name), nil
+}
+
+func newAnyFromSpec(spec ThresholdSpec) (CustomThreshold, error) {
+	if len(spec.Children) == 0 {
+		return nil, fmt.Errorf("%w: %s: no children", ErrInvalidThreshold, spec.Type)
+	}
+
+	thresholds := make([]CustomThreshold, 0, len(spec.Children))
+	for _, child := range spec.Children {
+		threshold, err := NewThreshold(child)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	return NewAnyThreshold(thresholds...), nil
+}
+
+func (spec ThresholdSpec) int64Param(name string) (int64, error) {
+	value := spec.Params[name]
+	if v, ok := asInt64(value); ok {
+		return v, nil
+	}
+	// JSON numbers are decoded as float64
+	if v, ok := asFloat64(value); ok && v == float64(int64(v)) {
+		return int64(v), nil
+	}
+	return 0, spec.invalidParam(name, value)
+}
+
+func (spec ThresholdSpec) float64Param(name string) (float64, error) {
+	value := spec.Params[name]
+	if v, ok := asFloat64(value); ok {
+		return v, nil
+	}
+	if v, ok := asInt64(value); ok {
+		return float64(v), nil
+	}
+	return 0, spec.invalidParam(name, value)
+}
+
+func (spec ThresholdSpec) durationParam(name string) (time.Duration, error) {
+	value, ok := spec.Params[name].(string)
+	if !ok {
+		return 0, spec.invalidParam(name, spec.Params[name])
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, spec.invalidParam(name, value)
+	}
+	return d, nil
+}
+
+func (spec ThresholdSpec) invalidParam(name string, value any) error {
+	return fmt.Errorf("%w: %s: param %q: %v", ErrInvalidThreshold, spec.Type, name, value)
+}
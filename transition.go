@@ -0,0 +1,73 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"time"
+)
+
+// - is a snapshot of call counters, it is also the value a CustomThreshold other than
+// Int64Threshold, Float64Threshold and the window and composite thresholds gets in Check,
+// replacing the anonymous struct{ Successes, Failures, Total int64 } passed before,
+// thresholds asserting that struct must assert Counts instead
+type Counts struct {
+	Successes int64 `json:"successes"`
+	Failures  int64 `json:"failures"`
+	Total     int64 `json:"total"`
+}
+
+// - describes a state transition and why it happened
+type TransitionInfo struct {
+	From string
+	To   string
+	At   time.Time
+	// human readable cause, e.g. "failure rate 63% >= 50%"
+	Reason string
+	// counters at the moment of transition
+	Counts Counts
+	// tags of the call that caused the transition, see WithTags
+	Tags Tags
+	// who forced the transition, see ForceOpenBy
+	Actor string
+}
+
+// counts returns current counters, must be called under lock
+func (cb *CircuitBreaker) counts() Counts {
+	return Counts{
+		Successes: cb.successes,
+		Failures:  cb.failures,
+		Total:     cb.successes + cb.failures,
+	}
+}
+
+// - returns the last state transition, zero value when the breaker never switched
+func (cb *CircuitBreaker) LastTransition() TransitionInfo {
+	// applies the pending lazy open -> half-open transition
+	cb.State()
+
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.lastTransition
+}
+
+// describeCheck explains which threshold passed and with which value
+func describeCheck(kind string, value any, threshold CustomThreshold) string {
+	switch th := threshold.(type) {
+	case *Int64Threshold:
+		return fmt.Sprintf("%ss %v >= %d", kind, value, th.threshold)
+	case *Float64Threshold:
+		rate, _ := value.(float64)
+		return fmt.Sprintf("%s rate %.0f%% >= %.0f%%", kind, rate*100, th.threshold*100)
+	case fmt.Stringer:
+		return fmt.Sprintf("%s threshold reached: %s", kind, th)
+	default:
+		return fmt.Sprintf("%s threshold reached: %T", kind, threshold)
+	}
+}
+
+// describeThreshold returns the configured value of threshold
+func describeThreshold(threshold CustomThreshold) string {
+	if s, ok := threshold.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", threshold)
+}
@@ -0,0 +1,122 @@
+package circuitbreaker
+
+import "time"
+
+// - is the snapshot passed to TripPolicy after every result recorded in closed state,
+// Counts accumulate since the last transition
+type Stats struct {
+	Counts
+	ConsecutiveSuccesses int64
+	ConsecutiveFailures  int64
+	// the result that triggered the evaluation and when it was recorded
+	Success bool
+	Time    time.Time
+	// last values of the load metrics, see RecordLoad, must not be modified
+	Load map[string]float64
+}
+
+// - decides when the closed circuit opens, independently of the recovery strategy
+type TripPolicy interface {
+	// ShouldTrip is called under the breaker lock after every result recorded in closed state,
+	// reason describes the decision to trip
+	ShouldTrip(stats Stats) (trip bool, reason string)
+}
+
+// - is a TripPolicy defined by a function
+type TripFunc func(stats Stats) (bool, string)
+
+func (f TripFunc) ShouldTrip(stats Stats) (bool, string) {
+	return f(stats)
+}
+
+// - replaces the failure threshold with the policy
+func WithTripPolicy(policy TripPolicy) Option {
+	return func(cb *CircuitBreaker) {
+		cb.tripPolicy = policy
+	}
+}
+
+// thresholdTrip is the TripPolicy of the failure threshold passed to NewCircuitBreaker
+type thresholdTrip struct {
+	cb *CircuitBreaker
+}
+
+func (t thresholdTrip) ShouldTrip(stats Stats) (bool, string) {
+	value, ok := t.cb.thresholdValue(t.cb.failureThreshold, stats.ConsecutiveFailures, stats.Failures, stats.Counts)
+	if ok && t.cb.check(t.cb.failureSwitch, value) {
+		return true, describeCheck("failure", value, t.cb.failureThreshold)
+	}
+	return false, ""
+}
+
+// thresholdValue adapts the counters to the value the threshold checks: Int64Threshold gets
+// the consecutive count, Float64Threshold the rate of the accumulated count, a WindowThreshold
+// the WindowStats, AnyThreshold the values of its thresholds, other thresholds the Counts,
+// false means the rate must not be evaluated yet because fewer than the minimum number
+// of calls were recorded
+func (cb *CircuitBreaker) thresholdValue(threshold CustomThreshold, consecutive, accumulated int64, counts Counts) (any, bool) {
+	switch th := threshold.(type) {
+	case *Int64Threshold:
+		return consecutive, true
+	case *Float64Threshold:
+		if counts.Total == 0 || counts.Total < cb.minimumCalls {
+			return 0.0, false
+		}
+		return float64(accumulated) / float64(counts.Total), true
+	case WindowThreshold:
+		return cb.windowStats(th, counts), true
+	case *AnyThreshold:
+		values := make(childValues, len(th.thresholds))
+		for i, child := range th.thresholds {
+			values[i].value, values[i].ok = cb.thresholdValue(child, consecutive, accumulated, counts)
+		}
+		return values, true
+	default:
+		return counts, true
+	}
+}
+
+// stats returns the snapshot for the trip policy, must be called under lock
+func (cb *CircuitBreaker) stats(now time.Time, success bool) Stats {
+	return Stats{
+		Counts:               cb.counts(),
+		ConsecutiveSuccesses: cb.consecutiveSuccesses,
+		ConsecutiveFailures:  cb.consecutiveFailures,
+		Success:              success,
+		Time:                 now,
+		Load:                 cb.load,
+	}
+}
+
+// evaluateTrip opens the circuit when the trip policy decides so, must be called under write lock
+func (cb *CircuitBreaker) evaluateTrip(now time.Time, success bool) {
+	if cb.inWarmup(now) || cb.dwelling(now) {
+		return
+	}
+
+	policy := cb.tripPolicy
+	if policy == nil {
+		policy = thresholdTrip{cb: cb}
+	}
+	stats := cb.stats(now, success)
+	trip, reason := false, ""
+	// the threshold adapter only trips on failures, a success never opens a recovered circuit,
+	// custom policies see every result
+	if cb.tripPolicy != nil || !success {
+		trip, reason = policy.ShouldTrip(stats)
+		cb.compareCandidate(now, stats, trip)
+	}
+	if !trip {
+		// a result recorded while overloaded trips like the policy, through grace and soft-open
+		reason, trip = cb.overloaded()
+	}
+	if !trip {
+		cb.cancelGrace()
+		cb.evaluateSoftOpen(now, stats)
+		return
+	}
+	if cb.inGrace(now) {
+		return
+	}
+	cb.setState(StateOpened, reason)
+}
@@ -0,0 +1,58 @@
+package circuitbreaker
+
+// - is the read-only part of the breaker API, for monitoring code that must
+// not record results or force states
+type View interface {
+	Name() string
+	State() string
+	Counts() Counts
+	LastTransition() TransitionInfo
+}
+
+var _ View = (*CircuitBreaker)(nil)
+
+// - returns counters of the current state, they are reset on every transition
+func (cb *CircuitBreaker) Counts() Counts {
+	cb.State()
+
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.counts()
+}
+
+// - returns a read-only view of the breaker, unlike *CircuitBreaker used as View
+// it can not be type asserted back to the full API
+func (cb *CircuitBreaker) View() View {
+	return breakerView{cb: cb}
+}
+
+// breakerView hides the write methods of the breaker
+type breakerView struct {
+	cb *CircuitBreaker
+}
+
+func (v breakerView) Name() string {
+	return v.cb.Name()
+}
+
+func (v breakerView) State() string {
+	return v.cb.State()
+}
+
+func (v breakerView) Counts() Counts {
+	return v.cb.Counts()
+}
+
+func (v breakerView) LastTransition() TransitionInfo {
+	return v.cb.LastTransition()
+}
+
+// - returns read-only views of all breakers sorted by name
+func (r *Registry) Views() []View {
+	all := r.All()
+	views := make([]View, 0, len(all))
+	for _, cb := range all {
+		views = append(views, cb.View())
+	}
+	return views
+}
@@ -0,0 +1,26 @@
+package circuitbreaker
+
+import "time"
+
+// - keeps the breaker from opening for the first duration and the first minCalls
+// recorded results after creation or Reset, zero disables the corresponding guard
+func WithWarmup(duration time.Duration, minCalls int64) Option {
+	return func(cb *CircuitBreaker) {
+		cb.warmupDuration = duration
+		cb.warmupCalls = minCalls
+	}
+}
+
+// inWarmup reports whether tripping is still suppressed, must be called under write lock
+func (cb *CircuitBreaker) inWarmup(now time.Time) bool {
+	if cb.warmupDuration > 0 {
+		d, skewed := elapsed(now, cb.warmupStart)
+		if skewed {
+			cb.warmupStart = now
+		}
+		if d < cb.warmupDuration {
+			return true
+		}
+	}
+	return cb.warmupCalls > 0 && cb.recordedCalls <= cb.warmupCalls
+}
@@ -0,0 +1,60 @@
+package circuitbreaker
+
+// - is a set of transitions clearing window-based thresholds and trip policies,
+// see WithWindowReset
+type WindowReset int
+
+const (
+	// clear the windows when the circuit opens, the probes start from an empty window
+	ResetOnOpen WindowReset = 1 << iota
+	// clear the windows when the circuit enters half-open state
+	ResetOnHalfOpen
+	// clear the windows when the circuit closes, failures seen before the outage
+	// do not count against the recovered dependency
+	ResetOnClose
+	// keep the windows across transitions, they only expire with time
+	ResetNever WindowReset = 0
+)
+
+// - selects the transitions clearing the failure and success thresholds, the trip policy
+// and the candidate threshold implementing Reset() (e.g. SlidingWindowThreshold,
+// MultiWindowTrip, WindowRateTrip), without the option the windows are cleared only
+// when the circuit closes, as with ResetOnClose
+func WithWindowReset(on WindowReset) Option {
+	return func(cb *CircuitBreaker) {
+		cb.windowReset = on
+		cb.windowResetSet = true
+	}
+}
+
+// resetWindows clears the windows configured for the transition to state,
+// must be called under write lock
+func (cb *CircuitBreaker) resetWindows(state string) {
+	on := cb.windowReset
+	if !cb.windowResetSet {
+		on = ResetOnClose
+	}
+
+	switch {
+	case state == StateOpened && on&ResetOnOpen != 0,
+		state == StateHalfOpen && on&ResetOnHalfOpen != 0,
+		state == StateClosed && on&ResetOnClose != 0:
+	default:
+		return
+	}
+	resetWindow(cb.tripPolicy)
+	resetWindow(cb.failureThreshold)
+	if cb.successThreshold != cb.failureThreshold {
+		resetWindow(cb.successThreshold)
+	}
+	if cb.candidate != nil {
+		resetWindow(cb.candidate.threshold)
+	}
+}
+
+// resetWindow clears w when it keeps a window
+func resetWindow(w any) {
+	if r, ok := w.(interface{ Reset() }); ok {
+		r.Reset()
+	}
+}
@@ -0,0 +1,86 @@
+package circuitbreaker
+
+import (
+	"slices"
+	"time"
+)
+
+// - is the value a WindowThreshold gets in Check instead of Counts: the counters of the
+// breaker with the period they were accumulated over, so a threshold keeping its own
+// history can relate both, e.g. count only the part of its window the counters cover
+type WindowStats struct {
+	Counts
+	ConsecutiveSuccesses int64
+	ConsecutiveFailures  int64
+	// start of the counting period, the last transition or counter rotation
+	Since time.Time
+	// time elapsed since Since, capped at the window of the threshold
+	Elapsed time.Duration
+	Window  time.Duration
+	Now     time.Time
+}
+
+// - is an optional extension of CustomThreshold keeping its own history over a window,
+// the breaker passes WindowStats to its Check
+type WindowThreshold interface {
+	CustomThreshold
+	WindowSize() time.Duration
+}
+
+// windowStats returns the value for threshold, must be called under lock
+func (cb *CircuitBreaker) windowStats(threshold WindowThreshold, counts Counts) WindowStats {
+	now := cb.clock.Now()
+	window := threshold.WindowSize()
+	d, _ := elapsed(now, cb.countersStart)
+	return WindowStats{
+		Counts:               counts,
+		ConsecutiveSuccesses: cb.consecutiveSuccesses,
+		ConsecutiveFailures:  cb.consecutiveFailures,
+		Since:                cb.countersStart,
+		Elapsed:              min(d, window),
+		Window:               window,
+		Now:                  now,
+	}
+}
+
+// - is an optional extension of CustomThreshold keeping its own history of results,
+// see WithOutcomeFeed
+type OutcomeRecorder interface {
+	RecordOutcome(outcome Outcome)
+}
+
+// - makes the breaker record every result counted in closed state into its failure,
+// candidate and soft-open thresholds implementing OutcomeRecorder before checking them,
+// so thresholds built from a config (see NewThreshold) are fed without the caller holding
+// them, callers feeding a threshold themselves (e.g. SlidingWindowThreshold.RecordFailure)
+// must not enable it, Config.New enables it
+func WithOutcomeFeed() Option {
+	return func(cb *CircuitBreaker) {
+		cb.feedOutcomes = true
+	}
+}
+
+// recordOutcome feeds a result to the thresholds checked in closed state, a threshold
+// used in several roles is fed once, must be called under write lock
+func (cb *CircuitBreaker) recordOutcome(now time.Time, success bool) {
+	if !cb.feedOutcomes {
+		return
+	}
+	thresholds := []CustomThreshold{cb.failureThreshold}
+	if cb.candidate != nil {
+		thresholds = append(thresholds, cb.candidate.threshold)
+	}
+	if cb.soft != nil {
+		thresholds = append(thresholds, cb.soft.threshold)
+	}
+
+	var fed []OutcomeRecorder
+	for _, threshold := range thresholds {
+		recorder, ok := threshold.(OutcomeRecorder)
+		if !ok || slices.Contains(fed, recorder) {
+			continue
+		}
+		recorder.RecordOutcome(Outcome{Time: now, Success: success})
+		fed = append(fed, recorder)
+	}
+}
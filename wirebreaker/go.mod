@@ -0,0 +1,10 @@
+module github.com/nick1jesky/circuit_breaker/wirebreaker
+
+go 1.24.2
+
+require (
+	github.com/google/wire v0.7.0
+	github.com/nick1jesky/circuit_breaker v0.0.0
+)
+
+replace github.com/nick1jesky/circuit_breaker => ../
@@ -0,0 +1,49 @@
+// Package wirebreaker provides circuit breakers to github.com/google/wire injectors: a registry
+// built from config, the admin handler and a cleanup draining the breakers.
+//
+//	func initService(configs map[string]circuitbreaker.Config) (*Service, func(), error) {
+//		wire.Build(wirebreaker.ProviderSet, newService)
+//		return nil, nil, nil
+//	}
+package wirebreaker
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/wire"
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+// - is how long the cleanup of NewRegistry waits for the calls in flight
+const DrainTimeout = 30 * time.Second
+
+// - provides the *circuitbreaker.Registry built from the map[string]circuitbreaker.Config
+// of the injector, its AdminHandler authenticated by the circuitbreaker.Identify of the
+// injector and a circuitbreaker.Factory of its breakers
+var ProviderSet = wire.NewSet(NewRegistry, NewAdminHandler, circuitbreaker.RegistryFactory)
+
+// - is the admin API of the registry, see circuitbreaker.NewAdminHandler
+type AdminHandler http.Handler
+
+// - creates the registry with circuitbreaker.NewRegistryFromConfig, the cleanup drains it
+// for at most DrainTimeout
+func NewRegistry(configs map[string]circuitbreaker.Config) (*circuitbreaker.Registry, func(), error) {
+	registry, err := circuitbreaker.NewRegistryFromConfig(configs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), DrainTimeout)
+		defer cancel()
+		_ = registry.Drain(ctx)
+	}
+	return registry, cleanup, nil
+}
+
+// - returns the admin API of the registry, mount it with http.StripPrefix
+func NewAdminHandler(registry *circuitbreaker.Registry, identify circuitbreaker.Identify) AdminHandler {
+	return circuitbreaker.NewAdminHandler(registry, identify)
+}
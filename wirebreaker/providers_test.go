@@ -0,0 +1,55 @@
+package wirebreaker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	circuitbreaker "github.com/nick1jesky/circuit_breaker"
+)
+
+func TestProviders(t *testing.T) {
+	configs := map[string]circuitbreaker.Config{
+		"payments": {
+			Failure:     circuitbreaker.ThresholdSpec{Type: "int64", Params: map[string]any{"value": 3.0}},
+			Success:     circuitbreaker.ThresholdSpec{Type: "int64", Params: map[string]any{"value": 1.0}},
+			OpenTimeout: circuitbreaker.Duration(time.Minute),
+		},
+	}
+
+	// what wire generates for ProviderSet
+	registry, cleanup, err := NewRegistry(configs)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := NewAdminHandler(registry, circuitbreaker.BearerIdentity(map[string]string{"secret": "alice"}))
+	factory := circuitbreaker.RegistryFactory(registry)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/breakers/payments", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the breaker status, got %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/breakers/payments/force-open", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the authenticated action to pass, got %d", rec.Code)
+	}
+
+	payments, err := factory("payments")
+	if err != nil {
+		t.Fatalf("Expected the configured breaker, got %v", err)
+	}
+	payments.(*circuitbreaker.CircuitBreaker).Reset()
+	cleanup()
+	if payments.Allow() {
+		t.Error("Expected the cleanup to drain the breaker")
+	}
+
+	if _, _, err := NewRegistry(map[string]circuitbreaker.Config{"broken": {}}); err == nil {
+		t.Error("Expected an invalid config to fail")
+	}
+}
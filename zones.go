@@ -0,0 +1,67 @@
+package circuitbreaker
+
+import (
+	"slices"
+	"strings"
+)
+
+// - separates service and zone in the keys of zone-aware breakers
+const zoneSeparator = "@"
+
+// - is the state of the breaker of a service in one zone
+type ZoneState struct {
+	Zone  string
+	State string
+}
+
+// - joins service and zone into a KeyedBreaker key, e.g. "payments@eu-west-1"
+func ZoneKey(service, zone string) string {
+	return service + zoneSeparator + zone
+}
+
+// - returns the breaker of the service in the zone, creating it when needed
+func (k *KeyedBreaker) GetZone(service, zone string) *CircuitBreaker {
+	return k.Get(ZoneKey(service, zone))
+}
+
+// - returns the states of the known zones of the service sorted by zone
+func (k *KeyedBreaker) Zones(service string) []ZoneState {
+	prefix := service + zoneSeparator
+
+	var zones []ZoneState
+	for _, key := range k.Keys() {
+		zone, ok := strings.CutPrefix(key, prefix)
+		if !ok || strings.Contains(zone, zoneSeparator) {
+			continue
+		}
+		if cb, ok := k.Lookup(key); ok {
+			zones = append(zones, ZoneState{Zone: zone, State: cb.State()})
+		}
+	}
+	return zones
+}
+
+// - orders the candidate zones of the service for routing: closed circuits first,
+// then half-open and open last, zones without a breaker count as closed,
+// the candidate order is kept within the same state
+func (k *KeyedBreaker) PreferredZones(service string, zones []string) []string {
+	rank := make(map[string]int, len(zones))
+	for _, zone := range zones {
+		cb, ok := k.Lookup(ZoneKey(service, zone))
+		if !ok {
+			continue
+		}
+		switch cb.State() {
+		case StateHalfOpen:
+			rank[zone] = 1
+		case StateOpened:
+			rank[zone] = 2
+		}
+	}
+
+	preferred := slices.Clone(zones)
+	slices.SortStableFunc(preferred, func(a, b string) int {
+		return rank[a] - rank[b]
+	})
+	return preferred
+}